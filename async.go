@@ -0,0 +1,160 @@
+package logging
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"go.uber.org/zap/zapcore"
+)
+
+const defaultAsyncBufferSize = 1000
+
+// Overflow policies of AsyncOutput.
+const (
+	overflowDropOldest = "drop_oldest"
+	overflowBlock      = "block"
+)
+
+// AsyncOutput queues the entries written to the standard streams, files and
+// writers, a background goroutine writing them, so that a slow output doesn't
+// add latency to the requests. The network outputs already send their
+// entries in the background, and the audit events are still written
+// synchronously. The entries queued are written by Sync, Close and Finalize.
+type AsyncOutput struct {
+	// BufferSize is the number of entries queued per output, 1000 by
+	// default.
+	BufferSize int `json:"buffer_size" yaml:"buffer_size"`
+	// Overflow is what happens to an entry logged when the queue is full:
+	// "drop_oldest", the default, drops the oldest entry queued and "block"
+	// waits for the output to catch up.
+	Overflow string `json:"overflow" yaml:"overflow"`
+}
+
+func (a *AsyncOutput) validate() error {
+	switch a.Overflow {
+	case "", overflowDropOldest, overflowBlock:
+		return nil
+	}
+	return fmt.Errorf("logging: unknown overflow policy %q", a.Overflow)
+}
+
+// asyncWriter queues the entries written, a background goroutine writing
+// them to w.
+type asyncWriter struct {
+	w     zapcore.WriteSyncer
+	size  int
+	block bool
+
+	mu sync.Mutex
+	// cond is signaled whenever the queue, writing or closed changes.
+	cond    *sync.Cond
+	queue   [][]byte
+	dropped int
+	writing bool
+	closed  bool
+	wg      sync.WaitGroup
+}
+
+func newAsyncWriter(w zapcore.WriteSyncer, a *AsyncOutput) *asyncWriter {
+	size := a.BufferSize
+	if size <= 0 {
+		size = defaultAsyncBufferSize
+	}
+	aw := &asyncWriter{w: w, size: size, block: a.Overflow == overflowBlock}
+	aw.cond = sync.NewCond(&aw.mu)
+	aw.wg.Add(1)
+	go aw.run()
+	return aw
+}
+
+// Write queues a copy of p, the encoders reusing their buffers. Once closed,
+// it writes p synchronously.
+func (w *asyncWriter) Write(p []byte) (int, error) {
+	entry := append([]byte(nil), p...)
+	w.mu.Lock()
+	for w.block && len(w.queue) >= w.size && !w.closed {
+		w.cond.Wait()
+	}
+	if w.closed {
+		w.mu.Unlock()
+		return w.w.Write(p)
+	}
+	if len(w.queue) >= w.size {
+		w.queue[0] = nil
+		w.queue = w.queue[1:]
+		w.dropped++
+	}
+	w.queue = append(w.queue, entry)
+	w.cond.Broadcast()
+	w.mu.Unlock()
+	return len(p), nil
+}
+
+func (w *asyncWriter) run() {
+	defer w.wg.Done()
+	for {
+		w.mu.Lock()
+		for len(w.queue) == 0 && !w.closed {
+			w.cond.Wait()
+		}
+		if len(w.queue) == 0 {
+			w.mu.Unlock()
+			return
+		}
+		entries := w.queue
+		w.queue = make([][]byte, 0, len(entries))
+		dropped := w.dropped
+		w.dropped = 0
+		w.writing = true
+		w.cond.Broadcast()
+		w.mu.Unlock()
+
+		if dropped > 0 {
+			outputErrors.Add(uint64(dropped))
+			fmt.Fprintf(os.Stderr, "logging: dropped %d entries, the output can't keep up\n", dropped)
+		}
+		for _, entry := range entries {
+			if _, err := w.w.Write(entry); err != nil {
+				// The entry is written to the standard error output instead.
+				outputErrors.Add(1)
+				fallbackOutput.Write(entry)
+			}
+		}
+
+		w.mu.Lock()
+		w.writing = false
+		w.cond.Broadcast()
+		w.mu.Unlock()
+	}
+}
+
+// Sync waits for the entries queued to be written, then syncs w.
+func (w *asyncWriter) Sync() error {
+	w.mu.Lock()
+	for (len(w.queue) > 0 || w.writing) && !w.closed {
+		w.cond.Wait()
+	}
+	w.mu.Unlock()
+	return w.w.Sync()
+}
+
+// close writes the entries queued and stops the background goroutine.
+func (w *asyncWriter) close() {
+	w.mu.Lock()
+	w.closed = true
+	w.cond.Broadcast()
+	w.mu.Unlock()
+	w.wg.Wait()
+}
+
+// asyncSink returns w queued by an asyncWriter if a is set, registering its
+// closing, which must happen before w is closed.
+func (l *Logger) asyncSink(w zapcore.WriteSyncer, a *AsyncOutput) zapcore.WriteSyncer {
+	if a == nil {
+		return w
+	}
+	aw := newAsyncWriter(w, a)
+	l.closers = append(l.closers, aw.close)
+	return aw
+}
@@ -0,0 +1,131 @@
+package logging
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// gatedWriter records the entries written once its gate is opened.
+type gatedWriter struct {
+	gate chan struct{}
+	mu   sync.Mutex
+	got  []string
+}
+
+func (w *gatedWriter) Write(p []byte) (int, error) {
+	<-w.gate
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.got = append(w.got, string(p))
+	return len(p), nil
+}
+
+func (w *gatedWriter) Sync() error { return nil }
+
+func (w *gatedWriter) written() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]string(nil), w.got...)
+}
+
+// waitWriting waits for the background goroutine to take the queued entries.
+func waitWriting(t *testing.T, w *asyncWriter) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		w.mu.Lock()
+		writing := w.writing
+		w.mu.Unlock()
+		if writing {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the queued entries are not being written")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestAsyncWriterDropOldest(t *testing.T) {
+	out := &gatedWriter{gate: make(chan struct{})}
+	w := newAsyncWriter(out, &AsyncOutput{BufferSize: 2})
+	w.Write([]byte("1"))
+	waitWriting(t, w)
+	// The writer is stuck on "1", the queue holds 2 entries.
+	for _, entry := range []string{"2", "3", "4"} {
+		if n, err := w.Write([]byte(entry)); n != 1 || err != nil {
+			t.Fatalf("Write(%q) = %d, %v", entry, n, err)
+		}
+	}
+	close(out.gate)
+	if err := w.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	w.close()
+	if got := strings.Join(out.written(), ","); got != "1,3,4" {
+		t.Errorf("written %s, want 1,3,4", got)
+	}
+}
+
+func TestAsyncWriterBlock(t *testing.T) {
+	out := &gatedWriter{gate: make(chan struct{})}
+	w := newAsyncWriter(out, &AsyncOutput{BufferSize: 1, Overflow: "block"})
+	w.Write([]byte("1"))
+	waitWriting(t, w)
+	w.Write([]byte("2"))
+
+	written := make(chan struct{})
+	go func() {
+		w.Write([]byte("3"))
+		close(written)
+	}()
+	select {
+	case <-written:
+		t.Fatal("Write didn't block on a full queue")
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(out.gate)
+	<-written
+	w.close()
+	if got := strings.Join(out.written(), ","); got != "1,2,3" {
+		t.Errorf("written %s, want 1,2,3", got)
+	}
+}
+
+func TestAsyncWriterClosed(t *testing.T) {
+	out := &gatedWriter{gate: make(chan struct{})}
+	close(out.gate)
+	w := newAsyncWriter(out, &AsyncOutput{})
+	w.close()
+	w.Write([]byte("late"))
+	if got := strings.Join(out.written(), ","); got != "late" {
+		t.Errorf("written %s, want the entry written synchronously", got)
+	}
+}
+
+func TestAsync(t *testing.T) {
+	buf := &bytes.Buffer{}
+	l, err := New(&Config{ProjectID: "test", Level: LevelDebug, Output: buf, Async: &AsyncOutput{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Info(context.Background(), "queued")
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	got := entries(t, buf)
+	if len(got) != 1 || got[0]["message"] != "queued" {
+		t.Errorf("got %v, want the entry queued", got)
+	}
+}
+
+func TestAsyncUnknownOverflow(t *testing.T) {
+	if _, err := New(&Config{ProjectID: "test", Async: &AsyncOutput{Overflow: "drop_newest"}}); err == nil {
+		t.Error("got no error for an unknown overflow policy")
+	}
+}
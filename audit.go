@@ -0,0 +1,126 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/blendle/zapdriver"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"golang.org/x/net/context"
+)
+
+// The outcomes of audit events.
+const (
+	AuditSuccess = "success"
+	AuditFailure = "failure"
+	AuditDenied  = "denied"
+)
+
+// AuditEvent is an action recorded for compliance by Audit.
+type AuditEvent struct {
+	// Actor is the user or service that acted, it defaults to the user ID
+	// of the context.
+	Actor string
+	// Action is what was done, e.g. "invoice.update".
+	Action string
+	// Resource is what it was done to, e.g. "invoices/42".
+	Resource string
+	// Outcome is AuditSuccess, AuditFailure, AuditDenied or another result.
+	Outcome string
+	// Diff optionally describes the changes, e.g. the old and new values of
+	// the fields updated.
+	Diff interface{}
+}
+
+// MarshalLogObject implements zapcore.ObjectMarshaler.
+func (e AuditEvent) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("actor", e.Actor)
+	enc.AddString("action", e.Action)
+	enc.AddString("resource", e.Resource)
+	enc.AddString("outcome", e.Outcome)
+	if e.Diff != nil {
+		return enc.AddReflected("diff", e.Diff)
+	}
+	return nil
+}
+
+// streamLabel labels the audit and security events with their stream.
+const streamLabel = "stream"
+
+// Audit logs an audit event with the package level logger, see
+// Logger.Audit.
+func Audit(ctx context.Context, e AuditEvent) error {
+	return std().Audit(ctx, e)
+}
+
+// Audit logs an audit event as the audit field of an entry labeled
+// stream=audit, written to Config.AuditOutput. Audit events are never
+// filtered by level nor sampled. The actor, action, resource and outcome are
+// mandatory, an event missing one is not logged.
+func (l *Logger) Audit(ctx context.Context, e AuditEvent) error {
+	ctx = orBackground(ctx)
+	if e.Actor == "" {
+		e.Actor, _ = l.loggedUserID(ctx)
+	}
+	for _, f := range []struct{ name, value string }{
+		{"actor", e.Actor},
+		{"action", e.Action},
+		{"resource", e.Resource},
+		{"outcome", e.Outcome},
+	} {
+		if f.value == "" {
+			return fmt.Errorf("logging: audit event without %s", f.name)
+		}
+	}
+	fields, _, _ := l.entryFields(ctx)
+	fields = append(fields, zapdriver.Label(streamLabel, "audit"), zap.Object("audit", e))
+	msg, fields := l.redactor.entry(e.Action, fields)
+	msg, fields = l.truncator.entry(msg, fields)
+	l.audit().Info(msg, fields...)
+	return nil
+}
+
+// audit returns the logger of the audit and security events, the fallback
+// zap logger if l isn't configured.
+func (l *Logger) audit() *zap.Logger {
+	if l.auditLogger == nil {
+		return fallbackZapLogger()
+	}
+	return l.auditLogger
+}
+
+// newAuditLogger returns the logger of the audit and security events,
+// encoding them as the entries of config, enabled at all levels and not
+// sampled.
+func (l *Logger) newAuditLogger(config zap.Config, c *Config, opts ...zap.Option) (*zap.Logger, error) {
+	var out *Output
+	if c != nil {
+		out = c.AuditOutput
+	}
+	format := config.Encoding
+	if out != nil && out.Format != "" {
+		format = out.Format
+	}
+	enc, err := newEncoder(format, config.EncoderConfig)
+	if err != nil {
+		return nil, err
+	}
+	var sink zapcore.WriteSyncer
+	switch {
+	case out != nil && out.Path != "":
+		var closeSink func()
+		if sink, closeSink, err = zap.Open(out.Path); err != nil {
+			return nil, err
+		}
+		l.closers = append(l.closers, closeSink)
+	case c != nil && c.Output != nil:
+		sink = zapcore.Lock(zapcore.AddSync(c.Output))
+	default:
+		// Stderr is unbuffered, and can't be synced when it's a pipe.
+		sink = zapcore.Lock(zapcore.AddSync(struct{ io.Writer }{os.Stderr}))
+	}
+	opts = append(opts, zap.WithClock(zapClock{l.clock}))
+	return zap.New(zapcore.NewCore(enc, sink, zapcore.DebugLevel), opts...), nil
+}
@@ -0,0 +1,88 @@
+package logging
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/net/context"
+)
+
+func TestAudit(t *testing.T) {
+	l, buf := newTestLogger(t, &Config{
+		Level:    LevelError,
+		Sampling: &Sampling{Initial: 1, Thereafter: 1000},
+	})
+	ctx := WithUserID(WithRequestID(context.Background(), "4bf92f3577b34da6a3ce929d0e0e4736"), "u-1")
+	for i := 0; i < 3; i++ {
+		err := l.Audit(ctx, AuditEvent{
+			Action:   "invoice.update",
+			Resource: "invoices/42",
+			Outcome:  AuditSuccess,
+			Diff:     map[string]interface{}{"amount": []int{10, 12}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	l.Info(ctx, "filtered")
+
+	got := entries(t, buf)
+	if len(got) != 3 {
+		t.Fatalf("got %d entries, want the 3 audit events", len(got))
+	}
+	e := got[0]
+	audit, _ := e["audit"].(map[string]interface{})
+	if e["severity"] != "INFO" || e["message"] != "invoice.update" || audit["actor"] != "u-1" || audit["resource"] != "invoices/42" ||
+		audit["outcome"] != "success" || audit["diff"] == nil {
+		t.Errorf("got %v, want the audit event", e)
+	}
+	labels := labels(e)
+	if labels["stream"] != "audit" || labels["request_id"] != "4bf92f3577b34da6a3ce929d0e0e4736" || labels["user_id"] != "u-1" {
+		t.Errorf("got labels %v, want the audit stream and request labels", labels)
+	}
+}
+
+func TestAuditMandatoryFields(t *testing.T) {
+	l, buf := newTestLogger(t, &Config{})
+	for _, e := range []AuditEvent{
+		{Action: "invoice.update", Resource: "invoices/42", Outcome: AuditSuccess},
+		{Actor: "u-1", Resource: "invoices/42", Outcome: AuditSuccess},
+		{Actor: "u-1", Action: "invoice.update", Outcome: AuditSuccess},
+		{Actor: "u-1", Action: "invoice.update", Resource: "invoices/42"},
+	} {
+		if err := l.Audit(context.Background(), e); err == nil {
+			t.Errorf("got no error for %+v", e)
+		}
+	}
+	if buf.Len() != 0 {
+		t.Errorf("got %q, want no entry", buf)
+	}
+}
+
+func TestAuditOutput(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	l, buf := newTestLogger(t, &Config{AuditOutput: &Output{Path: path}})
+	ctx := context.Background()
+	l.Info(ctx, "regular")
+	if err := l.Audit(ctx, AuditEvent{Actor: "svc", Action: "key.rotate", Resource: "keys/1", Outcome: AuditDenied}); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Close(); err != nil {
+		t.Log(err)
+	}
+
+	got := entries(t, buf)
+	if len(got) != 1 || got[0]["message"] != "regular" {
+		t.Errorf("got %v, want the regular entry only", got)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	audit := entries(t, bytes.NewBuffer(data))
+	if len(audit) != 1 || audit[0]["message"] != "key.rotate" {
+		t.Errorf("got %v, want the audit event", audit)
+	}
+}
@@ -0,0 +1,240 @@
+package logging
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	defaultBatchSize     = 100
+	defaultBatchInterval = time.Second
+	// batchQueueFactor bounds the entries waiting to be sent to this many
+	// batches, newer entries are dropped beyond.
+	batchQueueFactor = 10
+)
+
+// batcher gathers items and sends them in batches, once a batch is full or
+// every interval, from a background goroutine.
+type batcher[T any] struct {
+	mu      sync.Mutex
+	pending []T
+	dropped int
+	size    int
+
+	sendMu sync.Mutex
+	send   func([]T) error
+	// line returns the encoded entry of an item, written to the standard
+	// error output when its batch fails to be sent. Nil only counts the
+	// items lost, for outputs written to in addition to the others.
+	line func(T) []byte
+
+	kick      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+func newBatcher[T any](size int, interval time.Duration, send func([]T) error, line func(T) []byte) *batcher[T] {
+	if size <= 0 {
+		size = defaultBatchSize
+	}
+	if interval <= 0 {
+		interval = defaultBatchInterval
+	}
+	b := &batcher[T]{
+		size: size,
+		send: send,
+		line: line,
+		kick: make(chan struct{}, 1),
+		done: make(chan struct{}),
+	}
+	b.wg.Add(1)
+	go b.run(interval)
+	return b
+}
+
+func (b *batcher[T]) run(interval time.Duration) {
+	defer b.wg.Done()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-b.done:
+			return
+		case <-ticker.C:
+		case <-b.kick:
+		}
+		if err := b.flush(); err != nil {
+			// The items were counted and fell back already.
+			fmt.Fprintf(os.Stderr, "logging: %v\n", err)
+		}
+	}
+}
+
+// add queues an item, dropping it if too many are waiting to be sent.
+func (b *batcher[T]) add(item T) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.pending) >= batchQueueFactor*b.size {
+		b.dropped++
+		return
+	}
+	b.pending = append(b.pending, item)
+	if len(b.pending) >= b.size {
+		select {
+		case b.kick <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// flush sends the pending items, the items of the batches failing to be
+// sent falling back to the standard error output. It returns the first error.
+func (b *batcher[T]) flush() error {
+	b.sendMu.Lock()
+	defer b.sendMu.Unlock()
+	var firstErr error
+	for {
+		b.mu.Lock()
+		n := len(b.pending)
+		if n > b.size {
+			n = b.size
+		}
+		batch := b.pending[:n:n]
+		b.pending = b.pending[n:]
+		dropped := b.dropped
+		b.dropped = 0
+		b.mu.Unlock()
+
+		if dropped > 0 {
+			outputErrors.Add(uint64(dropped))
+			fmt.Fprintf(os.Stderr, "logging: dropped %d entries, the output can't keep up\n", dropped)
+		}
+		if n == 0 {
+			return firstErr
+		}
+		if err := b.send(batch); err != nil {
+			b.fallBack(batch)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+}
+
+// fallBack counts the items of a batch that failed to be sent, and writes
+// their entries to the standard error output.
+func (b *batcher[T]) fallBack(batch []T) {
+	outputErrors.Add(uint64(len(batch)))
+	if b.line == nil {
+		return
+	}
+	for _, item := range batch {
+		fallbackOutput.Write(append(b.line(item), '\n'))
+	}
+}
+
+// close stops sending in the background and sends the pending items. It may
+// be called again.
+func (b *batcher[T]) close() error {
+	b.closeOnce.Do(func() { close(b.done) })
+	b.wg.Wait()
+	return b.flush()
+}
+
+// sinkCore is a zapcore.Core encoding entries and handing them with their
+// labels to a sink, typically queueing them in a batcher.
+type sinkCore struct {
+	zapcore.LevelEnabler
+	enc    zapcore.Encoder
+	fields []zapcore.Field
+	write  func(ent zapcore.Entry, labels map[string]string, line []byte) error
+	sync   func() error
+}
+
+func (c *sinkCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := *c
+	clone.enc = c.enc.Clone()
+	for _, f := range fields {
+		f.AddTo(clone.enc)
+	}
+	clone.fields = append(c.fields[:len(c.fields):len(c.fields)], fields...)
+	return &clone
+}
+
+func (c *sinkCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *sinkCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	if !c.Enabled(ent.Level) {
+		return nil
+	}
+	buf, err := c.enc.EncodeEntry(ent, fields)
+	if err != nil {
+		return err
+	}
+	defer buf.Free()
+	buf.TrimNewline()
+	line := append([]byte(nil), buf.Bytes()...)
+	return c.write(ent, entryLabels(c.fields, fields), line)
+}
+
+func (c *sinkCore) Sync() error {
+	if c.sync == nil {
+		return nil
+	}
+	return c.sync()
+}
+
+// zapdriverPrefix prefixes the keys of the fields specific to Cloud Logging,
+// zapdriverLabelsKey is the key of the object zapdriver gathers labels in.
+const (
+	zapdriverPrefix    = "logging.googleapis.com/"
+	zapdriverLabelsKey = zapdriverPrefix + "labels"
+)
+
+// entryLabels returns the labels among the fields, whether they are label
+// fields or already gathered by zapdriver.
+func entryLabels(fieldSets ...[]zapcore.Field) map[string]string {
+	labels := map[string]string{}
+	for _, fields := range fieldSets {
+		for _, f := range fields {
+			switch {
+			case f.Type == zapcore.StringType && strings.HasPrefix(f.Key, "labels."):
+				labels[strings.TrimPrefix(f.Key, "labels.")] = f.String
+			case f.Key == zapdriverLabelsKey:
+				m := zapcore.NewMapObjectEncoder()
+				f.AddTo(m)
+				obj, _ := m.Fields[f.Key].(map[string]interface{})
+				for k, v := range obj {
+					if s, ok := v.(string); ok {
+						labels[k] = s
+					}
+				}
+			}
+		}
+	}
+	return labels
+}
+
+// plainEncoderConfig returns the encoder configuration for machine-read
+// sinks, with plain level names.
+func plainEncoderConfig(cfg zapcore.EncoderConfig) zapcore.EncoderConfig {
+	cfg.EncodeLevel = zapcore.CapitalLevelEncoder
+	return cfg
+}
+
+// validRequestID reports whether the request ID is set, the request ID of
+// entries logged outside of a trace being all zeros.
+func validRequestID(requestID string) bool {
+	return strings.Trim(requestID, "0") != ""
+}
@@ -0,0 +1,24 @@
+package logging
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBatcherCloseTwice(t *testing.T) {
+	var sent []int
+	b := newBatcher(10, time.Hour, func(batch []int) error {
+		sent = append(sent, batch...)
+		return nil
+	}, nil)
+	b.add(1)
+	if err := b.close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.close(); err != nil {
+		t.Fatal(err)
+	}
+	if len(sent) != 1 {
+		t.Errorf("sent %v, want the pending item once", sent)
+	}
+}
@@ -0,0 +1,205 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"golang.org/x/net/context"
+)
+
+const defaultBodyMaxBytes = 4096
+
+// truncatedValue replaces the bodies that can't be redacted since they were
+// truncated.
+const truncatedValue = "[TRUNCATED]"
+
+var defaultBodyContentTypes = []string{"application/json", "application/x-www-form-urlencoded", "text/plain"}
+
+// BodyCapture logs the request and response bodies with the access logs of
+// the middlewares, as the request_body and response_body fields.
+type BodyCapture struct {
+	// MaxBytes is the size of the bodies logged, 4 KiB by default.
+	MaxBytes int `json:"max_bytes" yaml:"max_bytes"`
+	// ContentTypes lists the media types of the bodies logged, by default
+	// JSON, forms and plain text. A type ending with / matches all its
+	// subtypes, e.g. "text/".
+	ContentTypes []string `json:"content_types" yaml:"content_types"`
+	// RedactKeys lists the JSON and form keys redacted in addition to the
+	// common credentials and secrets, whatever their case.
+	RedactKeys []string `json:"redact_keys" yaml:"redact_keys"`
+}
+
+// bodyCapture is the body capture of a logger.
+type bodyCapture struct {
+	maxBytes     int
+	contentTypes []string
+	redact       map[string]struct{}
+}
+
+func newBodyCapture(c *BodyCapture) *bodyCapture {
+	if c == nil {
+		return nil
+	}
+	b := &bodyCapture{
+		maxBytes:     c.MaxBytes,
+		contentTypes: c.ContentTypes,
+		redact:       map[string]struct{}{},
+	}
+	if b.maxBytes <= 0 {
+		b.maxBytes = defaultBodyMaxBytes
+	}
+	if len(b.contentTypes) == 0 {
+		b.contentTypes = defaultBodyContentTypes
+	}
+	for _, keys := range [][]string{defaultRedactKeys, c.RedactKeys} {
+		for _, k := range keys {
+			b.redact[strings.ToLower(k)] = struct{}{}
+		}
+	}
+	return b
+}
+
+// captures reports whether bodies of the content type are logged.
+func (b *bodyCapture) captures(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, t := range b.contentTypes {
+		if mediaType == t || (strings.HasSuffix(t, "/") && strings.HasPrefix(mediaType, t)) {
+			return true
+		}
+	}
+	return false
+}
+
+// field returns the field logging a body of the content type, redacted,
+// unless it isn't captured.
+func (b *bodyCapture) field(ctx context.Context, l *Logger, key, contentType string, body []byte, truncated bool) (zapcore.Field, bool) {
+	if len(body) == 0 || !b.captures(contentType) {
+		return zap.Skip(), false
+	}
+	redact := func(k string) bool {
+		if _, ok := b.redact[strings.ToLower(k)]; ok || l.redactor.redactsKey(k) {
+			return true
+		}
+		_, ok := l.redactKeysFor(ctx)[k]
+		return ok
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		var v interface{}
+		if err := json.Unmarshal(body, &v); err != nil {
+			// Secrets can't be found in a partial document.
+			return zap.String(key, truncatedValue), true
+		}
+		redacted, err := json.Marshal(redactJSON(v, redact))
+		if err != nil {
+			return zap.Skip(), false
+		}
+		return zap.String(key, string(redacted)), true
+	case mediaType == "application/x-www-form-urlencoded":
+		if truncated {
+			return zap.String(key, truncatedValue), true
+		}
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			return zap.String(key, truncatedValue), true
+		}
+		for k := range values {
+			if redact(k) {
+				values[k] = []string{redactedValue}
+			}
+		}
+		return zap.String(key, values.Encode()), true
+	default:
+		return zap.String(key, string(body)), true
+	}
+}
+
+// truncate returns the beginning of a body.
+func (b *bodyCapture) truncate(body []byte) ([]byte, bool) {
+	if len(body) > b.maxBytes {
+		return body[:b.maxBytes], true
+	}
+	return body, false
+}
+
+// redactJSON replaces the values of the keys to redact in a decoded JSON
+// value.
+func redactJSON(v interface{}, redact func(key string) bool) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, e := range v {
+			if redact(k) {
+				v[k] = redactedValue
+			} else {
+				v[k] = redactJSON(e, redact)
+			}
+		}
+	case []interface{}:
+		for i, e := range v {
+			v[i] = redactJSON(e, redact)
+		}
+	}
+	return v
+}
+
+// captureRequestBody reads the beginning of the body of the request, leaving
+// it unchanged for the handler.
+func (b *bodyCapture) captureRequestBody(body io.ReadCloser) ([]byte, bool, io.ReadCloser) {
+	if body == nil || body == http.NoBody {
+		return nil, false, body
+	}
+	head, err := io.ReadAll(io.LimitReader(body, int64(b.maxBytes)+1))
+	rest := struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(head), body), body}
+	if err != nil {
+		return nil, false, rest
+	}
+	head, truncated := b.truncate(head)
+	return head, truncated, rest
+}
+
+// capturedBody captures the beginning of a response body.
+type capturedBody struct {
+	body      bytes.Buffer
+	max       int
+	truncated bool
+}
+
+func (c *capturedBody) capture(p []byte) {
+	if n := c.max - c.body.Len(); n < len(p) {
+		p = p[:n]
+		c.truncated = true
+	}
+	c.body.Write(p)
+}
+
+// bodyWriter captures the beginning of the response body written through a
+// gin writer.
+type bodyWriter struct {
+	gin.ResponseWriter
+	capturedBody
+}
+
+func (w *bodyWriter) Write(p []byte) (int, error) {
+	w.capture(p)
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *bodyWriter) WriteString(s string) (int, error) {
+	w.capture([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,97 @@
+package logging
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestBodyCapture(t *testing.T) {
+	l, buf := newTestLogger(t, &Config{
+		RedactKeys:  []string{"email"},
+		BodyCapture: &BodyCapture{MaxBytes: 64, RedactKeys: []string{"pin"}},
+	})
+	r := gin.New()
+	r.Use(l.RequestLogger())
+	r.POST("/users", func(c *gin.Context) {
+		var body map[string]interface{}
+		if err := c.BindJSON(&body); err != nil {
+			t.Errorf("handler: %v", err)
+		}
+		if body["password"] != "hunter2" {
+			t.Errorf("handler got %v, want the unredacted body", body)
+		}
+		c.JSON(201, gin.H{"id": 1, "Token": "t", "email": "a@example.com"})
+	})
+	r.POST("/upload", func(c *gin.Context) {
+		c.Data(200, "image/png", []byte("png"))
+	})
+	r.POST("/large", func(c *gin.Context) {
+		c.JSON(200, gin.H{"data": strings.Repeat("x", 100)})
+	})
+
+	post := func(path, contentType, body string) {
+		req := httptest.NewRequest("POST", path, strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	post("/users", "application/json", `{"name":"a","password":"hunter2","card":{"PIN":"1234"}}`)
+	post("/upload", "image/png", "png")
+	post("/large", "application/x-www-form-urlencoded", "name=a&password=hunter2")
+
+	got := entries(t, buf)
+	if len(got) != 3 {
+		t.Fatalf("got %d entries, want 3", len(got))
+	}
+	var reqBody, resBody map[string]interface{}
+	if err := json.Unmarshal([]byte(got[0]["request_body"].(string)), &reqBody); err != nil {
+		t.Fatal(err)
+	}
+	if reqBody["name"] != "a" || reqBody["password"] != redactedValue || reqBody["card"].(map[string]interface{})["PIN"] != redactedValue {
+		t.Errorf("got request body %v, want the password and pin redacted", reqBody)
+	}
+	if err := json.Unmarshal([]byte(got[0]["response_body"].(string)), &resBody); err != nil {
+		t.Fatal(err)
+	}
+	if resBody["id"] != float64(1) || resBody["Token"] != redactedValue || resBody["email"] != redactedValue {
+		t.Errorf("got response body %v, want the token and email redacted", resBody)
+	}
+	if _, ok := got[1]["request_body"]; ok {
+		t.Errorf("got request body %v, want images not logged", got[1]["request_body"])
+	}
+	if body := got[2]["request_body"]; body != "name=a&password=%5BREDACTED%5D" {
+		t.Errorf("got request body %v, want the form with the password redacted", body)
+	}
+	if body := got[2]["response_body"]; body != truncatedValue {
+		t.Errorf("got response body %v, want it truncated", body)
+	}
+}
+
+func TestFiberBodyCapture(t *testing.T) {
+	l, buf := newTestLogger(t, &Config{BodyCapture: &BodyCapture{}})
+	app := fiber.New()
+	app.Use(l.FiberRequestLogger())
+	app.Post("/login", func(c *fiber.Ctx) error {
+		return c.JSON(fiber.Map{"access_token": "t", "user": "a"})
+	})
+	req := httptest.NewRequest("POST", "/login", strings.NewReader(`{"user":"a","password":"p"}`))
+	req.Header.Set("Content-Type", "application/json")
+	if _, err := app.Test(req); err != nil {
+		t.Fatal(err)
+	}
+
+	got := entries(t, buf)
+	if len(got) != 1 {
+		t.Fatalf("got %d entries, want 1", len(got))
+	}
+	if body := got[0]["request_body"]; body != `{"password":"[REDACTED]","user":"a"}` {
+		t.Errorf("got request body %v, want the password redacted", body)
+	}
+	if body := got[0]["response_body"]; body != `{"access_token":"[REDACTED]","user":"a"}` {
+		t.Errorf("got response body %v, want the token redacted", body)
+	}
+}
@@ -0,0 +1,24 @@
+package logging
+
+import "runtime/debug"
+
+// buildInfoLabels returns the labels of the module version, VCS revision and
+// dirty flag of the binary, leaving out the ones it wasn't built with.
+func buildInfoLabels(info *debug.BuildInfo) map[string]string {
+	labels := map[string]string{}
+	if info == nil {
+		return labels
+	}
+	if v := info.Main.Version; v != "" && v != "(devel)" {
+		labels["module_version"] = v
+	}
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			labels["vcs_revision"] = s.Value
+		case "vcs.modified":
+			labels["vcs_modified"] = s.Value
+		}
+	}
+	return labels
+}
@@ -0,0 +1,51 @@
+package logging
+
+import (
+	"runtime/debug"
+	"testing"
+
+	"golang.org/x/net/context"
+)
+
+func TestBuildInfoLabels(t *testing.T) {
+	got := buildInfoLabels(&debug.BuildInfo{
+		Main: debug.Module{Path: "example.com/api", Version: "v1.4.0"},
+		Settings: []debug.BuildSetting{
+			{Key: "vcs", Value: "git"},
+			{Key: "vcs.revision", Value: "9f2c1e7"},
+			{Key: "vcs.modified", Value: "true"},
+		},
+	})
+	want := map[string]string{"module_version": "v1.4.0", "vcs_revision": "9f2c1e7", "vcs_modified": "true"}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("got %s %q, want %q", k, got[k], v)
+		}
+	}
+	if got := buildInfoLabels(&debug.BuildInfo{Main: debug.Module{Version: "(devel)"}}); len(got) != 0 {
+		t.Errorf("got %v for a development build, want no labels", got)
+	}
+}
+
+func TestBuildInfo(t *testing.T) {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		t.Skip("no build info")
+	}
+	l, buf := newTestLogger(t, &Config{BuildInfo: true})
+	l.Info(context.Background(), "started")
+
+	got := entries(t, buf)
+	if len(got) != 1 {
+		t.Fatalf("got %d entries, want 1", len(got))
+	}
+	lbls := labels(got[0])
+	for k, v := range buildInfoLabels(info) {
+		if lbls[k] != v {
+			t.Errorf("got %s %q, want %q", k, lbls[k], v)
+		}
+	}
+}
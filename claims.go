@@ -0,0 +1,143 @@
+package logging
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/context"
+)
+
+// Claims configures the middlewares logging the user ID and scope of the
+// requests from their JWT claims.
+type Claims struct {
+	// UserID and Scope are the claims of the user ID and scope, "sub" and
+	// "scope" by default. A scope given as a list is logged space-separated.
+	UserID string
+	Scope  string
+	// ContextKey is the key an authentication middleware stores the
+	// validated claims under, in the gin context or the request context, as
+	// a map or a struct marshaled to JSON with the claim names. If nil, the
+	// claims are read from the bearer token of the Authorization header,
+	// without validating it: the middleware must then be installed after
+	// the middleware validating the token.
+	ContextKey interface{}
+}
+
+// ClaimsLogger provides a gin middleware setting the user ID and scope of
+// the requests from their claims, as WithUserID and WithScope.
+func ClaimsLogger(c Claims) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var value interface{}
+		if key, ok := c.ContextKey.(string); ok {
+			value, _ = ctx.Get(key)
+		}
+		ctx.Request = ctx.Request.WithContext(c.withClaims(ctx.Request, value))
+		ctx.Next()
+	}
+}
+
+// ClaimsMiddleware provides a net/http middleware setting the user ID and
+// scope of the requests from their claims, as ClaimsLogger.
+func ClaimsMiddleware(c Claims) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(c.withClaims(r, nil)))
+		})
+	}
+}
+
+// withClaims returns a copy of the context of the request carrying the user
+// ID and scope of the claims, value being the claims found in the context of
+// a framework if any.
+func (c Claims) withClaims(r *http.Request, value interface{}) context.Context {
+	ctx := r.Context()
+	if value == nil && c.ContextKey != nil {
+		value = ctx.Value(c.ContextKey)
+	}
+	var claims map[string]interface{}
+	switch {
+	case value != nil:
+		claims = claimsMap(value)
+	case c.ContextKey == nil:
+		claims = bearerClaims(r.Header.Get("Authorization"))
+	}
+	if claims == nil {
+		return ctx
+	}
+	userIDClaim, scopeClaim := c.UserID, c.Scope
+	if userIDClaim == "" {
+		userIDClaim = "sub"
+	}
+	if scopeClaim == "" {
+		scopeClaim = "scope"
+	}
+	if v, ok := claims[userIDClaim]; ok && v != nil {
+		ctx = WithUserID(ctx, claimString(v))
+	}
+	if v, ok := claims[scopeClaim]; ok && v != nil {
+		ctx = WithScope(ctx, claimString(v))
+	}
+	return ctx
+}
+
+// claimsMap returns the claims of a map or struct as a map.
+func claimsMap(value interface{}) map[string]interface{} {
+	if m, ok := value.(map[string]interface{}); ok {
+		return m
+	}
+	b, err := json.Marshal(value)
+	if err != nil {
+		return nil
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil
+	}
+	return m
+}
+
+// bearerClaims decodes the claims of the JWT of an Authorization header,
+// without validating it.
+func bearerClaims(authorization string) map[string]interface{} {
+	token, ok := strings.CutPrefix(authorization, "Bearer ")
+	if !ok {
+		return nil
+	}
+	parts := strings.Split(strings.TrimSpace(token), ".")
+	if len(parts) != 3 {
+		return nil
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return nil
+	}
+	var claims map[string]interface{}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil
+	}
+	return claims
+}
+
+// claimString formats a claim, lists being space-separated.
+func claimString(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case []interface{}:
+		s := make([]string, len(v))
+		for i, e := range v {
+			s[i] = claimString(e)
+		}
+		return strings.Join(s, " ")
+	case float64:
+		// JSON numbers, such as numeric user IDs.
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
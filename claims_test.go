@@ -0,0 +1,79 @@
+package logging
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/context"
+)
+
+func testToken(payload string) string {
+	enc := base64.RawURLEncoding
+	return "Bearer " + enc.EncodeToString([]byte(`{"alg":"RS256"}`)) + "." + enc.EncodeToString([]byte(payload)) + ".c2ln"
+}
+
+func TestClaimsLogger(t *testing.T) {
+	type registeredClaims struct {
+		Subject string   `json:"sub"`
+		Scopes  []string `json:"scp"`
+	}
+	for _, tt := range []struct {
+		name          string
+		claims        Claims
+		authorization string
+		set           func(c *gin.Context)
+		userID, scope interface{}
+	}{
+		{"bearer", Claims{}, testToken(`{"sub":"u-1","scope":"read write"}`), nil, "u-1", "read write"},
+		{"numeric", Claims{}, testToken(`{"sub":1234567}`), nil, "1234567", nil},
+		{"custom claims", Claims{UserID: "uid", Scope: "scp"}, testToken(`{"uid":"u-2","scp":["a","b"]}`), nil, "u-2", "a b"},
+		{"not a jwt", Claims{}, "Bearer opaque", nil, nil, nil},
+		{"basic", Claims{}, "Basic dTpw", nil, nil, nil},
+		{"gin key", Claims{ContextKey: "claims", Scope: "scp"}, testToken(`{"sub":"ignored"}`), func(c *gin.Context) {
+			c.Set("claims", &registeredClaims{Subject: "u-3", Scopes: []string{"admin"}})
+		}, "u-3", "admin"},
+		{"gin key unset", Claims{ContextKey: "claims"}, testToken(`{"sub":"ignored"}`), nil, nil, nil},
+	} {
+		l, buf := newTestLogger(t, &Config{})
+		r := gin.New()
+		r.Use(l.RequestLogger())
+		if tt.set != nil {
+			r.Use(tt.set)
+		}
+		r.Use(ClaimsLogger(tt.claims))
+		r.GET("/", func(c *gin.Context) {
+			l.Info(c.Request.Context(), "handling")
+		})
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Authorization", tt.authorization)
+		r.ServeHTTP(httptest.NewRecorder(), req)
+
+		got := entries(t, buf)
+		if len(got) != 2 {
+			t.Fatalf("%s: got %d entries, want 2", tt.name, len(got))
+		}
+		labels := labels(got[0])
+		if labels["user_id"] != tt.userID || labels["scope"] != tt.scope {
+			t.Errorf("%s: got user ID %v and scope %v, want %v and %v", tt.name, labels["user_id"], labels["scope"], tt.userID, tt.scope)
+		}
+	}
+}
+
+func TestClaimsMiddleware(t *testing.T) {
+	l, buf := newTestLogger(t, &Config{})
+	type claimsKey struct{}
+	h := ClaimsMiddleware(Claims{ContextKey: claimsKey{}})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		l.Info(r.Context(), "handling")
+	}))
+	req := httptest.NewRequest("GET", "/", nil)
+	req = req.WithContext(context.WithValue(req.Context(), claimsKey{}, map[string]interface{}{"sub": "u-4"}))
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	got := entries(t, buf)
+	if len(got) != 1 || labels(got[0])["user_id"] != "u-4" {
+		t.Errorf("got %v, want the user ID of the context claims", got)
+	}
+}
@@ -0,0 +1,132 @@
+package logging
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+const forwardedForHeader = "X-Forwarded-For"
+
+// ClientIP configures how the middlewares resolve the IP of the clients
+// logged as remote_ip. The headers are only trusted on requests from the
+// trusted proxies, the remote address being logged otherwise.
+type ClientIP struct {
+	// TrustedProxies lists the addresses or CIDR ranges of the proxies in
+	// front of the service, e.g. "10.0.0.0/8".
+	TrustedProxies []string `json:"trusted_proxies" yaml:"trusted_proxies"`
+	// Headers lists the headers carrying the client IP by precedence, e.g.
+	// CF-Connecting-IP, True-Client-IP, X-Real-IP and X-Forwarded-For. It
+	// defaults to X-Forwarded-For.
+	Headers []string `json:"headers" yaml:"headers"`
+	// ForwardedDepth is the position of the client IP in X-Forwarded-For
+	// from its end, 1 being the last address. By default the addresses of
+	// trusted proxies are skipped from the end.
+	ForwardedDepth int `json:"forwarded_depth" yaml:"forwarded_depth"`
+}
+
+// clientIPResolver resolves the IP of clients, a nil resolver trusting no
+// header.
+type clientIPResolver struct {
+	trusted []*net.IPNet
+	headers []string
+	depth   int
+}
+
+func newClientIPResolver(c *ClientIP) (*clientIPResolver, error) {
+	if c == nil {
+		return nil, nil
+	}
+	r := &clientIPResolver{depth: c.ForwardedDepth}
+	for _, proxy := range c.TrustedProxies {
+		if !strings.Contains(proxy, "/") {
+			if ip := net.ParseIP(proxy); ip != nil && ip.To4() != nil {
+				proxy += "/32"
+			} else {
+				proxy += "/128"
+			}
+		}
+		_, ipNet, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("logging: invalid trusted proxy %q", proxy)
+		}
+		r.trusted = append(r.trusted, ipNet)
+	}
+	for _, name := range c.Headers {
+		r.headers = append(r.headers, http.CanonicalHeaderKey(name))
+	}
+	if len(r.headers) == 0 {
+		r.headers = []string{forwardedForHeader}
+	}
+	return r, nil
+}
+
+// resolve returns the IP of the client of a request from remoteAddr, the
+// headers being read only if it is a trusted proxy.
+func (r *clientIPResolver) resolve(header func(string) string, remoteAddr string) string {
+	peer := remoteAddr
+	if host, _, err := net.SplitHostPort(remoteAddr); err == nil {
+		peer = host
+	}
+	if r == nil || !r.trusts(peer) {
+		return peer
+	}
+	for _, name := range r.headers {
+		value := header(name)
+		if value == "" {
+			continue
+		}
+		if name == forwardedForHeader {
+			if ip := r.forwarded(value); ip != "" {
+				return ip
+			}
+			continue
+		}
+		if ip := net.ParseIP(strings.TrimSpace(value)); ip != nil {
+			return ip.String()
+		}
+	}
+	return peer
+}
+
+// forwarded returns the client IP of an X-Forwarded-For header, or an empty
+// string if the header is invalid.
+func (r *clientIPResolver) forwarded(value string) string {
+	chain := strings.Split(value, ",")
+	if r.depth > 0 {
+		if r.depth > len(chain) {
+			return ""
+		}
+		if ip := net.ParseIP(strings.TrimSpace(chain[len(chain)-r.depth])); ip != nil {
+			return ip.String()
+		}
+		return ""
+	}
+	client := ""
+	for i := len(chain) - 1; i >= 0; i-- {
+		ip := net.ParseIP(strings.TrimSpace(chain[i]))
+		if ip == nil {
+			break
+		}
+		client = ip.String()
+		if !r.trusts(client) {
+			break
+		}
+	}
+	return client
+}
+
+// trusts reports whether the address is a trusted proxy.
+func (r *clientIPResolver) trusts(addr string) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, ipNet := range r.trusted {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+package logging
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestClientIP(t *testing.T) {
+	trusted := []string{"10.0.0.0/8", "192.0.2.1", "2001:db8::/32"}
+	for _, tt := range []struct {
+		name       string
+		config     *ClientIP
+		remoteAddr string
+		headers    map[string]string
+		want       string
+	}{
+		{"untrusted by default", nil, "198.51.100.1:1234", map[string]string{"X-Forwarded-For": "203.0.113.7"}, "198.51.100.1"},
+		{"untrusted peer", &ClientIP{TrustedProxies: trusted}, "198.51.100.1:1234", map[string]string{"X-Forwarded-For": "203.0.113.7"}, "198.51.100.1"},
+		{"trusted peer", &ClientIP{TrustedProxies: trusted}, "10.1.2.3:1234", map[string]string{"X-Forwarded-For": "203.0.113.7"}, "203.0.113.7"},
+		{"trusted address", &ClientIP{TrustedProxies: trusted}, "192.0.2.1:1234", map[string]string{"X-Forwarded-For": "203.0.113.7"}, "203.0.113.7"},
+		{"spoofed chain", &ClientIP{TrustedProxies: trusted}, "10.1.2.3:1234", map[string]string{"X-Forwarded-For": "1.2.3.4, 203.0.113.7, 10.0.0.2"}, "203.0.113.7"},
+		{"all trusted", &ClientIP{TrustedProxies: trusted}, "10.1.2.3:1234", map[string]string{"X-Forwarded-For": "10.0.0.3, 10.0.0.2"}, "10.0.0.3"},
+		{"invalid chain", &ClientIP{TrustedProxies: trusted}, "10.1.2.3:1234", map[string]string{"X-Forwarded-For": "unknown"}, "10.1.2.3"},
+		{"depth", &ClientIP{TrustedProxies: trusted, ForwardedDepth: 2}, "10.1.2.3:1234", map[string]string{"X-Forwarded-For": "1.2.3.4, 203.0.113.7, 198.51.100.9"}, "203.0.113.7"},
+		{"depth beyond chain", &ClientIP{TrustedProxies: trusted, ForwardedDepth: 3}, "10.1.2.3:1234", map[string]string{"X-Forwarded-For": "203.0.113.7"}, "10.1.2.3"},
+		{"precedence", &ClientIP{TrustedProxies: trusted, Headers: []string{"cf-connecting-ip", "True-Client-IP", "X-Real-IP", "X-Forwarded-For"}}, "10.1.2.3:1234", map[string]string{
+			"True-Client-IP":  "203.0.113.8",
+			"X-Real-IP":       "203.0.113.9",
+			"X-Forwarded-For": "203.0.113.7",
+		}, "203.0.113.8"},
+		{"header not configured", &ClientIP{TrustedProxies: trusted}, "10.1.2.3:1234", map[string]string{"X-Real-IP": "203.0.113.9"}, "10.1.2.3"},
+		{"IPv6", &ClientIP{TrustedProxies: trusted}, "[2001:db8::1]:1234", map[string]string{"X-Real-IP": "203.0.113.9"}, "2001:db8::1"},
+	} {
+		r, err := newClientIPResolver(tt.config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		header := http.Header{}
+		for k, v := range tt.headers {
+			header.Set(k, v)
+		}
+		if got := r.resolve(header.Get, tt.remoteAddr); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestClientIPInvalidProxy(t *testing.T) {
+	if _, err := New(&Config{ClientIP: &ClientIP{TrustedProxies: []string{"10.0.0.0/33"}}}); err == nil {
+		t.Error("got no error for an invalid trusted proxy")
+	}
+}
+
+func TestRequestLoggerClientIP(t *testing.T) {
+	l, buf := newTestLogger(t, &Config{ClientIP: &ClientIP{TrustedProxies: []string{"192.0.2.0/24"}}})
+	r := gin.New()
+	r.Use(l.RequestLogger())
+	r.GET("/", func(c *gin.Context) {
+		if len(c.Request.Header) != 1 || c.GetHeader("X-Forwarded-For") != "203.0.113.7" {
+			t.Errorf("the request headers were modified: %v", c.Request.Header)
+		}
+	})
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("X-Forwarded-For", "203.0.113.7")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	got := entries(t, buf)
+	if len(got) != 1 || labels(got[0])["remote_ip"] != "203.0.113.7" {
+		t.Errorf("got %v, want the forwarded client IP", got)
+	}
+}
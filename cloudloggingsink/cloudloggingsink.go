@@ -0,0 +1,220 @@
+// Package cloudloggingsink writes the entries of the logging package with
+// the Cloud Logging API.
+package cloudloggingsink
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	gcplogging "cloud.google.com/go/logging"
+	"cloud.google.com/go/logging/apiv2/loggingpb"
+	"github.com/cyoyu/logging"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap/zapcore"
+	"golang.org/x/net/context"
+	"google.golang.org/api/option"
+	mrpb "google.golang.org/genproto/googleapis/api/monitoredres"
+)
+
+// zapdriverPrefix prefixes the keys of the fields specific to Cloud Logging,
+// zapdriverLabelsKey is the key of the object zapdriver gathers labels in.
+const (
+	zapdriverPrefix    = "logging.googleapis.com/"
+	zapdriverLabelsKey = zapdriverPrefix + "labels"
+)
+
+// Output is a logging.Sink writing the entries through the Cloud Logging
+// API rather than to the standard error output parsed by the logging agent,
+// for hosts without the agent. They are written to in the project of the
+// logger.
+type Output struct {
+	// LogName defaults to "app".
+	LogName string `json:"log_name" yaml:"log_name"`
+	// ResourceType and ResourceLabels set the monitored resource of the
+	// entries, e.g. "gce_instance". It is detected on GCE, Cloud Run, Cloud
+	// Functions and App Engine by default.
+	ResourceType   string            `json:"resource_type" yaml:"resource_type"`
+	ResourceLabels map[string]string `json:"resource_labels" yaml:"resource_labels"`
+	// Options configure the Cloud Logging client.
+	Options []option.ClientOption `json:"-" yaml:"-"`
+}
+
+// core is a zapcore.Core writing entries with a Cloud Logging client.
+type core struct {
+	zapcore.LevelEnabler
+	sc     *logging.SinkContext
+	logger *gcplogging.Logger
+	fields []zapcore.Field
+}
+
+// Open returns a core writing the entries with the Cloud Logging API.
+func (out *Output) Open(sc *logging.SinkContext) (zapcore.Core, func(), error) {
+	if sc.ProjectID == "" {
+		return nil, nil, errors.New("cloudloggingsink: the output requires a project ID")
+	}
+	client, err := gcplogging.NewClient(context.Background(), "projects/"+sc.ProjectID, out.Options...)
+	if err != nil {
+		return nil, nil, err
+	}
+	client.OnError = func(err error) {
+		fmt.Fprintf(os.Stderr, "logging: %v\n", err)
+	}
+	logName := out.LogName
+	if logName == "" {
+		logName = "app"
+	}
+	var opts []gcplogging.LoggerOption
+	if out.ResourceType != "" {
+		opts = append(opts, gcplogging.CommonResource(&mrpb.MonitoredResource{
+			Type:   out.ResourceType,
+			Labels: out.ResourceLabels,
+		}))
+	}
+	c := &core{
+		LevelEnabler: sc.Level,
+		sc:           sc,
+		logger:       client.Logger(logName, opts...),
+	}
+	return c, func() { client.Close() }, nil
+}
+
+func (c *core) With(fields []zapcore.Field) zapcore.Core {
+	clone := *c
+	clone.fields = append(c.fields[:len(c.fields):len(c.fields)], fields...)
+	return &clone
+}
+
+func (c *core) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *core) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	if !c.Enabled(ent.Level) {
+		return nil
+	}
+	var span trace.SpanContext
+	m := zapcore.NewMapObjectEncoder()
+	for _, fieldSet := range [][]zapcore.Field{c.fields, fields} {
+		for _, f := range fieldSet {
+			if ctx, ok := f.Interface.(context.Context); ok && f.Type == zapcore.SkipType {
+				span = trace.SpanContextFromContext(ctx)
+				continue
+			}
+			if f.Key == zapdriverLabelsKey || strings.HasPrefix(f.Key, "labels.") {
+				continue
+			}
+			f.AddTo(m)
+		}
+	}
+
+	e := gcplogging.Entry{
+		Timestamp: ent.Time,
+		Severity:  severity(ent.Level),
+		Labels:    c.sc.Labels(c.fields, fields),
+	}
+	if c.sc.LinksTrace(span) {
+		e.Trace = fmt.Sprintf("projects/%s/traces/%s", c.sc.ProjectID, span.TraceID())
+		e.TraceSampled = span.IsSampled()
+		if span.HasSpanID() {
+			e.SpanID = span.SpanID().String()
+		}
+	}
+	if source, ok := m.Fields[zapdriverPrefix+"sourceLocation"].(map[string]interface{}); ok {
+		e.SourceLocation = sourceLocation(source)
+	}
+	if payload, ok := m.Fields["httpRequest"].(map[string]interface{}); ok {
+		e.HTTPRequest = httpRequest(payload)
+		delete(m.Fields, "httpRequest")
+	}
+	jsonPayload := map[string]interface{}{"message": ent.Message}
+	for k, v := range m.Fields {
+		if strings.HasPrefix(k, zapdriverPrefix) {
+			continue
+		}
+		jsonPayload[k] = v
+	}
+	if ent.Stack != "" {
+		jsonPayload["stack_trace"] = ent.Stack
+	}
+	e.Payload = jsonPayload
+	c.logger.Log(e)
+	return nil
+}
+
+func (c *core) Sync() error {
+	return c.logger.Flush()
+}
+
+// severity maps zap levels to Cloud Logging severities.
+func severity(level zapcore.Level) gcplogging.Severity {
+	switch level {
+	case zapcore.DebugLevel:
+		return gcplogging.Debug
+	case zapcore.InfoLevel:
+		return gcplogging.Info
+	case zapcore.WarnLevel:
+		return gcplogging.Warning
+	case zapcore.ErrorLevel:
+		return gcplogging.Error
+	case zapcore.DPanicLevel:
+		return gcplogging.Critical
+	case zapcore.PanicLevel:
+		return gcplogging.Alert
+	default:
+		return gcplogging.Emergency
+	}
+}
+
+// sourceLocation converts the zapdriver source location payload.
+func sourceLocation(source map[string]interface{}) *loggingpb.LogEntrySourceLocation {
+	loc := &loggingpb.LogEntrySourceLocation{}
+	loc.File, _ = source["file"].(string)
+	loc.Function, _ = source["function"].(string)
+	line, _ := source["line"].(string)
+	loc.Line, _ = strconv.ParseInt(line, 10, 64)
+	return loc
+}
+
+// httpRequest converts the zapdriver request payload.
+func httpRequest(payload map[string]interface{}) *gcplogging.HTTPRequest {
+	str := func(key string) string {
+		s, _ := payload[key].(string)
+		return s
+	}
+	req := &http.Request{
+		Method: str("requestMethod"),
+		Proto:  str("protocol"),
+		Header: http.Header{},
+	}
+	req.URL, _ = url.Parse(str("requestUrl"))
+	if req.URL == nil {
+		req.URL = &url.URL{}
+	}
+	if ua := str("userAgent"); ua != "" {
+		req.Header.Set("User-Agent", ua)
+	}
+	if referer := str("referer"); referer != "" {
+		req.Header.Set("Referer", referer)
+	}
+	r := &gcplogging.HTTPRequest{
+		Request:  req,
+		RemoteIP: str("remoteIp"),
+		LocalIP:  str("serverIp"),
+	}
+	r.Status, _ = payload["status"].(int)
+	r.RequestSize, _ = strconv.ParseInt(str("requestSize"), 10, 64)
+	r.ResponseSize, _ = strconv.ParseInt(str("responseSize"), 10, 64)
+	if latency, err := time.ParseDuration(str("latency")); err == nil {
+		r.Latency = latency
+	}
+	return r
+}
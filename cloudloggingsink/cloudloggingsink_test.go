@@ -0,0 +1,116 @@
+package cloudloggingsink
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/logging/apiv2/loggingpb"
+	"github.com/cyoyu/logging"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/net/context"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// memoryLoggingService records the entries written to it, but the
+// instrumentation entry of the client.
+type memoryLoggingService struct {
+	loggingpb.UnimplementedLoggingServiceV2Server
+	mu      sync.Mutex
+	entries []*loggingpb.LogEntry
+}
+
+func (s *memoryLoggingService) WriteLogEntries(_ context.Context, req *loggingpb.WriteLogEntriesRequest) (*loggingpb.WriteLogEntriesResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, e := range req.Entries {
+		if e.LogName == "" {
+			e.LogName = req.LogName
+		}
+		if e.Resource == nil {
+			e.Resource = req.Resource
+		}
+		if strings.HasSuffix(e.LogName, "/diagnostic-log") {
+			continue
+		}
+		s.entries = append(s.entries, e)
+	}
+	return &loggingpb.WriteLogEntriesResponse{}, nil
+}
+
+func TestOutput(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer()
+	service := &memoryLoggingService{}
+	loggingpb.RegisterLoggingServiceV2Server(srv, service)
+	go srv.Serve(ln)
+	defer srv.Stop()
+
+	l, err := logging.New(&logging.Config{
+		ProjectID: "test",
+		Level:     logging.LevelDebug,
+		Sinks: []logging.Sink{&Output{
+			LogName:        "api",
+			ResourceType:   "gce_instance",
+			ResourceLabels: map[string]string{"instance_id": "1"},
+			Options: []option.ClientOption{
+				option.WithEndpoint(ln.Addr().String()),
+				option.WithoutAuthentication(),
+				option.WithGRPCDialOption(grpc.WithTransportCredentials(insecure.NewCredentials())),
+			},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	traceID := trace.TraceID{1}
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     trace.SpanID{2},
+		TraceFlags: trace.FlagsSampled,
+	}))
+	l.Errorw(logging.WithUserID(ctx, "u1"), "disk full", "disk", "sda")
+	req := httptest.NewRequest("GET", "/users/1", nil)
+	l.HTTP(ctx, req, &http.Response{StatusCode: 404}, "/users/:id", 3*time.Millisecond)
+	if err := l.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	l.Close()
+
+	service.mu.Lock()
+	defer service.mu.Unlock()
+	if len(service.entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(service.entries))
+	}
+	e := service.entries[0]
+	if e.LogName != "projects/test/logs/api" {
+		t.Errorf("got log name %q, want projects/test/logs/api", e.LogName)
+	}
+	if e.Resource.GetType() != "gce_instance" || e.Resource.GetLabels()["instance_id"] != "1" {
+		t.Errorf("got resource %v, want the configured gce_instance", e.Resource)
+	}
+	if got := e.GetJsonPayload().GetFields()["message"].GetStringValue(); got != "disk full" {
+		t.Errorf("got message %q, want disk full", got)
+	}
+	if e.Labels["user_id"] != "u1" || e.Labels["disk"] != "sda" {
+		t.Errorf("got labels %v, want the user ID and disk", e.Labels)
+	}
+	if e.Trace != "projects/test/traces/"+traceID.String() || !e.TraceSampled {
+		t.Errorf("got trace %q sampled %v, want the sampled trace of the context", e.Trace, e.TraceSampled)
+	}
+	if e.GetSourceLocation().GetLine() == 0 {
+		t.Error("got no source location")
+	}
+	if got := service.entries[1].GetHttpRequest(); got.GetStatus() != 404 || got.GetRequestMethod() != "GET" {
+		t.Errorf("got request %v, want the GET request answered 404", got)
+	}
+}
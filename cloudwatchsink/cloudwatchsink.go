@@ -0,0 +1,164 @@
+// Package cloudwatchsink sends the entries of the logging package to AWS
+// CloudWatch Logs.
+package cloudwatchsink
+
+import (
+	"errors"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/cyoyu/logging"
+	"go.uber.org/zap/zapcore"
+	"golang.org/x/net/context"
+)
+
+// maxSpan is the longest time the events of a PutLogEvents call may span.
+const maxSpan = 24 * time.Hour
+
+// Output is a logging.Sink sending the entries to AWS CloudWatch Logs in
+// batches. The credentials and region are loaded from the environment.
+type Output struct {
+	// Region defaults to the region of the environment.
+	Region   string `json:"region" yaml:"region"`
+	LogGroup string `json:"log_group" yaml:"log_group"`
+	// LogStream defaults to the host name. The group and stream are created
+	// if missing.
+	LogStream string `json:"log_stream" yaml:"log_stream"`
+	// BatchSize and BatchInterval default to 100 entries and a second.
+	BatchSize     int           `json:"batch_size" yaml:"batch_size"`
+	BatchInterval time.Duration `json:"batch_interval" yaml:"batch_interval"`
+}
+
+// logsClient is the part of the CloudWatch Logs client used by the output.
+type logsClient interface {
+	CreateLogGroup(ctx context.Context, in *cloudwatchlogs.CreateLogGroupInput, opts ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.CreateLogGroupOutput, error)
+	CreateLogStream(ctx context.Context, in *cloudwatchlogs.CreateLogStreamInput, opts ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.CreateLogStreamOutput, error)
+	PutLogEvents(ctx context.Context, in *cloudwatchlogs.PutLogEventsInput, opts ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.PutLogEventsOutput, error)
+}
+
+// newClient returns the CloudWatch Logs client of the region, it is replaced
+// in tests.
+var newClient = func(ctx context.Context, region string) (logsClient, error) {
+	var opts []func(*awsconfig.LoadOptions) error
+	if region != "" {
+		opts = append(opts, awsconfig.WithRegion(region))
+	}
+	cfg, err := awsconfig.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return cloudwatchlogs.NewFromConfig(cfg), nil
+}
+
+// stream puts events to a log stream, keeping its sequence token.
+type stream struct {
+	client logsClient
+	group  string
+	stream string
+
+	mu    sync.Mutex
+	token *string
+}
+
+// create creates the log group and stream unless they exist.
+func (s *stream) create(ctx context.Context) error {
+	var exists *types.ResourceAlreadyExistsException
+	_, err := s.client.CreateLogGroup(ctx, &cloudwatchlogs.CreateLogGroupInput{LogGroupName: &s.group})
+	if err != nil && !errors.As(err, &exists) {
+		return err
+	}
+	_, err = s.client.CreateLogStream(ctx, &cloudwatchlogs.CreateLogStreamInput{
+		LogGroupName:  &s.group,
+		LogStreamName: &s.stream,
+	})
+	if err != nil && !errors.As(err, &exists) {
+		return err
+	}
+	return nil
+}
+
+// put puts the events in chronological order, split in calls spanning at most
+// 24 hours.
+func (s *stream) put(ctx context.Context, events []types.InputLogEvent) error {
+	sort.SliceStable(events, func(i, j int) bool {
+		return *events[i].Timestamp < *events[j].Timestamp
+	})
+	for len(events) > 0 {
+		n := 1
+		for n < len(events) && *events[n].Timestamp-*events[0].Timestamp < maxSpan.Milliseconds() {
+			n++
+		}
+		if err := s.putBatch(ctx, events[:n]); err != nil {
+			return err
+		}
+		events = events[n:]
+	}
+	return nil
+}
+
+// putBatch puts events, retrying once with the expected sequence token if
+// the token is rejected.
+func (s *stream) putBatch(ctx context.Context, events []types.InputLogEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for retried := false; ; retried = true {
+		out, err := s.client.PutLogEvents(ctx, &cloudwatchlogs.PutLogEventsInput{
+			LogGroupName:  &s.group,
+			LogStreamName: &s.stream,
+			LogEvents:     events,
+			SequenceToken: s.token,
+		})
+		var invalid *types.InvalidSequenceTokenException
+		if errors.As(err, &invalid) && !retried {
+			s.token = invalid.ExpectedSequenceToken
+			continue
+		}
+		var accepted *types.DataAlreadyAcceptedException
+		if errors.As(err, &accepted) {
+			s.token = accepted.ExpectedSequenceToken
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		s.token = out.NextSequenceToken
+		return nil
+	}
+}
+
+// Open creates the log group and stream, and returns a core sending the
+// entries to them.
+func (out *Output) Open(sc *logging.SinkContext) (zapcore.Core, func(), error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	client, err := newClient(ctx, out.Region)
+	if err != nil {
+		return nil, nil, err
+	}
+	s := &stream{client: client, group: out.LogGroup, stream: out.LogStream}
+	if s.stream == "" {
+		s.stream, _ = os.Hostname()
+	}
+	if err := s.create(ctx); err != nil {
+		return nil, nil, err
+	}
+	core, closeCore := sc.BatchCore(out.BatchSize, out.BatchInterval, func(entries []logging.BatchEntry) error {
+		events := make([]types.InputLogEvent, len(entries))
+		for i, e := range entries {
+			events[i] = types.InputLogEvent{
+				Message:   aws.String(string(e.Line)),
+				Timestamp: aws.Int64(e.Time.UnixMilli()),
+			}
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		return s.put(ctx, events)
+	})
+	return core, closeCore, nil
+}
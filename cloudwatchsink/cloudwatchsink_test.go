@@ -0,0 +1,113 @@
+package cloudwatchsink
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/cyoyu/logging"
+	"golang.org/x/net/context"
+)
+
+// memoryCloudWatch is a log stream rejecting the first sequence token.
+type memoryCloudWatch struct {
+	mu      sync.Mutex
+	streams []string
+	token   string
+	events  []types.InputLogEvent
+}
+
+func (c *memoryCloudWatch) CreateLogGroup(context.Context, *cloudwatchlogs.CreateLogGroupInput, ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.CreateLogGroupOutput, error) {
+	return nil, &types.ResourceAlreadyExistsException{}
+}
+
+func (c *memoryCloudWatch) CreateLogStream(_ context.Context, in *cloudwatchlogs.CreateLogStreamInput, _ ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.CreateLogStreamOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.streams = append(c.streams, *in.LogGroupName+"/"+*in.LogStreamName)
+	return &cloudwatchlogs.CreateLogStreamOutput{}, nil
+}
+
+func (c *memoryCloudWatch) PutLogEvents(_ context.Context, in *cloudwatchlogs.PutLogEventsInput, _ ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.PutLogEventsOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if aws.ToString(in.SequenceToken) != c.token {
+		return nil, &types.InvalidSequenceTokenException{ExpectedSequenceToken: aws.String(c.token)}
+	}
+	c.events = append(c.events, in.LogEvents...)
+	c.token += "1"
+	return &cloudwatchlogs.PutLogEventsOutput{NextSequenceToken: aws.String(c.token)}, nil
+}
+
+func TestOutput(t *testing.T) {
+	client := &memoryCloudWatch{token: "expected"}
+	old := newClient
+	newClient = func(_ context.Context, region string) (logsClient, error) {
+		if region != "eu-west-1" {
+			t.Errorf("got region %q, want eu-west-1", region)
+		}
+		return client, nil
+	}
+	defer func() { newClient = old }()
+
+	l, err := logging.New(&logging.Config{
+		ProjectID: "test",
+		Level:     logging.LevelDebug,
+		Sinks: []logging.Sink{&Output{
+			Region:        "eu-west-1",
+			LogGroup:      "api",
+			LogStream:     "api-1",
+			BatchInterval: time.Hour,
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Info(context.Background(), "first")
+	if err := l.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	l.Info(context.Background(), "second")
+	l.Close()
+
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	if len(client.streams) != 1 || client.streams[0] != "api/api-1" {
+		t.Errorf("got streams %q created, want api/api-1", client.streams)
+	}
+	if len(client.events) != 2 {
+		t.Fatalf("got %d events, want 2", len(client.events))
+	}
+	for i, want := range []string{"first", "second"} {
+		entry := map[string]interface{}{}
+		if err := json.Unmarshal([]byte(*client.events[i].Message), &entry); err != nil {
+			t.Fatal(err)
+		}
+		if entry["message"] != want {
+			t.Errorf("got message %v, want %s", entry["message"], want)
+		}
+	}
+}
+
+func TestOrder(t *testing.T) {
+	client := &memoryCloudWatch{}
+	s := &stream{client: client, group: "api", stream: "api-1"}
+	now := time.Now()
+	err := s.put(context.Background(), []types.InputLogEvent{
+		{Message: aws.String("late"), Timestamp: aws.Int64(now.Add(25 * time.Hour).UnixMilli())},
+		{Message: aws.String("early"), Timestamp: aws.Int64(now.UnixMilli())},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(client.events) != 2 || *client.events[0].Message != "early" {
+		t.Errorf("got events %v, want them in chronological order", client.events)
+	}
+	if client.token != "11" {
+		t.Errorf("got %d calls, want one per day of events", len(client.token))
+	}
+}
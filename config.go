@@ -1,5 +1,13 @@
 package logging
 
+import (
+	"io"
+	"time"
+
+	otellog "go.opentelemetry.io/otel/log"
+	"go.uber.org/zap/zapcore"
+)
+
 type Level uint
 
 const (
@@ -12,6 +20,14 @@ const (
 	LevelLast
 )
 
+// Levels of the entries that panic or exit. They are above LevelLast since
+// they can't be disabled.
+const (
+	levelDPanic Level = LevelLast + 1 + iota
+	levelPanic
+	levelFatal
+)
+
 type Config struct {
 	ProjectID    string `json:"project_id" yaml:"project_id"`
 	Level        Level  `json:"level" yaml:"level"`
@@ -20,4 +36,233 @@ type Config struct {
 	KeyUserID    string `json:"key_user_id" yaml:"key_user_id"`
 	KeyError     string `json:"key_error" yaml:"key_error"`
 	KeyScope     string `json:"key_scope" yaml:"key_scope"`
+	Clock        Clock  `json:"-" yaml:"-"`
+
+	// Format lays the entries out for another log management system than
+	// Cloud Logging: "datadog" or "ecs" for the Elastic Common Schema.
+	Format string `json:"format" yaml:"format"`
+
+	PrettyConsole  bool           `json:"pretty_console" yaml:"pretty_console"`
+	WarnEscalation WarnEscalation `json:"warn_escalation" yaml:"warn_escalation"`
+	Dedup          Dedup          `json:"dedup" yaml:"dedup"`
+
+	// RedactKeys lists the keys whose values are replaced before logging.
+	// RedactPolicies overrides it for requests carrying a given scope.
+	RedactKeys     []string            `json:"redact_keys" yaml:"redact_keys"`
+	RedactPolicies map[string][]string `json:"redact_policies" yaml:"redact_policies"`
+	// Redaction redacts sensitive keys and values from all the entries.
+	Redaction *Redaction `json:"redaction" yaml:"redaction"`
+	// UserIDHashKey pseudonymizes the user IDs logged, replacing them with
+	// their hex-encoded HMAC-SHA256 keyed with it. The entries of a user can
+	// still be correlated, Config.DebugUsers still lists raw user IDs.
+	UserIDHashKey string `json:"user_id_hash_key" yaml:"user_id_hash_key"`
+
+	// MaxMessageLength and MaxFieldLength truncate the messages and string
+	// values of the labels and fields longer than these many bytes, the
+	// entries truncated being marked with truncated=true. Cloud Logging
+	// drops the entries larger than 256 KiB. Zero doesn't truncate.
+	MaxMessageLength int `json:"max_message_length" yaml:"max_message_length"`
+	MaxFieldLength   int `json:"max_field_length" yaml:"max_field_length"`
+
+	// Sampling limits the entries logged per second below error severity.
+	// It defaults to 100 entries, then one in 100, but on the development
+	// console and with Development.
+	Sampling *Sampling `json:"sampling" yaml:"sampling"`
+
+	// Levels maps the names of sub-loggers to their level, e.g. {"storage":
+	// "debug"}. The other sub-loggers have the level of their parent.
+	Levels map[string]Level `json:"levels" yaml:"levels"`
+
+	// StatusLevels maps response statuses, e.g. "404", or classes, e.g.
+	// "5xx", to the level of the access logs of the middlewares. It defaults
+	// to error for 5xx and warning for 4xx, the others being logged at info.
+	StatusLevels map[string]Level `json:"status_levels" yaml:"status_levels"`
+
+	// SlowRequest is the latency above which the access logs are logged at
+	// warning level at least, labeled slow_request. SlowRoutes overrides it
+	// for the routes matching its patterns, as for RouteLevels.
+	SlowRequest time.Duration            `json:"slow_request" yaml:"slow_request"`
+	SlowRoutes  map[string]time.Duration `json:"slow_routes" yaml:"slow_routes"`
+
+	// SlowQuery is the latency above which the statements logged by
+	// SQLDriver, gormlog and redishook are logged at warning level rather
+	// than debug, labeled slow_query.
+	SlowQuery time.Duration `json:"slow_query" yaml:"slow_query"`
+
+	// BodyCapture logs the request and response bodies with the access
+	// logs.
+	BodyCapture *BodyCapture `json:"body_capture" yaml:"body_capture"`
+	// HeaderCapture logs selected request and response headers with the
+	// access logs.
+	HeaderCapture *HeaderCapture `json:"header_capture" yaml:"header_capture"`
+	// ClientIP trusts proxies to report the IP of the clients. Without it,
+	// the remote address of the requests is logged.
+	ClientIP *ClientIP `json:"client_ip" yaml:"client_ip"`
+
+	// RouteLevels maps route patterns to the level of the entries logged for
+	// their requests, e.g. {"/api/v2/experimental/*": "debug"}. Patterns are
+	// matched against the route and URL path of the requests, a trailing *
+	// matching all the paths it prefixes. ScopeLevels maps scopes to the
+	// level of the entries logged with them, taking precedence.
+	RouteLevels map[string]Level `json:"route_levels" yaml:"route_levels"`
+	ScopeLevels map[string]Level `json:"scope_levels" yaml:"scope_levels"`
+
+	// DebugToken logs the requests carrying it in their X-Debug-Log header
+	// at debug level, DebugUsers the entries logged with their user IDs.
+	DebugToken string   `json:"debug_token" yaml:"debug_token"`
+	DebugUsers []string `json:"debug_users" yaml:"debug_users"`
+
+	// DebugBufferSize enables holding the last debug entries of each request
+	// logged by the middlewares below the level of the logger, and writing
+	// them only if the request logs an error or fails with a 5xx status.
+	DebugBufferSize int `json:"debug_buffer_size" yaml:"debug_buffer_size"`
+
+	// RouteSampling maps a route pattern to the ratio of its access logs to
+	// keep. Routes that are not listed are always logged.
+	RouteSampling map[string]float64 `json:"route_sampling" yaml:"route_sampling"`
+
+	// StatusSampling maps a status code or class, e.g. "200" or "2xx", to
+	// the ratio of the access logs of the requests answered with it to keep,
+	// zero skipping them. A key may be preceded by a route pattern and a
+	// space to only apply to its routes, e.g. "/health 200". Client and
+	// server errors are always logged.
+	StatusSampling map[string]float64 `json:"status_sampling" yaml:"status_sampling"`
+
+	// ContextKeys maps the string keys of context values to the label they
+	// are logged under. An empty label name logs the value under its key.
+	ContextKeys map[string]string `json:"context_keys" yaml:"context_keys"`
+
+	// BaggageKeys maps the keys of OpenTelemetry baggage members to the
+	// label they are logged under. An empty label name logs the value under
+	// its key.
+	BaggageKeys map[string]string `json:"baggage_keys" yaml:"baggage_keys"`
+
+	// PayloadFields logs key/value pairs as typed payload fields instead of
+	// labels, which are then reserved for the request metadata.
+	PayloadFields bool `json:"payload_fields" yaml:"payload_fields"`
+
+	// ErrorReporting formats entries of error severity and above as Cloud
+	// Error Reporting events reported for the service.
+	ErrorReporting bool `json:"error_reporting" yaml:"error_reporting"`
+
+	// ServiceName, ServiceVersion and Environment identify the binary that
+	// logged the entries, in the serviceContext field of every entry and an
+	// environment label, or the service, version and environment fields of
+	// Format. Labels are static labels added to every entry.
+	ServiceName    string            `json:"service_name" yaml:"service_name"`
+	ServiceVersion string            `json:"service_version" yaml:"service_version"`
+	Environment    string            `json:"environment" yaml:"environment"`
+	Labels         map[string]string `json:"labels" yaml:"labels"`
+
+	// Metadata labels every entry with the pod, namespace, node and
+	// container names set by the Kubernetes downward API in the POD_NAME,
+	// POD_NAMESPACE, NODE_NAME and CONTAINER_NAME environment variables, and
+	// on GCE and GKE with the zone, instance ID and cluster name read from
+	// the metadata server when the logger is created.
+	Metadata bool `json:"metadata" yaml:"metadata"`
+
+	// BuildInfo labels every entry with the module version, VCS revision and
+	// dirty flag the binary was built with, as module_version, vcs_revision
+	// and vcs_modified.
+	BuildInfo bool `json:"build_info" yaml:"build_info"`
+
+	// SampledTracesOnly only links the entries to their trace if it is
+	// sampled, so unrecorded traces don't appear correlated with entries.
+	SampledTracesOnly bool `json:"sampled_traces_only" yaml:"sampled_traces_only"`
+
+	// StacktraceLevel is the minimum level of the entries with a stack
+	// trace, error by default. StacktraceFormat is "zap" for the stacktrace
+	// field of zap, "error_reporting" for a stack_trace field formatted like
+	// a Go panic, which Cloud Error Reporting parses, or "none".
+	StacktraceLevel  Level  `json:"stacktrace_level" yaml:"stacktrace_level"`
+	StacktraceFormat string `json:"stacktrace_format" yaml:"stacktrace_format"`
+
+	// DisableSourceLocation leaves out the source location of the entries,
+	// saving the lookup of their caller on hot paths. Cloud Error Reporting
+	// events are still located.
+	DisableSourceLocation bool `json:"disable_source_location" yaml:"disable_source_location"`
+
+	// SpanEventLevel also adds the entries at this level and above to the
+	// recording span of their context as events, entries of error severity
+	// setting its status to error. Zero disables span events.
+	SpanEventLevel Level `json:"span_event_level" yaml:"span_event_level"`
+
+	// Outputs replaces the default standard error output with several
+	// outputs, each with its own format and level.
+	Outputs []Output `json:"outputs" yaml:"outputs"`
+	// SplitStreams replaces the standard error output with the standard
+	// output for the entries below LevelWarn and the standard error output
+	// for the others.
+	SplitStreams bool `json:"split_streams" yaml:"split_streams"`
+	// File replaces the standard error output with a rotated file.
+	File *FileOutput `json:"file" yaml:"file"`
+	// Syslog replaces the standard error output with a syslog daemon.
+	Syslog *SyslogOutput `json:"syslog" yaml:"syslog"`
+	// Loki replaces the standard error output with Grafana Loki.
+	Loki *LokiOutput `json:"loki" yaml:"loki"`
+	// GELF replaces the standard error output with Graylog.
+	GELF *GELFOutput `json:"gelf" yaml:"gelf"`
+	// Fluentd replaces the standard error output with a fluentd forward
+	// input.
+	Fluentd *FluentdOutput `json:"fluentd" yaml:"fluentd"`
+	// OTLP replaces the standard error output with an OpenTelemetry
+	// collector, LoggerProvider with the logger provider of the application.
+	OTLP           *OTLPOutput            `json:"otlp" yaml:"otlp"`
+	LoggerProvider otellog.LoggerProvider `json:"-" yaml:"-"`
+	// Sinks replace the standard error output with the outputs implemented
+	// in other packages: AWS CloudWatch Logs with cloudwatchsink, Kafka with
+	// kafkasink and the Cloud Logging API with cloudloggingsink.
+	Sinks []Sink `json:"-" yaml:"-"`
+	// PubSub publishes selected entries to a Pub/Sub topic, in addition to
+	// the other outputs.
+	PubSub *PubSubOutput `json:"pubsub" yaml:"pubsub"`
+	// FlushInterval flushes the buffered and queued entries periodically,
+	// FlushOnSignal when the process receives SIGINT or SIGTERM, so that the
+	// last entries aren't lost when Finalize isn't reached, e.g. when a pod
+	// is killed. The signal is then raised again to terminate the process:
+	// applications handling these signals receive them twice, and should
+	// rather call Finalize when shutting down.
+	FlushInterval time.Duration `json:"flush_interval" yaml:"flush_interval"`
+	FlushOnSignal bool          `json:"flush_on_signal" yaml:"flush_on_signal"`
+	// Async queues the entries written to the standard streams, files and
+	// writers of the outputs, or to the standard error output by default.
+	Async *AsyncOutput `json:"async" yaml:"async"`
+	// Output replaces the standard error output with a writer.
+	Output io.Writer `json:"-" yaml:"-"`
+	// AuditOutput writes the audit and security events apart from the other
+	// entries, its level being ignored. They are written to the standard
+	// error output, or Output, by default.
+	AuditOutput *Output `json:"audit_output" yaml:"audit_output"`
+	// Core replaces the zap core entries are encoded and written with,
+	// taking precedence over the outputs.
+	Core zapcore.Core `json:"-" yaml:"-"`
+}
+
+// WarnEscalation promotes a warning to error severity once the same message
+// template has been logged more than Count times within Window, a minute by
+// default.
+type WarnEscalation struct {
+	Count  int           `json:"count" yaml:"count"`
+	Window time.Duration `json:"window" yaml:"window"`
+}
+
+// perRequestLevels reports whether entries below the level of the logger
+// are logged for some requests or sub-loggers.
+func (c *Config) perRequestLevels() bool {
+	return c.DebugBufferSize > 0 || len(c.Levels) > 0 || len(c.RouteLevels) > 0 || len(c.ScopeLevels) > 0 ||
+		c.DebugToken != "" || len(c.DebugUsers) > 0
+}
+
+// Dedup drops the repeats of a message logged with the same severity within
+// Window of its first occurrence. The last repeat is logged with a count
+// field once the window is over, or when the logger is synced.
+type Dedup struct {
+	Window time.Duration `json:"window" yaml:"window"`
+}
+
+// Clock provides the time used to stamp log entries and to measure the
+// latency of requests, calls, queries and jobs, e.g. to log stable durations
+// in tests.
+type Clock interface {
+	Now() time.Time
 }
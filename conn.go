@@ -0,0 +1,60 @@
+package logging
+
+import (
+	"net"
+	"sync"
+)
+
+// connWriter writes messages to a network connection, reconnecting when a
+// write fails.
+type connWriter struct {
+	mu   sync.Mutex
+	dial func() (net.Conn, error)
+	send func(conn net.Conn, msg []byte) error
+	conn net.Conn
+}
+
+func newConnWriter(dial func() (net.Conn, error), send func(conn net.Conn, msg []byte) error) (*connWriter, error) {
+	w := &connWriter{dial: dial, send: send}
+	conn, err := dial()
+	if err != nil {
+		return nil, err
+	}
+	w.conn = conn
+	return w, nil
+}
+
+func (w *connWriter) write(msg []byte) error {
+	return w.do(func(conn net.Conn) error {
+		return w.send(conn, msg)
+	})
+}
+
+// do calls fn with the connection, reconnecting first if needed.
+func (w *connWriter) do(fn func(conn net.Conn) error) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.conn == nil {
+		conn, err := w.dial()
+		if err != nil {
+			return err
+		}
+		w.conn = conn
+	}
+	if err := fn(w.conn); err != nil {
+		// Reconnect on the next write.
+		w.conn.Close()
+		w.conn = nil
+		return err
+	}
+	return nil
+}
+
+func (w *connWriter) close() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.conn != nil {
+		w.conn.Close()
+		w.conn = nil
+	}
+}
@@ -0,0 +1,117 @@
+package logging
+
+import (
+	"strings"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"golang.org/x/net/context"
+)
+
+// MessageMetadata describes a message received from a queue, for Consumer.
+type MessageMetadata struct {
+	// Source is the topic, queue or subscription the message was received
+	// from. It is logged as the route of the message.
+	Source string
+	ID     string
+	// Attributes are the attributes or headers of the message. The trace
+	// context, baggage and request ID are read from them as from the
+	// headers of HTTP requests, their names being case insensitive.
+	Attributes map[string]string
+	// DeliveryAttempt is the number of times the message has been
+	// delivered, 1 on the first delivery, or 0 if it isn't known.
+	DeliveryAttempt int
+	// PublishTime is the time the message was published, if known.
+	PublishTime time.Time
+}
+
+// PubSubMetadata returns the metadata of a Pub/Sub message received from
+// the subscription.
+func PubSubMetadata(subscription string, msg *pubsub.Message) MessageMetadata {
+	m := MessageMetadata{
+		Source:      subscription,
+		ID:          msg.ID,
+		Attributes:  msg.Attributes,
+		PublishTime: msg.PublishTime,
+	}
+	if msg.DeliveryAttempt != nil {
+		m.DeliveryAttempt = *msg.DeliveryAttempt
+	}
+	return m
+}
+
+// attribute returns the value of an attribute, ignoring the case of its
+// name.
+func (m MessageMetadata) attribute(name string) string {
+	if v, ok := m.Attributes[name]; ok {
+		return v
+	}
+	for k, v := range m.Attributes {
+		if strings.EqualFold(k, name) {
+			return v
+		}
+	}
+	return ""
+}
+
+// Consumer handles a message with the package level logger, as the
+// middlewares handle requests: the context passed to the handler carries
+// the trace context and request ID of the message, its receipt is logged at
+// debug level and its handling at info level, or error level if the handler
+// fails.
+func Consumer(ctx context.Context, msg MessageMetadata, handler func(ctx context.Context) error) error {
+	return std().consume(ctx, msg, handler)
+}
+
+// Consumer handles a message with l.
+func (l *Logger) Consumer(ctx context.Context, msg MessageMetadata, handler func(ctx context.Context) error) error {
+	return l.consume(ctx, msg, handler)
+}
+
+func (l *Logger) consume(ctx context.Context, msg MessageMetadata, handler func(ctx context.Context) error) error {
+	ctx = withHeaderBaggage(withHeaderTrace(orBackground(ctx), msg.attribute), msg.attribute)
+	ctx, _ = withRequestID(ctx, msg.attribute(requestIDHeader))
+	ctx = withRoute(ctx, msg.Source, msg.Source)
+	ctx = withLogger(l.withDebugBuffer(ctx), l)
+
+	fields := []zapcore.Field{
+		zap.String("message_source", msg.Source),
+		zap.String("message_id", msg.ID),
+	}
+	if msg.DeliveryAttempt > 0 {
+		fields = append(fields,
+			zap.Int("delivery_attempt", msg.DeliveryAttempt),
+			zap.Int("redeliveries", msg.DeliveryAttempt-1),
+		)
+	}
+	if !msg.PublishTime.IsZero() {
+		fields = append(fields, zap.Int64("message_age_ms", l.since(msg.PublishTime).Milliseconds()))
+	}
+	if l.enabled(ctx, LevelDebug) {
+		l.zentry(ctx, LevelDebug, "message received", msg.Source, fields)
+	}
+
+	start := l.clock.Now()
+	err := handler(ctx)
+	duration := l.since(start)
+
+	level, outcome := LevelInfo, "ok"
+	if err != nil {
+		level, outcome = LevelError, "error"
+		fields = append(fields, zap.NamedError(l.keyError, err))
+	}
+	level, fields = l.slowRequest(level, fields, msg.Source, msg.Source, duration)
+	if level.zapLevel() >= zapcore.ErrorLevel {
+		l.flushDebug(ctx)
+	}
+	if l.enabled(ctx, level) {
+		fields = append(fields,
+			zap.String("outcome", outcome),
+			zap.Int64("duration_ms", duration.Milliseconds()),
+		)
+		l.zentry(ctx, level, "message handled", msg.Source, fields)
+	}
+	return err
+}
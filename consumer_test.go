@@ -0,0 +1,60 @@
+package logging
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/net/context"
+)
+
+func TestConsumer(t *testing.T) {
+	l, buf := newTestLogger(t, &Config{Level: LevelDebug})
+	attempt := 3
+	msg := PubSubMetadata("orders-sub", &pubsub.Message{
+		ID: "m-1",
+		Attributes: map[string]string{
+			"traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		},
+		DeliveryAttempt: &attempt,
+		PublishTime:     time.Now().Add(-time.Minute),
+	})
+	err := l.Consumer(context.Background(), msg, func(ctx context.Context) error {
+		if sc := trace.SpanContextFromContext(ctx); sc.TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+			t.Errorf("got trace %v, want the trace of the message", sc.TraceID())
+		}
+		FromContext(ctx).Info("handling")
+		return errors.New("out of stock")
+	})
+	if err == nil || err.Error() != "out of stock" {
+		t.Errorf("got error %v, want the error of the handler", err)
+	}
+
+	got := entries(t, buf)
+	if len(got) != 3 {
+		t.Fatalf("got %d entries, want 3", len(got))
+	}
+	for i, want := range []struct{ severity, message string }{
+		{"DEBUG", "message received"},
+		{"INFO", "handling"},
+		{"ERROR", "message handled"},
+	} {
+		e := got[i]
+		if e["severity"] != want.severity || e["message"] != want.message {
+			t.Errorf("entry %d: got %v, want %+v", i, e, want)
+		}
+		if e["logging.googleapis.com/trace"] != "projects/test/traces/4bf92f3577b34da6a3ce929d0e0e4736" {
+			t.Errorf("entry %d: got %v, want the trace of the message", i, e)
+		}
+	}
+	handled := got[2]
+	if handled["message_source"] != "orders-sub" || handled["message_id"] != "m-1" || handled["delivery_attempt"] != 3.0 ||
+		handled["redeliveries"] != 2.0 || handled["outcome"] != "error" || handled["err"] != "out of stock" {
+		t.Errorf("got %v, want the message metadata and outcome", handled)
+	}
+	if age, _ := handled["message_age_ms"].(float64); age < 60000 {
+		t.Errorf("got message age %v, want a minute", handled["message_age_ms"])
+	}
+}
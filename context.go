@@ -0,0 +1,155 @@
+package logging
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+
+	"go.opentelemetry.io/otel/baggage"
+	"golang.org/x/net/context"
+)
+
+// fieldsKey is the context key of the fields accumulated by WithFields.
+type fieldsKey struct{}
+
+// WithFields returns a copy of ctx carrying the key/value pairs, in addition to
+// those already carried by ctx. They are logged with every entry logged with
+// the returned context, such as those of FromContext(ctx).
+func WithFields(ctx context.Context, keysAndValues ...interface{}) context.Context {
+	if len(keysAndValues) == 0 {
+		return ctx
+	}
+	parent := contextFields(ctx)
+	fields := make([]interface{}, 0, len(parent)+len(keysAndValues))
+	fields = append(fields, parent...)
+	fields = append(fields, keysAndValues...)
+	return context.WithValue(ctx, fieldsKey{}, fields)
+}
+
+// contextFields returns the key/value pairs accumulated in ctx, which must not
+// be modified.
+func contextFields(ctx context.Context) []interface{} {
+	fields, _ := ctx.Value(fieldsKey{}).([]interface{})
+	return fields
+}
+
+// orBackground returns ctx, or the background context if ctx is nil, so
+// logging with a nil context doesn't panic.
+func orBackground(ctx context.Context) context.Context {
+	if ctx == nil {
+		return context.Background()
+	}
+	return ctx
+}
+
+// userIDKey and scopeKey are the context keys of the user ID and scope.
+type userIDKey struct{}
+type scopeKey struct{}
+
+// WithUserID returns a copy of ctx carrying the user ID to log.
+func WithUserID(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, userIDKey{}, userID)
+}
+
+// UserIDFromContext returns the user ID carried by ctx.
+func UserIDFromContext(ctx context.Context) (string, bool) {
+	userID, ok := ctx.Value(userIDKey{}).(string)
+	return userID, ok
+}
+
+// WithScope returns a copy of ctx carrying the scope to log.
+func WithScope(ctx context.Context, scope string) context.Context {
+	return context.WithValue(ctx, scopeKey{}, scope)
+}
+
+// ScopeFromContext returns the scope carried by ctx.
+func ScopeFromContext(ctx context.Context) (string, bool) {
+	scope, ok := ctx.Value(scopeKey{}).(string)
+	return scope, ok
+}
+
+// userID returns the user ID carried by ctx, falling back to the value stored
+// under the configured string key for contexts set up before WithUserID.
+func (l *Logger) userID(ctx context.Context) (string, bool) {
+	if userID, ok := UserIDFromContext(ctx); ok {
+		return userID, true
+	}
+	userID, ok := ctx.Value(l.userIDValueKey).(string)
+	return userID, ok
+}
+
+// loggedUserID returns the user ID carried by ctx as logged, pseudonymized
+// with its HMAC-SHA256 if Config.UserIDHashKey is set.
+func (l *Logger) loggedUserID(ctx context.Context) (string, bool) {
+	userID, ok := l.userID(ctx)
+	if !ok || len(l.userIDHashKey) == 0 {
+		return userID, ok
+	}
+	mac := hmac.New(sha256.New, l.userIDHashKey)
+	mac.Write([]byte(userID))
+	return hex.EncodeToString(mac.Sum(nil)), true
+}
+
+// scope returns the scope carried by ctx, falling back to the value stored
+// under the configured string key for contexts set up before WithScope.
+func (l *Logger) scope(ctx context.Context) (string, bool) {
+	if scope, ok := ScopeFromContext(ctx); ok {
+		return scope, true
+	}
+	scope, ok := ctx.Value(l.scopeValueKey).(string)
+	return scope, ok
+}
+
+// contextKey is a context value logged as a label.
+type contextKey struct {
+	key   string
+	label string
+}
+
+// newContextKeys returns the keys mapped to their labels, sorted by label.
+func newContextKeys(keys map[string]string) []contextKey {
+	contextKeys := make([]contextKey, 0, len(keys))
+	for key, label := range keys {
+		if label == "" {
+			label = key
+		}
+		contextKeys = append(contextKeys, contextKey{key: key, label: label})
+	}
+	sort.Slice(contextKeys, func(i, j int) bool {
+		return contextKeys[i].label < contextKeys[j].label
+	})
+	return contextKeys
+}
+
+func (l *Logger) setContextKeys(keys map[string]string) {
+	l.contextKeys = newContextKeys(keys)
+}
+
+func (l *Logger) setBaggageKeys(keys map[string]string) {
+	l.baggageKeys = newContextKeys(keys)
+}
+
+// contextKeysAndValues returns the configured baggage members and context
+// values followed by the fields accumulated by WithFields.
+func (l *Logger) contextKeysAndValues(ctx context.Context) []interface{} {
+	fields := contextFields(ctx)
+	if len(l.contextKeys) == 0 && len(l.baggageKeys) == 0 {
+		return fields
+	}
+	keysAndValues := make([]interface{}, 0, 2*(len(l.baggageKeys)+len(l.contextKeys))+len(fields))
+	if len(l.baggageKeys) > 0 {
+		bag := baggage.FromContext(ctx)
+		for _, k := range l.baggageKeys {
+			if m := bag.Member(k.key); m.Key() != "" {
+				keysAndValues = append(keysAndValues, k.label, m.Value())
+			}
+		}
+	}
+	for _, k := range l.contextKeys {
+		if v := ctx.Value(k.key); v != nil {
+			keysAndValues = append(keysAndValues, k.label, v)
+		}
+	}
+	return append(keysAndValues, fields...)
+}
@@ -0,0 +1,99 @@
+package logging
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/baggage"
+	"golang.org/x/net/context"
+)
+
+func TestWithFields(t *testing.T) {
+	l, buf := newTestLogger(t, &Config{})
+	ctx := WithFields(context.Background(), "tenant", "acme")
+	ctx = WithFields(ctx, "order_id", "o-1")
+
+	fields := FromContext(ctx).Fields()
+	if len(fields) != 4 || fields[0] != "tenant" || fields[3] != "o-1" {
+		t.Errorf("got fields %v, want the accumulated fields", fields)
+	}
+	fields[1] = "changed"
+	l.Info(ctx, "placed")
+
+	got := entries(t, buf)
+	if len(got) != 1 {
+		t.Fatalf("got %d entries, want 1", len(got))
+	}
+	labels := labels(got[0])
+	if labels["tenant"] != "acme" || labels["order_id"] != "o-1" {
+		t.Errorf("got labels %v, want the accumulated fields", labels)
+	}
+}
+
+func TestBaggageKeys(t *testing.T) {
+	l, buf := newTestLogger(t, &Config{BaggageKeys: map[string]string{"tenant": "", "exp": "experiment"}})
+	tenant, _ := baggage.NewMember("tenant", "acme")
+	exp, _ := baggage.NewMember("exp", "b")
+	other, _ := baggage.NewMember("other", "x")
+	bag, _ := baggage.New(tenant, exp, other)
+	l.Info(baggage.ContextWithBaggage(context.Background(), bag), "hello")
+
+	r := gin.New()
+	r.Use(l.RequestLogger())
+	r.GET("/", func(c *gin.Context) {
+		l.Info(c.Request.Context(), "handling")
+	})
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("baggage", "tenant=globex")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	got := entries(t, buf)
+	if len(got) != 3 {
+		t.Fatalf("got %d entries, want 3", len(got))
+	}
+	labels0 := labels(got[0])
+	if labels0["tenant"] != "acme" || labels0["experiment"] != "b" || labels0["other"] != nil {
+		t.Errorf("got labels %v, want the configured baggage members", labels0)
+	}
+	for _, e := range got[1:] {
+		if tenant := labels(e)["tenant"]; tenant != "globex" {
+			t.Errorf("got tenant %v, want the member of the baggage header", tenant)
+		}
+	}
+}
+
+func TestUserIDHashKey(t *testing.T) {
+	l, buf := newTestLogger(t, &Config{
+		Level:         LevelInfo,
+		UserIDHashKey: "k",
+		DebugUsers:    []string{"u-1"},
+	})
+	var hooked string
+	l.RegisterHook(func(e Entry) { hooked = e.UserID })
+	ctx := WithUserID(context.Background(), "u-1")
+	l.Debug(ctx, "debugged user")
+	if err := l.Audit(ctx, AuditEvent{Action: "a", Resource: "r", Outcome: AuditSuccess}); err != nil {
+		t.Fatal(err)
+	}
+
+	mac := hmac.New(sha256.New, []byte("k"))
+	mac.Write([]byte("u-1"))
+	want := hex.EncodeToString(mac.Sum(nil))
+	got := entries(t, buf)
+	if len(got) != 2 {
+		t.Fatalf("got %d entries, want 2", len(got))
+	}
+	if userID := labels(got[0])["user_id"]; userID != want {
+		t.Errorf("got user ID %v, want %s", userID, want)
+	}
+	if audit, _ := got[1]["audit"].(map[string]interface{}); audit["actor"] != want {
+		t.Errorf("got audit event %v, want the pseudonymized actor", audit)
+	}
+	if hooked != want {
+		t.Errorf("hook got user ID %q, want %s", hooked, want)
+	}
+}
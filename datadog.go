@@ -0,0 +1,78 @@
+package logging
+
+import (
+	"encoding/binary"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func init() {
+	registerFormat("datadog", datadogLayout)
+}
+
+// datadogStatuses maps zap levels to Datadog statuses.
+var datadogStatuses = map[zapcore.Level]string{
+	zapcore.DebugLevel:  "debug",
+	zapcore.InfoLevel:   "info",
+	zapcore.WarnLevel:   "warn",
+	zapcore.ErrorLevel:  "error",
+	zapcore.DPanicLevel: "critical",
+	zapcore.PanicLevel:  "alert",
+	zapcore.FatalLevel:  "emergency",
+}
+
+// datadogLayout lays out an entry with the reserved and standard attributes
+// of Datadog, the trace and span IDs being the 64-bit decimal IDs Datadog
+// correlates traces with.
+func datadogLayout(e *mappedEntry) map[string]interface{} {
+	out := e.Fields
+	for k, v := range e.Labels {
+		if _, ok := out[k]; !ok {
+			out[k] = v
+		}
+	}
+	if env, ok := e.pop("environment"); ok {
+		out["env"] = env
+	}
+	out["timestamp"] = e.Time.Format(time.RFC3339Nano)
+	out["status"] = datadogStatuses[e.Level]
+	out["message"] = e.Message
+	if e.Span.HasTraceID() {
+		traceID := e.Span.TraceID()
+		out["dd.trace_id"] = strconv.FormatUint(binary.BigEndian.Uint64(traceID[8:]), 10)
+	}
+	if e.Span.HasSpanID() {
+		spanID := e.Span.SpanID()
+		out["dd.span_id"] = strconv.FormatUint(binary.BigEndian.Uint64(spanID[:]), 10)
+	}
+	if e.Source != nil {
+		logger := map[string]interface{}{}
+		setIf(logger, "method_name", e.Source["function"])
+		setIf(logger, "file_name", e.Source["file"])
+		setIf(logger, "line", e.Source["line"])
+		out["logger"] = logger
+	}
+	if e.HTTP != nil {
+		http := map[string]interface{}{}
+		setIf(http, "method", e.HTTP["requestMethod"])
+		setIf(http, "url", e.HTTP["requestUrl"])
+		setIf(http, "status_code", e.HTTP["status"])
+		setIf(http, "useragent", e.HTTP["userAgent"])
+		setIf(http, "referer", e.HTTP["referer"])
+		out["http"] = http
+		if latency, ok := mappedLatency(e.HTTP); ok {
+			out["duration"] = latency.Nanoseconds()
+		}
+	}
+	stack, _ := e.Fields[keyStackTrace].(string)
+	if stack == "" {
+		stack = e.Stack
+	}
+	if stack != "" {
+		delete(out, keyStackTrace)
+		out["error.stack"] = stack
+	}
+	return out
+}
@@ -0,0 +1,78 @@
+package logging
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/net/context"
+)
+
+func TestDatadogFormat(t *testing.T) {
+	buf := &bytes.Buffer{}
+	l, err := New(&Config{Level: LevelDebug, Format: "datadog", ServiceName: "api", ServiceVersion: "1.2.0", Environment: "prod", Output: buf})
+	if err != nil {
+		t.Fatal(err)
+	}
+	traceID := trace.TraceID{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1, 2}
+	spanID := trace.SpanID{0, 0, 0, 0, 0, 0, 0, 3}
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: spanID}))
+	l.Infow(WithUserID(ctx, "u1"), "slow", Int("attempt", 2))
+	req := httptest.NewRequest("POST", "/users", nil)
+	l.HTTP(ctx, req, &http.Response{StatusCode: 201}, "/users", 2*time.Millisecond)
+
+	got := entries(t, buf)
+	if len(got) != 2 {
+		t.Fatalf("got %d entries, want 2", len(got))
+	}
+	for key, want := range map[string]interface{}{
+		"message":     "slow",
+		"status":      "info",
+		"service":     "api",
+		"version":     "1.2.0",
+		"env":         "prod",
+		"dd.trace_id": "258",
+		"dd.span_id":  "3",
+		"user_id":     "u1",
+		"attempt":     float64(2),
+	} {
+		if got[0][key] != want {
+			t.Errorf("got %s %#v, want %#v", key, got[0][key], want)
+		}
+	}
+	if _, ok := got[0]["timestamp"].(string); !ok {
+		t.Errorf("got timestamp %#v, want a string", got[0]["timestamp"])
+	}
+	http, _ := got[1]["http"].(map[string]interface{})
+	if http["method"] != "POST" || http["status_code"] != float64(201) {
+		t.Errorf("got http %v, want the request", http)
+	}
+	if got[1]["duration"] != float64(2*time.Millisecond) {
+		t.Errorf("got duration %v, want 2ms in nanoseconds", got[1]["duration"])
+	}
+}
+
+func TestFormatDevelopment(t *testing.T) {
+	for _, development := range []bool{false, true} {
+		buf := &bytes.Buffer{}
+		l, err := New(&Config{Level: LevelDebug, Format: "datadog", Development: development, Output: buf})
+		if err != nil {
+			t.Fatal(err)
+		}
+		panicked := func() (panicked bool) {
+			defer func() { panicked = recover() != nil }()
+			l.DPanic(context.Background(), "invariant broken")
+			return false
+		}()
+		if panicked != development {
+			t.Errorf("development %v: got panicked %v", development, panicked)
+		}
+		got := entries(t, buf)
+		if len(got) != 1 || got[0]["message"] != "invariant broken" {
+			t.Errorf("development %v: got %v, want the entry in the format", development, got)
+		}
+	}
+}
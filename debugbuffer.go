@@ -0,0 +1,81 @@
+package logging
+
+import (
+	"sync"
+
+	"go.uber.org/zap/zapcore"
+	"golang.org/x/net/context"
+)
+
+type debugBufferKey struct{}
+
+// debugEntry is a debug entry held until its request fails.
+type debugEntry struct {
+	ent    zapcore.Entry
+	fields []zapcore.Field
+}
+
+// debugBuffer holds the last debug entries of a request.
+type debugBuffer struct {
+	mu      sync.Mutex
+	entries []debugEntry
+	// next is the index the next entry is written at once the buffer is
+	// full.
+	next int
+	size int
+}
+
+// add holds an entry, dropping the oldest one if the buffer is full.
+func (b *debugBuffer) add(ent zapcore.Entry, fields []zapcore.Field) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.entries) < b.size {
+		b.entries = append(b.entries, debugEntry{ent, fields})
+		return
+	}
+	b.entries[b.next] = debugEntry{ent, fields}
+	b.next = (b.next + 1) % b.size
+}
+
+// take returns the entries held in the order they were logged and empties
+// the buffer.
+func (b *debugBuffer) take() []debugEntry {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	entries := append(b.entries[b.next:len(b.entries):len(b.entries)], b.entries[:b.next]...)
+	b.entries = nil
+	b.next = 0
+	return entries
+}
+
+// withDebugBuffer returns a copy of ctx holding the debug entries of a
+// request, if enabled.
+func (l *Logger) withDebugBuffer(ctx context.Context) context.Context {
+	if l.debugBufferSize <= 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, debugBufferKey{}, &debugBuffer{size: l.debugBufferSize})
+}
+
+// debugBufferFrom returns the buffer of ctx if the level is held in it.
+func debugBufferFrom(ctx context.Context, level Level) *debugBuffer {
+	if level != LevelDebug {
+		return nil
+	}
+	b, _ := ctx.Value(debugBufferKey{}).(*debugBuffer)
+	return b
+}
+
+// flushDebug writes the debug entries held for the request of ctx.
+func (l *Logger) flushDebug(ctx context.Context) {
+	b := debugBufferFrom(ctx, LevelDebug)
+	if b == nil {
+		return
+	}
+	core := l.zapLogger().Core()
+	for _, e := range b.take() {
+		if ce := core.Check(e.ent, nil); ce != nil {
+			ce.Write(e.fields...)
+		}
+	}
+}
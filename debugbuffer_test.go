@@ -0,0 +1,48 @@
+package logging
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/context"
+)
+
+func TestDebugBuffer(t *testing.T) {
+	l, buf := newTestLogger(t, &Config{Level: LevelInfo, DebugBufferSize: 2})
+	r := gin.New()
+	r.Use(l.RequestLogger())
+	r.GET("/ok", func(c *gin.Context) {
+		l.Debug(c.Request.Context(), "dropped")
+		c.Status(200)
+	})
+	r.GET("/error", func(c *gin.Context) {
+		for _, msg := range []string{"step 1", "step 2", "step 3"} {
+			l.Debug(c.Request.Context(), msg)
+		}
+		l.Error(c.Request.Context(), "failed")
+		c.Status(200)
+	})
+	r.GET("/5xx", func(c *gin.Context) {
+		l.Debug(c.Request.Context(), "query")
+		c.Status(503)
+	})
+	for _, path := range []string{"/ok", "/error", "/5xx"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+	l.Debug(context.Background(), "outside of a request")
+
+	var got []string
+	for _, e := range entries(t, buf) {
+		got = append(got, e["severity"].(string)+" "+e["message"].(string))
+	}
+	want := []string{
+		"INFO request log",
+		"DEBUG step 2", "DEBUG step 3", "ERROR failed", "INFO request log",
+		"DEBUG query", "ERROR request log",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got entries\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
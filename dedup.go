@@ -0,0 +1,144 @@
+package logging
+
+import (
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// maxDedupMessages caps the number of messages tracked, the repeats of the
+// messages dropped beyond are reported right away.
+const maxDedupMessages = 1024
+
+// dedupOptions returns the options collapsing the repeats of a message, to
+// be applied last.
+func dedupOptions(c *Config) []zap.Option {
+	if c == nil || c.Dedup.Window <= 0 {
+		return nil
+	}
+	d := &deduper{window: c.Dedup.Window, seen: map[dedupKey]*dedupWindow{}}
+	return []zap.Option{zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return &dedupCore{Core: core, d: d}
+	})}
+}
+
+type dedupKey struct {
+	level   zapcore.Level
+	message string
+}
+
+// dedupWindow tracks a message logged at start and repeated count times
+// since, last through core.
+type dedupWindow struct {
+	start time.Time
+	last  zapcore.Entry
+	core  zapcore.Core
+	count int
+}
+
+// deduper tracks the messages logged by the cores of a logger.
+type deduper struct {
+	mu     sync.Mutex
+	window time.Duration
+	seen   map[dedupKey]*dedupWindow
+	pruned time.Time
+}
+
+// repeat records an occurrence of the entry logged through core and reports
+// whether it repeats a message logged within the window. It returns the
+// windows whose repeats must be reported.
+func (d *deduper) repeat(core zapcore.Core, ent zapcore.Entry) (bool, []*dedupWindow) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	key := dedupKey{ent.Level, ent.Message}
+	expired := d.prune(ent.Time)
+	if w, ok := d.seen[key]; ok {
+		if ent.Time.Sub(w.start) < d.window {
+			w.last = ent
+			w.core = core
+			w.count++
+			return true, expired
+		}
+		delete(d.seen, key)
+		if w.count > 0 {
+			expired = append(expired, w)
+		}
+	}
+	if len(d.seen) >= maxDedupMessages {
+		expired = append(expired, d.drain()...)
+	}
+	d.seen[key] = &dedupWindow{start: ent.Time}
+	return false, expired
+}
+
+// prune drops the expired windows at most once per window.
+func (d *deduper) prune(now time.Time) []*dedupWindow {
+	if now.Sub(d.pruned) < d.window {
+		return nil
+	}
+	d.pruned = now
+	var expired []*dedupWindow
+	for key, w := range d.seen {
+		if now.Sub(w.start) >= d.window {
+			delete(d.seen, key)
+			if w.count > 0 {
+				expired = append(expired, w)
+			}
+		}
+	}
+	return expired
+}
+
+// drain drops all the windows and returns those with repeats.
+func (d *deduper) drain() []*dedupWindow {
+	var repeated []*dedupWindow
+	for _, w := range d.seen {
+		if w.count > 0 {
+			repeated = append(repeated, w)
+		}
+	}
+	d.seen = map[dedupKey]*dedupWindow{}
+	return repeated
+}
+
+// report logs the last repeat of each window with the number of repeats.
+func (d *deduper) report(windows []*dedupWindow) {
+	for _, w := range windows {
+		if ce := w.core.Check(w.last, nil); ce != nil {
+			ce.Write(zap.Int("count", w.count))
+		}
+	}
+}
+
+// dedupCore drops the repeats of a message within the window, the last one
+// being logged with their count once the window is over.
+type dedupCore struct {
+	zapcore.Core
+	d *deduper
+}
+
+func (c *dedupCore) With(fields []zapcore.Field) zapcore.Core {
+	return &dedupCore{Core: c.Core.With(fields), d: c.d}
+}
+
+func (c *dedupCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.Enabled(ent.Level) {
+		return ce
+	}
+	repeated, expired := c.d.repeat(c.Core, ent)
+	c.d.report(expired)
+	if repeated {
+		return ce
+	}
+	return c.Core.Check(ent, ce)
+}
+
+func (c *dedupCore) Sync() error {
+	c.d.mu.Lock()
+	repeated := c.d.drain()
+	c.d.mu.Unlock()
+	c.d.report(repeated)
+	return c.Core.Sync()
+}
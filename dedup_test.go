@@ -0,0 +1,55 @@
+package logging
+
+import (
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// manualClock is a Clock returning the time it is set to.
+type manualClock struct {
+	now time.Time
+}
+
+func (c *manualClock) Now() time.Time {
+	return c.now
+}
+
+func TestDedup(t *testing.T) {
+	clock := &manualClock{now: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)}
+	l, buf := newTestLogger(t, &Config{Clock: clock, Dedup: Dedup{Window: time.Minute}})
+	for i := 0; i < 5; i++ {
+		l.Error(context.Background(), "retry failed")
+	}
+	l.Info(context.Background(), "retry failed")
+	clock.now = clock.now.Add(2 * time.Minute)
+	l.Error(context.Background(), "retry failed")
+
+	got := entries(t, buf)
+	if len(got) != 4 {
+		t.Fatalf("got %d entries, want 4: %v", len(got), got)
+	}
+	if got[0]["count"] != nil || got[1]["severity"] != "INFO" {
+		t.Errorf("got first entries %v, want the first error and the info", got[:2])
+	}
+	if got[2]["count"] != float64(4) {
+		t.Errorf("got count %v, want the 4 repeats", got[2]["count"])
+	}
+	if got[3]["message"] != "retry failed" || got[3]["count"] != nil {
+		t.Errorf("got %v, want the message logged again after the window", got[3])
+	}
+}
+
+func TestDedupSync(t *testing.T) {
+	l, buf := newTestLogger(t, &Config{Dedup: Dedup{Window: time.Hour}})
+	l.Warn(context.Background(), "slow")
+	l.Warn(context.Background(), "slow")
+	if err := l.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	got := entries(t, buf)
+	if len(got) != 2 || got[1]["count"] != float64(1) {
+		t.Errorf("got %v, want the repeat reported on sync", got)
+	}
+}
@@ -0,0 +1,97 @@
+package logging
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// EchoRequestLogger provides an echo middleware to log HTTP requests as
+// RequestLogger.
+func EchoRequestLogger(opts ...Option) echo.MiddlewareFunc {
+	return echoRequestLogger(nil, opts)
+}
+
+// EchoRequestLogger provides an echo middleware to log HTTP requests with l.
+func (l *Logger) EchoRequestLogger(opts ...Option) echo.MiddlewareFunc {
+	return echoRequestLogger(l, opts)
+}
+
+func echoRequestLogger(logger *Logger, opts []Option) echo.MiddlewareFunc {
+	o := newMiddlewareOptions(opts)
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			l := orStd(logger)
+			r := c.Request()
+			// Do nothing if the request is on the blacklist.
+			if o.excludes.match(r.Method, r.URL.EscapedPath()) {
+				return next(c)
+			}
+			header := r.Header.Get
+			ctx := withHeaderBaggage(withHeaderTrace(r.Context(), header), header)
+			ctx, requestID := withRequestID(ctx, header(requestIDHeader))
+			c.Response().Header().Set(requestIDHeader, requestID)
+			ctx = withRoute(ctx, c.Path(), r.URL.Path)
+			ctx = l.withForceDebug(ctx, header(debugHeader))
+			c.SetRequest(r.WithContext(withLogger(l.withDebugBuffer(ctx), l)))
+			bodyCapture, headerCapture := o.captures(l)
+			var reqBody []byte
+			var reqTruncated bool
+			var resBody *capturedBody
+			if b := bodyCapture; b != nil {
+				if b.captures(header("Content-Type")) {
+					reqBody, reqTruncated, c.Request().Body = b.captureRequestBody(c.Request().Body)
+				}
+				resBody = &capturedBody{max: b.maxBytes}
+				c.Response().Writer = &responseWriter{ResponseWriter: c.Response().Writer, body: resBody}
+			}
+			start := o.now(l)
+			err := next(c)
+			if err != nil {
+				// Let the error handler write the response so the logged
+				// status matches what the client receives.
+				c.Error(err)
+			}
+			duration := o.since(l, start)
+
+			r = c.Request()
+			status := c.Response().Status
+			// Failed requests are always logged.
+			failed := err != nil || status >= http.StatusInternalServerError
+			if !failed && !o.sample(r.Context(), l, c.Path(), r.URL.Path, status) {
+				return nil
+			}
+			level, extra := l.slowRequest(l.statusLevel(status), nil, c.Path(), r.URL.Path, duration)
+			if err != nil {
+				extra = append(extra, zap.NamedError(l.keyError, err))
+			}
+			if h := headerCapture; h != nil {
+				extra = append(extra, h.fields(r.Header, c.Response().Header())...)
+			}
+			if b := bodyCapture; b != nil {
+				if f, ok := b.field(r.Context(), l, "request_body", header("Content-Type"), reqBody, reqTruncated); ok {
+					extra = append(extra, f)
+				}
+				if f, ok := b.field(r.Context(), l, "response_body", c.Response().Header().Get("Content-Type"), resBody.body.Bytes(), resBody.truncated); ok {
+					extra = append(extra, f)
+				}
+			}
+			// The body has already been handled, don't count it twice.
+			logged := *r
+			logged.Body = nil
+			l.zhttp(r.Context(),
+				level,
+				&logged,
+				&http.Response{
+					StatusCode:    status,
+					ContentLength: c.Response().Size,
+				},
+				c.Path(),
+				duration,
+				extra...,
+			)
+			return nil
+		}
+	}
+}
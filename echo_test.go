@@ -0,0 +1,64 @@
+package logging
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestEchoRequestLogger(t *testing.T) {
+	l, buf := newTestLogger(t, &Config{})
+	e := echo.New()
+	e.Use(l.EchoRequestLogger(WithExcludes("/health")))
+	e.GET("/users/:id", func(c echo.Context) error {
+		l.Info(c.Request().Context(), "handling")
+		return c.String(http.StatusOK, "user")
+	})
+	e.GET("/missing/:id", func(c echo.Context) error {
+		return echo.NewHTTPError(http.StatusNotFound, "no such thing")
+	})
+	e.GET("/health", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	var requestIDs []string
+	for _, path := range []string{"/users/1", "/missing/2", "/health"} {
+		req := httptest.NewRequest("GET", path, nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, req)
+		requestIDs = append(requestIDs, w.Header().Get("X-Request-ID"))
+	}
+
+	got := entries(t, buf)
+	if len(got) != 3 {
+		t.Fatalf("got %d entries, want 3", len(got))
+	}
+	if id := labels(got[0])["request_id"]; id != requestIDs[0] {
+		t.Errorf("got request ID %v in the handler, want %s", id, requestIDs[0])
+	}
+	for i, want := range []struct {
+		route    string
+		status   float64
+		size     string
+		severity string
+	}{
+		{"/users/:id", 200, "4", "INFO"},
+		{"/missing/:id", 404, "28", "WARNING"},
+	} {
+		e := got[i+1]
+		req, _ := e["httpRequest"].(map[string]interface{})
+		if req["status"] != want.status || req["responseSize"] != want.size || e["severity"] != want.severity {
+			t.Errorf("entry %d: got status %v, size %v, severity %v, want %v, %s, %s", i, req["status"], req["responseSize"], e["severity"], want.status, want.size, want.severity)
+		}
+		labels := labels(e)
+		if labels["route"] != want.route || labels["remote_ip"] != "192.0.2.1" || labels["request_id"] != requestIDs[i] {
+			t.Errorf("entry %d: got labels %v, want route %s", i, labels, want.route)
+		}
+	}
+	if got[2]["err"] == nil {
+		t.Error("got no error logged for the failed request")
+	}
+}
@@ -0,0 +1,88 @@
+package logging
+
+import (
+	"strconv"
+	"time"
+)
+
+// ecsVersion is the version of the Elastic Common Schema of the entries.
+const ecsVersion = "8.11.0"
+
+func init() {
+	registerFormat("ecs", ecsLayout)
+}
+
+// ecsLayout lays out an entry with the fields of the Elastic Common Schema.
+// Labels are logged under labels and other fields are kept at the top level.
+func ecsLayout(e *mappedEntry) map[string]interface{} {
+	out := e.Fields
+	out["@timestamp"] = e.Time.UTC().Format(time.RFC3339Nano)
+	out["message"] = e.Message
+	out["ecs"] = map[string]interface{}{"version": ecsVersion}
+
+	log := map[string]interface{}{"level": levelFromZap(e.Level).String()}
+	if e.Source != nil {
+		origin := map[string]interface{}{}
+		setIf(origin, "function", e.Source["function"])
+		if file, ok := e.Source["file"].(string); ok && file != "" {
+			f := map[string]interface{}{"name": file}
+			line, _ := e.Source["line"].(string)
+			if line, err := strconv.Atoi(line); err == nil {
+				f["line"] = line
+			}
+			origin["file"] = f
+		}
+		log["origin"] = origin
+	}
+	out["log"] = log
+
+	service := map[string]interface{}{}
+	for field, key := range map[string]string{"service": "name", "version": "version", "environment": "environment"} {
+		if v, ok := e.pop(field); ok {
+			service[key] = v
+		}
+	}
+	if len(service) > 0 {
+		out["service"] = service
+	}
+	if e.Span.HasTraceID() {
+		out["trace"] = map[string]interface{}{"id": e.Span.TraceID().String()}
+	}
+	if e.Span.HasSpanID() {
+		out["span"] = map[string]interface{}{"id": e.Span.SpanID().String()}
+	}
+	if len(e.Labels) > 0 {
+		out["labels"] = e.Labels
+	}
+	if e.HTTP != nil {
+		request := map[string]interface{}{}
+		setIf(request, "method", e.HTTP["requestMethod"])
+		setIf(request, "referrer", e.HTTP["referer"])
+		http := map[string]interface{}{"request": request}
+		if status, ok := e.HTTP["status"].(int); ok && status != 0 {
+			http["response"] = map[string]interface{}{"status_code": status}
+		}
+		if proto, ok := e.HTTP["protocol"].(string); ok && proto != "" {
+			http["version"] = proto
+		}
+		out["http"] = http
+		if url, ok := e.HTTP["requestUrl"].(string); ok && url != "" {
+			out["url"] = map[string]interface{}{"original": url}
+		}
+		if agent, ok := e.HTTP["userAgent"].(string); ok && agent != "" {
+			out["user_agent"] = map[string]interface{}{"original": agent}
+		}
+		if latency, ok := mappedLatency(e.HTTP); ok {
+			out["event"] = map[string]interface{}{"duration": latency.Nanoseconds()}
+		}
+	}
+	stack, _ := e.Fields[keyStackTrace].(string)
+	if stack == "" {
+		stack = e.Stack
+	}
+	if stack != "" {
+		delete(out, keyStackTrace)
+		out["error"] = map[string]interface{}{"stack_trace": stack}
+	}
+	return out
+}
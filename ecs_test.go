@@ -0,0 +1,61 @@
+package logging
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/net/context"
+)
+
+func TestECSFormat(t *testing.T) {
+	buf := &bytes.Buffer{}
+	l, err := New(&Config{Level: LevelDebug, Format: "ecs", ServiceName: "api", ServiceVersion: "1.2.0", Environment: "prod", Output: buf})
+	if err != nil {
+		t.Fatal(err)
+	}
+	traceID := trace.TraceID{1}
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID}))
+	req := httptest.NewRequest("GET", "/users?page=2", nil)
+	l.HTTP(ctx, req, &http.Response{StatusCode: 404}, "/users", time.Millisecond)
+
+	got := entries(t, buf)
+	if len(got) != 1 {
+		t.Fatalf("got %d entries, want 1", len(got))
+	}
+	entry := got[0]
+	path := func(keys ...string) interface{} {
+		var v interface{} = entry
+		for _, k := range keys {
+			m, _ := v.(map[string]interface{})
+			v = m[k]
+		}
+		return v
+	}
+	for _, c := range []struct {
+		keys []string
+		want interface{}
+	}{
+		{[]string{"message"}, "request log"},
+		{[]string{"ecs", "version"}, ecsVersion},
+		{[]string{"log", "level"}, "info"},
+		{[]string{"service", "name"}, "api"},
+		{[]string{"service", "version"}, "1.2.0"},
+		{[]string{"service", "environment"}, "prod"},
+		{[]string{"trace", "id"}, traceID.String()},
+		{[]string{"http", "request", "method"}, "GET"},
+		{[]string{"http", "response", "status_code"}, float64(404)},
+		{[]string{"url", "original"}, "/users?page=2"},
+		{[]string{"labels", "route"}, "/users"},
+	} {
+		if got := path(c.keys...); got != c.want {
+			t.Errorf("got %v %#v, want %#v", c.keys, got, c.want)
+		}
+	}
+	if _, ok := entry["@timestamp"].(string); !ok {
+		t.Errorf("got @timestamp %#v, want a string", entry["@timestamp"])
+	}
+}
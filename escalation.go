@@ -0,0 +1,78 @@
+package logging
+
+import (
+	"sync"
+	"time"
+)
+
+// maxWarnTemplates caps the number of templates counted, so that warnings
+// with preformatted messages don't grow the counts without bound.
+const maxWarnTemplates = 1024
+
+// defaultWarnWindow is the window of WarnEscalation if unset.
+const defaultWarnWindow = time.Minute
+
+// warnEscalator counts warnings per message template and reports when a
+// template exceeds the configured threshold within the window.
+type warnEscalator struct {
+	mu     sync.Mutex
+	count  int
+	window time.Duration
+	seen   map[string]*warnWindow
+	pruned time.Time
+}
+
+type warnWindow struct {
+	start time.Time
+	count int
+}
+
+func newWarnEscalator(c WarnEscalation) *warnEscalator {
+	if c.Count <= 0 {
+		return nil
+	}
+	window := c.Window
+	if window <= 0 {
+		window = defaultWarnWindow
+	}
+	return &warnEscalator{
+		count:  c.Count,
+		window: window,
+		seen:   map[string]*warnWindow{},
+	}
+}
+
+// escalate records an occurrence of the template at the given time and
+// returns true if it should be logged at error severity.
+func (e *warnEscalator) escalate(template string, now time.Time) bool {
+	if e == nil {
+		return false
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	w, ok := e.seen[template]
+	if !ok || now.Sub(w.start) >= e.window {
+		e.prune(now)
+		w = &warnWindow{start: now}
+		e.seen[template] = w
+	}
+	w.count++
+	return w.count > e.count
+}
+
+// prune drops the expired windows at most once per window, and all the
+// counts once there are too many templates.
+func (e *warnEscalator) prune(now time.Time) {
+	if now.Sub(e.pruned) >= e.window {
+		for template, w := range e.seen {
+			if now.Sub(w.start) >= e.window {
+				delete(e.seen, template)
+			}
+		}
+		e.pruned = now
+	}
+	if len(e.seen) >= maxWarnTemplates {
+		e.seen = map[string]*warnWindow{}
+	}
+}
@@ -0,0 +1,63 @@
+package logging
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestWarnEscalation(t *testing.T) {
+	const count = 3
+	l, buf := newTestLogger(t, &Config{WarnEscalation: WarnEscalation{Count: count, Window: time.Minute}})
+	for i := 0; i <= count; i++ {
+		l.Warn(context.Background(), "disk at %d%%", 90+i)
+	}
+
+	got := entries(t, buf)
+	if len(got) != count+1 {
+		t.Fatalf("got %d entries, want %d", len(got), count+1)
+	}
+	for i, entry := range got {
+		want := "WARNING"
+		if i == count {
+			want = "ERROR"
+		}
+		if severity := entry["severity"]; severity != want {
+			t.Errorf("entry %d: got severity %v, want %s", i, severity, want)
+		}
+	}
+}
+
+func TestWarnEscalatorPrunes(t *testing.T) {
+	e := newWarnEscalator(WarnEscalation{Count: 1, Window: time.Second})
+	now := time.Now()
+	for i := 0; i < 100; i++ {
+		e.escalate(fmt.Sprint("message ", i), now)
+	}
+	e.escalate("late", now.Add(2*time.Second))
+	if n := len(e.seen); n != 1 {
+		t.Errorf("got %d templates after the window, want 1", n)
+	}
+
+	e = newWarnEscalator(WarnEscalation{Count: 1})
+	for i := 0; i < 10*maxWarnTemplates; i++ {
+		e.escalate(fmt.Sprint("message ", i), now)
+	}
+	if n := len(e.seen); n > maxWarnTemplates {
+		t.Errorf("got %d templates, want at most %d", n, maxWarnTemplates)
+	}
+}
+
+func TestWarnEscalationDefaultWindow(t *testing.T) {
+	e := newWarnEscalator(WarnEscalation{Count: 1})
+	now := time.Now()
+	e.escalate("disk full", now)
+	if !e.escalate("disk full", now.Add(time.Second)) {
+		t.Error("got a repeat within the default window not escalated")
+	}
+	if e.escalate("disk full", now.Add(2*defaultWarnWindow)) {
+		t.Error("got a repeat after the default window escalated, want the count reset")
+	}
+}
@@ -0,0 +1,75 @@
+package logging
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// requestExclude matches the requests whose access logs are skipped.
+type requestExclude struct {
+	// method is empty to match all methods.
+	method  string
+	pattern string
+	re      *regexp.Regexp
+}
+
+// requestExcludes are the requests excluded from the access logs, parsed from
+// patterns that are:
+//
+//   - a path, e.g. /health,
+//   - a path ending with *, matching the paths it prefixes, e.g. /healthz*,
+//   - a glob matched with path.Match, e.g. /users/*/avatar,
+//   - a regular expression prefixed with ~, e.g. ~^/v[0-9]+/ping$,
+//
+// optionally preceded by a method and a space, e.g. "OPTIONS *" or
+// "GET /healthz*".
+type requestExcludes []requestExclude
+
+// newRequestExcludes parses the patterns, it panics if a regular expression
+// is invalid.
+func newRequestExcludes(patterns []string) requestExcludes {
+	excludes := make(requestExcludes, 0, len(patterns))
+	for _, p := range patterns {
+		var e requestExclude
+		if method, rest, ok := strings.Cut(p, " "); ok && isMethod(method) {
+			e.method = method
+			p = strings.TrimSpace(rest)
+		}
+		if expr, ok := strings.CutPrefix(p, "~"); ok {
+			e.re = regexp.MustCompile(expr)
+		}
+		e.pattern = p
+		excludes = append(excludes, e)
+	}
+	return excludes
+}
+
+// isMethod reports whether s is an HTTP method.
+func isMethod(s string) bool {
+	switch s {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
+
+// match reports whether the access log of the request is skipped.
+func (excludes requestExcludes) match(method, path string) bool {
+	for _, e := range excludes {
+		if e.method != "" && e.method != method {
+			continue
+		}
+		if e.re != nil {
+			if e.re.MatchString(path) {
+				return true
+			}
+			continue
+		}
+		if routeMatch(e.pattern, path) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,63 @@
+package logging
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequestExcludes(t *testing.T) {
+	excludes := newRequestExcludes([]string{
+		"/health",
+		"GET /healthz*",
+		"OPTIONS *",
+		"/users/*/avatar",
+		`~^/v[0-9]+/ping$`,
+	})
+	for _, tt := range []struct {
+		method, path string
+		want         bool
+	}{
+		{"GET", "/health", true},
+		{"POST", "/health", true},
+		{"GET", "/health/db", false},
+		{"GET", "/healthz/live", true},
+		{"POST", "/healthz/live", false},
+		{"OPTIONS", "/users/1", true},
+		{"GET", "/users/1/avatar", true},
+		{"GET", "/users/1/2/avatar", false},
+		{"GET", "/v2/ping", true},
+		{"GET", "/v2/ping/x", false},
+		{"GET", "/users", false},
+	} {
+		if got := excludes.match(tt.method, tt.path); got != tt.want {
+			t.Errorf("match(%s %s) = %v, want %v", tt.method, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestRequestLoggerExcludes(t *testing.T) {
+	l, buf := newTestLogger(t, &Config{})
+	r := gin.New()
+	r.Use(l.RequestLogger(WithExcludes("OPTIONS *", "/healthz*")))
+	r.Any("/*path", func(c *gin.Context) {
+		c.Status(200)
+	})
+	for _, req := range []struct{ method, path string }{
+		{"OPTIONS", "/users"},
+		{"GET", "/healthz/ready"},
+		{"GET", "/users"},
+	} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(req.method, req.path, nil))
+	}
+
+	got := entries(t, buf)
+	if len(got) != 1 {
+		t.Fatalf("got %d entries, want 1", len(got))
+	}
+	req, _ := got[0]["httpRequest"].(map[string]interface{})
+	if req["requestMethod"] != "GET" || req["requestUrl"] != "/users" {
+		t.Errorf("got request %v, want GET /users", req)
+	}
+}
@@ -0,0 +1,81 @@
+package logging
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync/atomic"
+	"syscall"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// outputErrors counts the entries the outputs of all the loggers failed to
+// write, and their failed flushes.
+var outputErrors atomic.Uint64
+
+// OutputErrors returns the number of entries the outputs failed to write,
+// such as a network output down or a full disk, and of their failed flushes.
+// The entries are written to the standard error output instead. The entries
+// dropped by the outputs that can't keep up are counted too.
+func OutputErrors() uint64 {
+	return outputErrors.Load()
+}
+
+// fallbackOutput is the standard error output the entries the outputs fail
+// to write are written to.
+var fallbackOutput zapcore.WriteSyncer = zapcore.Lock(zapcore.AddSync(struct{ io.Writer }{os.Stderr}))
+
+// fallbackCore writes the entries its core fails to write to the standard
+// error output.
+type fallbackCore struct {
+	zapcore.Core
+	enc zapcore.Encoder
+}
+
+func newFallbackCore(core zapcore.Core, enc zapcore.Encoder) zapcore.Core {
+	return &fallbackCore{Core: core, enc: enc}
+}
+
+func (c *fallbackCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := *c
+	clone.Core = c.Core.With(fields)
+	clone.enc = c.enc.Clone()
+	for _, f := range fields {
+		f.AddTo(clone.enc)
+	}
+	return &clone
+}
+
+func (c *fallbackCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *fallbackCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	if err := c.Core.Write(ent, fields); err == nil {
+		return nil
+	}
+	outputErrors.Add(1)
+	buf, err := c.enc.EncodeEntry(ent, fields)
+	if err != nil {
+		return err
+	}
+	defer buf.Free()
+	_, err = fallbackOutput.Write(buf.Bytes())
+	return err
+}
+
+// reportOutputError counts a failed flush and reports it on the standard
+// error output, but for the standard streams, which can't be synced when they
+// are pipes or terminals.
+func reportOutputError(err error) {
+	if err == nil || errors.Is(err, syscall.EINVAL) || errors.Is(err, syscall.ENOTTY) {
+		return
+	}
+	outputErrors.Add(1)
+	fmt.Fprintf(os.Stderr, "logging: %v\n", err)
+}
@@ -0,0 +1,74 @@
+package logging
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"syscall"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"golang.org/x/net/context"
+)
+
+// failingWriter fails all the writes, as a full disk.
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("no space left on device")
+}
+
+// useFallbackOutput records the entries falling back for the test.
+func useFallbackOutput(t *testing.T) *bytes.Buffer {
+	buf := &bytes.Buffer{}
+	old := fallbackOutput
+	fallbackOutput = zapcore.AddSync(buf)
+	t.Cleanup(func() { fallbackOutput = old })
+	return buf
+}
+
+func TestFallbackCore(t *testing.T) {
+	cfg := zap.NewProductionEncoderConfig()
+	primary := zapcore.NewCore(zapcore.NewJSONEncoder(cfg), zapcore.AddSync(failingWriter{}), zapcore.DebugLevel)
+	core := newFallbackCore(primary, zapcore.NewJSONEncoder(cfg)).(*fallbackCore)
+	buf := useFallbackOutput(t)
+
+	before := OutputErrors()
+	z := zap.New(core).With(zap.String("service", "api"))
+	z.Info("kept")
+
+	if got := OutputErrors() - before; got != 1 {
+		t.Errorf("counted %d output errors, want 1", got)
+	}
+	got := entries(t, buf)
+	if len(got) != 1 || got[0]["msg"] != "kept" || got[0]["service"] != "api" {
+		t.Errorf("fell back to %v, want the entry with its fields", got)
+	}
+}
+
+func TestFallbackOutput(t *testing.T) {
+	l, err := New(&Config{ProjectID: "test", Level: LevelDebug, Output: failingWriter{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := OutputErrors()
+	l.Info(context.Background(), "output down")
+	if got := OutputErrors() - before; got != 1 {
+		t.Errorf("counted %d output errors, want 1", got)
+	}
+}
+
+func TestReportOutputError(t *testing.T) {
+	before := OutputErrors()
+	reportOutputError(nil)
+	// Syncing stderr fails so when it's a pipe.
+	reportOutputError(&os.PathError{Op: "sync", Path: "/dev/stderr", Err: syscall.EINVAL})
+	if got := OutputErrors() - before; got != 0 {
+		t.Errorf("counted %d output errors for the standard streams, want 0", got)
+	}
+	reportOutputError(errors.New("connection refused"))
+	if got := OutputErrors() - before; got != 1 {
+		t.Errorf("counted %d output errors, want 1", got)
+	}
+}
@@ -0,0 +1,93 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Field is a typed field logged in the entry payload. Fields can be mixed
+// with the key/value pairs given to Infow and Errorw.
+type Field = zap.Field
+
+// String constructs a field with a string value.
+func String(key string, val string) Field {
+	return zap.String(key, val)
+}
+
+// Strings constructs a field with a slice of strings.
+func Strings(key string, val []string) Field {
+	return zap.Strings(key, val)
+}
+
+// Int constructs a field with an int value.
+func Int(key string, val int) Field {
+	return zap.Int(key, val)
+}
+
+// Int64 constructs a field with an int64 value.
+func Int64(key string, val int64) Field {
+	return zap.Int64(key, val)
+}
+
+// Float64 constructs a field with a float64 value.
+func Float64(key string, val float64) Field {
+	return zap.Float64(key, val)
+}
+
+// Bool constructs a field with a bool value.
+func Bool(key string, val bool) Field {
+	return zap.Bool(key, val)
+}
+
+// Duration constructs a field with a duration value.
+func Duration(key string, val time.Duration) Field {
+	return zap.Duration(key, val)
+}
+
+// Time constructs a field with a time value.
+func Time(key string, val time.Time) Field {
+	return zap.Time(key, val)
+}
+
+// Err constructs a field with the error under the "error" key.
+func Err(err error) Field {
+	return zap.Error(err)
+}
+
+// Any constructs a field with an arbitrary value, choosing the best encoding
+// for its type.
+func Any(key string, val interface{}) Field {
+	return zap.Any(key, val)
+}
+
+// Lazy constructs a field whose value is computed by value only when the
+// entry is written, once whatever the number of outputs, so that expensive
+// values cost nothing on the entries filtered out.
+func Lazy(key string, value func() interface{}) Field {
+	return zap.Reflect(key, &lazyValue{value: sync.OnceValue(value)})
+}
+
+// lazyValue is encoded as the value computed, by the JSON encoders and those
+// formatting values.
+type lazyValue struct {
+	value func() interface{}
+}
+
+func (v *lazyValue) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v.value()); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+func (v *lazyValue) String() string {
+	return fmt.Sprintf("%+v", v.value())
+}
@@ -0,0 +1,41 @@
+package logging
+
+import (
+	"testing"
+
+	"golang.org/x/net/context"
+)
+
+func TestLazy(t *testing.T) {
+	l, buf := newTestLogger(t, &Config{Level: LevelWarn})
+	calls := 0
+	dump := Lazy("dump", func() interface{} {
+		calls++
+		return map[string]int{"items": 3}
+	})
+
+	l.Infow(context.Background(), "skipped", dump)
+	if calls != 0 {
+		t.Fatalf("value computed %d times for a filtered entry", calls)
+	}
+
+	l.Errorw(context.Background(), "written", dump)
+	if calls != 1 {
+		t.Errorf("value computed %d times, want 1", calls)
+	}
+	got := entries(t, buf)
+	if len(got) != 1 {
+		t.Fatalf("got %d entries, want 1", len(got))
+	}
+	d, _ := got[0]["dump"].(map[string]interface{})
+	if d["items"] != float64(3) {
+		t.Errorf("dump = %v, want the value computed", got[0]["dump"])
+	}
+}
+
+func TestLazyString(t *testing.T) {
+	v := Lazy("n", func() interface{} { return 42 }).Interface
+	if s, ok := v.(interface{ String() string }); !ok || s.String() != "42" {
+		t.Errorf("lazy value = %v, want 42", v)
+	}
+}
@@ -0,0 +1,147 @@
+package logging
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"go.uber.org/zap/zapcore"
+)
+
+// fluentdAckTimeout is the time waited for fluentd to acknowledge a batch.
+const fluentdAckTimeout = 10 * time.Second
+
+// FluentdOutput sends the entries to fluentd or fluent-bit with the forward
+// protocol, each batch being acknowledged.
+type FluentdOutput struct {
+	// Address is the TCP address of the forward input, e.g. localhost:24224.
+	Address string `json:"address" yaml:"address"`
+	// Tag defaults to "app".
+	Tag string `json:"tag" yaml:"tag"`
+	// BatchSize and BatchInterval default to 100 entries and a second.
+	BatchSize     int           `json:"batch_size" yaml:"batch_size"`
+	BatchInterval time.Duration `json:"batch_interval" yaml:"batch_interval"`
+}
+
+// fluentdEvent is an entry waiting to be forwarded.
+type fluentdEvent struct {
+	time   time.Time
+	record map[string]interface{}
+}
+
+// fluentdCore returns a core forwarding the entries to fluentd, and the
+// function flushing it on close.
+func (l *Logger) fluentdCore(out *FluentdOutput, enc zapcore.Encoder) (zapcore.Core, func(), error) {
+	tag := out.Tag
+	if tag == "" {
+		tag = "app"
+	}
+	w, err := newConnWriter(func() (net.Conn, error) {
+		return net.Dial("tcp", out.Address)
+	}, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	b := newBatcher(out.BatchSize, out.BatchInterval, func(events []fluentdEvent) error {
+		msg, chunk, err := fluentdMessage(tag, events)
+		if err != nil {
+			return err
+		}
+		return w.do(func(conn net.Conn) error {
+			return sendFluentd(conn, msg, chunk)
+		})
+	}, func(event fluentdEvent) []byte {
+		line, _ := json.Marshal(event.record)
+		return line
+	})
+	core := &sinkCore{
+		LevelEnabler: l.coreLevel,
+		enc:          enc,
+		write: func(ent zapcore.Entry, _ map[string]string, line []byte) error {
+			record := map[string]interface{}{}
+			if err := json.Unmarshal(line, &record); err != nil {
+				return err
+			}
+			b.add(fluentdEvent{time: ent.Time, record: record})
+			return nil
+		},
+		sync: b.flush,
+	}
+	return core, func() {
+		b.close()
+		w.close()
+	}, nil
+}
+
+// fluentdMessage encodes the events as a message of the Forward mode, with
+// a chunk option requesting an acknowledgment of the returned chunk ID.
+func fluentdMessage(tag string, events []fluentdEvent) ([]byte, string, error) {
+	var id [16]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return nil, "", err
+	}
+	chunk := base64.StdEncoding.EncodeToString(id[:])
+	buf := &bytes.Buffer{}
+	enc := msgpack.NewEncoder(buf)
+	enc.SetSortMapKeys(true)
+	if err := enc.EncodeArrayLen(3); err != nil {
+		return nil, "", err
+	}
+	if err := enc.EncodeString(tag); err != nil {
+		return nil, "", err
+	}
+	if err := enc.EncodeArrayLen(len(events)); err != nil {
+		return nil, "", err
+	}
+	for _, e := range events {
+		if err := enc.EncodeArrayLen(2); err != nil {
+			return nil, "", err
+		}
+		// EventTime extension, seconds and nanoseconds as big-endian
+		// 32-bit integers.
+		if err := enc.EncodeExtHeader(0, 8); err != nil {
+			return nil, "", err
+		}
+		var t [8]byte
+		binary.BigEndian.PutUint32(t[:4], uint32(e.time.Unix()))
+		binary.BigEndian.PutUint32(t[4:], uint32(e.time.Nanosecond()))
+		buf.Write(t[:])
+		if err := enc.EncodeMap(e.record); err != nil {
+			return nil, "", err
+		}
+	}
+	option := map[string]interface{}{
+		"chunk": chunk,
+		"size":  len(events),
+	}
+	if err := enc.EncodeMap(option); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), chunk, nil
+}
+
+// sendFluentd writes the message and waits for the acknowledgment of its
+// chunk.
+func sendFluentd(conn net.Conn, msg []byte, chunk string) error {
+	if _, err := conn.Write(msg); err != nil {
+		return err
+	}
+	conn.SetReadDeadline(time.Now().Add(fluentdAckTimeout))
+	defer conn.SetReadDeadline(time.Time{})
+	var ack struct {
+		Ack string `msgpack:"ack"`
+	}
+	if err := msgpack.NewDecoder(conn).Decode(&ack); err != nil {
+		return fmt.Errorf("waiting for fluentd acknowledgment: %w", err)
+	}
+	if ack.Ack != chunk {
+		return fmt.Errorf("fluentd acknowledged chunk %q instead of %q", ack.Ack, chunk)
+	}
+	return nil
+}
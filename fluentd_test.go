@@ -0,0 +1,144 @@
+package logging
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"golang.org/x/net/context"
+)
+
+// fluentdMessageReceived is a forward message decoded by a test server.
+type fluentdMessageReceived struct {
+	tag     string
+	times   []time.Time
+	records []map[string]interface{}
+	chunk   string
+}
+
+// fluentdServer accepts a connection, decodes the forward messages and
+// acknowledges them.
+func fluentdServer(t *testing.T) (net.Listener, <-chan fluentdMessageReceived) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	messages := make(chan fluentdMessageReceived, 10)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		d := msgpack.NewDecoder(conn)
+		for {
+			var msg fluentdMessageReceived
+			if _, err := d.DecodeArrayLen(); err != nil {
+				return
+			}
+			if msg.tag, err = d.DecodeString(); err != nil {
+				return
+			}
+			n, err := d.DecodeArrayLen()
+			if err != nil {
+				return
+			}
+			for i := 0; i < n; i++ {
+				if _, err := d.DecodeArrayLen(); err != nil {
+					return
+				}
+				raw, err := d.DecodeRaw()
+				if err != nil || len(raw) < 8 {
+					return
+				}
+				ts := raw[len(raw)-8:]
+				msg.times = append(msg.times, time.Unix(int64(binary.BigEndian.Uint32(ts)), int64(binary.BigEndian.Uint32(ts[4:]))))
+				record := map[string]interface{}{}
+				if err := d.Decode(&record); err != nil {
+					return
+				}
+				msg.records = append(msg.records, record)
+			}
+			option := map[string]interface{}{}
+			if err := d.Decode(&option); err != nil {
+				return
+			}
+			msg.chunk, _ = option["chunk"].(string)
+			ack, _ := msgpack.Marshal(map[string]string{"ack": msg.chunk})
+			conn.Write(ack)
+			messages <- msg
+		}
+	}()
+	return ln, messages
+}
+
+func TestFluentd(t *testing.T) {
+	ln, messages := fluentdServer(t)
+	l, err := New(&Config{ProjectID: "test", Level: LevelDebug, Fluentd: &FluentdOutput{Address: ln.Addr().String(), Tag: "api"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	before := time.Now().Truncate(time.Second)
+	l.Info(context.Background(), "started")
+	l.Error(WithUserID(context.Background(), "u1"), "failed")
+	if err := l.Sync(); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case msg := <-messages:
+		if msg.tag != "api" {
+			t.Errorf("got tag %q, want api", msg.tag)
+		}
+		if msg.chunk == "" {
+			t.Error("got no chunk option")
+		}
+		if len(msg.records) != 2 {
+			t.Fatalf("got %d records, want 2", len(msg.records))
+		}
+		if got := msg.records[0]["message"]; got != "started" {
+			t.Errorf("got message %v, want started", got)
+		}
+		if got := msg.records[1]["severity"]; got != "ERROR" {
+			t.Errorf("got severity %v, want ERROR", got)
+		}
+		if msg.times[0].Before(before) || msg.times[0].After(time.Now()) {
+			t.Errorf("got time %v, want the time of the entry", msg.times[0])
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("got no message")
+	}
+}
+
+func TestFluentdAck(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		ack, _ := msgpack.Marshal(map[string]string{"ack": "other"})
+		conn.Write(ack)
+	}()
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	msg, chunk, err := fluentdMessage("app", []fluentdEvent{{time: time.Now(), record: map[string]interface{}{"message": "m"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sendFluentd(conn, msg, chunk); err == nil {
+		t.Error("got no error, want an error for the wrong acknowledgment")
+	}
+}
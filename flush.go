@@ -0,0 +1,68 @@
+package logging
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// flushSignals are the signals terminating the process a logger is flushed
+// on with Config.FlushOnSignal.
+var flushSignals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+
+// raise sends the signal to the process again once flushed.
+var raise = func(sig os.Signal) {
+	if p, err := os.FindProcess(os.Getpid()); err == nil {
+		p.Signal(sig)
+	}
+}
+
+// startFlushing flushes l every interval, if positive, and on the signals
+// terminating the process if onSignal, until l is closed.
+func (l *Logger) startFlushing(interval time.Duration, onSignal bool) {
+	if interval <= 0 && !onSignal {
+		return
+	}
+	var tick <-chan time.Time
+	var ticker *time.Ticker
+	if interval > 0 {
+		ticker = time.NewTicker(interval)
+		tick = ticker.C
+	}
+	sigs := make(chan os.Signal, 1)
+	if onSignal {
+		signal.Notify(sigs, flushSignals...)
+	}
+	done, stopped := make(chan struct{}), make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+		for {
+			select {
+			case <-done:
+				return
+			case <-tick:
+				reportOutputError(l.Sync())
+			case sig := <-sigs:
+				reportOutputError(l.Sync())
+				// Once no longer notified, the signal terminates the
+				// process as if it wasn't caught.
+				signal.Stop(sigs)
+				raise(sig)
+				return
+			}
+		}
+	}()
+
+	// Flushing stops before the outputs are closed, waiting for a flush in
+	// progress.
+	l.closers = append([]func(){func() {
+		if ticker != nil {
+			ticker.Stop()
+		}
+		signal.Stop(sigs)
+		close(done)
+		<-stopped
+	}}, l.closers...)
+}
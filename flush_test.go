@@ -0,0 +1,95 @@
+package logging
+
+import (
+	"os"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// syncCounter is a core counting its syncs.
+type syncCounter struct {
+	zapcore.Core
+	syncs atomic.Int32
+}
+
+func (c *syncCounter) With([]zapcore.Field) zapcore.Core {
+	return c
+}
+
+func (c *syncCounter) Sync() error {
+	c.syncs.Add(1)
+	return nil
+}
+
+// waitSyncs waits for the core to be synced at least n times.
+func waitSyncs(t *testing.T, c *syncCounter, n int32) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for c.syncs.Load() < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("synced %d times, want %d", c.syncs.Load(), n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestFlushInterval(t *testing.T) {
+	core := &syncCounter{Core: zapcore.NewNopCore()}
+	l, err := New(&Config{ProjectID: "test", Core: core, FlushInterval: time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitSyncs(t, core, 2)
+
+	l.Close()
+	synced := core.syncs.Load()
+	time.Sleep(10 * time.Millisecond)
+	if got := core.syncs.Load(); got != synced {
+		t.Errorf("synced %d times once closed", got-synced)
+	}
+}
+
+func TestFlushOnSignal(t *testing.T) {
+	raised := make(chan os.Signal, 1)
+	defer func(signals []os.Signal, r func(os.Signal)) { flushSignals, raise = signals, r }(flushSignals, raise)
+	// SIGUSR1 stands for the termination signals, which would stop the test.
+	flushSignals = []os.Signal{syscall.SIGUSR1}
+	raise = func(sig os.Signal) { raised <- sig }
+
+	core := &syncCounter{Core: zapcore.NewNopCore()}
+	l, err := New(&Config{ProjectID: "test", Core: core, FlushOnSignal: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	syscall.Kill(os.Getpid(), syscall.SIGUSR1)
+
+	select {
+	case sig := <-raised:
+		if sig != syscall.SIGUSR1 {
+			t.Errorf("raised %v, want SIGUSR1", sig)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("the signal wasn't raised again")
+	}
+	if core.syncs.Load() == 0 {
+		t.Error("not flushed before raising the signal")
+	}
+}
+
+func TestFinalizeTwice(t *testing.T) {
+	useStd(t, newLogger())
+	core := &syncCounter{Core: zapcore.NewNopCore()}
+	if err := Initialize(&Config{ProjectID: "test", Core: core, FlushInterval: time.Millisecond}); err != nil {
+		t.Fatal(err)
+	}
+	Finalize()
+	Finalize()
+	if core.syncs.Load() < 2 {
+		t.Errorf("synced %d times, want each Finalize to flush", core.syncs.Load())
+	}
+}
@@ -0,0 +1,181 @@
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+	"golang.org/x/net/context"
+)
+
+// formatEncodings maps the values of Config.Format to zap encoding names,
+// formatLayouts maps both to the layout of the format.
+var (
+	formatEncodings = map[string]string{}
+	formatLayouts   = map[string]func(e *mappedEntry) map[string]interface{}{}
+)
+
+// registerFormat registers the JSON layout of a Config.Format, built by
+// layout from the entry.
+func registerFormat(format string, layout func(e *mappedEntry) map[string]interface{}) {
+	encoding := "logging-" + format
+	formatEncodings[format] = encoding
+	formatLayouts[format] = layout
+	formatLayouts[encoding] = layout
+	err := zap.RegisterEncoder(encoding, func(cfg zapcore.EncoderConfig) (zapcore.Encoder, error) {
+		return newMappedEncoder(cfg, layout), nil
+	})
+	if err != nil {
+		panic(err)
+	}
+}
+
+// formatEncoding returns the zap encoding of a Config.Format.
+func formatEncoding(format string) (string, error) {
+	encoding, ok := formatEncodings[format]
+	if !ok {
+		return "", fmt.Errorf("logging: unknown format %q", format)
+	}
+	return encoding, nil
+}
+
+var mappedPool = buffer.NewPool()
+
+// mappedEntry is an entry being laid out by a mappedEncoder. The well-known
+// fields are taken out of Fields.
+type mappedEntry struct {
+	zapcore.Entry
+	Span   trace.SpanContext
+	Labels map[string]string
+	// HTTP and Source are the zapdriver request and source location
+	// payloads, if any.
+	HTTP   map[string]interface{}
+	Source map[string]interface{}
+	Fields map[string]interface{}
+}
+
+// pop removes the field from the entry and returns it.
+func (e *mappedEntry) pop(key string) (interface{}, bool) {
+	v, ok := e.Fields[key]
+	delete(e.Fields, key)
+	return v, ok
+}
+
+// mappedEncoder encodes entries as JSON objects laid out by a function from
+// the decoded fields, to match the schema of a log management system.
+type mappedEncoder struct {
+	*zapcore.MapObjectEncoder
+	cfg    zapcore.EncoderConfig
+	labels map[string]string
+	layout func(e *mappedEntry) map[string]interface{}
+}
+
+func newMappedEncoder(cfg zapcore.EncoderConfig, layout func(e *mappedEntry) map[string]interface{}) *mappedEncoder {
+	return &mappedEncoder{
+		MapObjectEncoder: zapcore.NewMapObjectEncoder(),
+		cfg:              cfg,
+		labels:           map[string]string{},
+		layout:           layout,
+	}
+}
+
+func (enc *mappedEncoder) Clone() zapcore.Encoder {
+	clone := newMappedEncoder(enc.cfg, enc.layout)
+	for k, v := range enc.Fields {
+		clone.Fields[k] = v
+	}
+	for k, v := range enc.labels {
+		clone.labels[k] = v
+	}
+	return clone
+}
+
+// AddString keeps labels added with With apart from the other fields.
+func (enc *mappedEncoder) AddString(key, value string) {
+	if label, ok := strings.CutPrefix(key, "labels."); ok {
+		enc.labels[label] = value
+		return
+	}
+	enc.MapObjectEncoder.AddString(key, value)
+}
+
+func (enc *mappedEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	e := &mappedEntry{
+		Entry:  ent,
+		Labels: map[string]string{},
+		Fields: make(map[string]interface{}, len(enc.Fields)+len(fields)),
+	}
+	for k, v := range enc.Fields {
+		e.Fields[k] = v
+	}
+	for k, v := range enc.labels {
+		e.Labels[k] = v
+	}
+	m := zapcore.NewMapObjectEncoder()
+	for _, f := range fields {
+		if ctx, ok := f.Interface.(context.Context); ok && f.Type == zapcore.SkipType {
+			e.Span = trace.SpanContextFromContext(ctx)
+			continue
+		}
+		f.AddTo(m)
+	}
+	for k, v := range m.Fields {
+		e.Fields[k] = v
+	}
+	for k, v := range entryLabels(fields) {
+		e.Labels[k] = v
+	}
+	for k := range e.Fields {
+		if strings.HasPrefix(k, "labels.") || k == zapdriverLabelsKey {
+			delete(e.Fields, k)
+		}
+	}
+	if v, ok := e.pop("httpRequest"); ok {
+		e.HTTP, _ = v.(map[string]interface{})
+	}
+	if v, ok := e.pop("logging.googleapis.com/sourceLocation"); ok {
+		e.Source, _ = v.(map[string]interface{})
+	}
+	if e.Stack == "" || enc.cfg.StacktraceKey == "" {
+		e.Stack = ""
+	}
+
+	buf := mappedPool.Get()
+	jsonEnc := json.NewEncoder(buf)
+	jsonEnc.SetEscapeHTML(false)
+	if err := jsonEnc.Encode(enc.layout(e)); err != nil {
+		buf.Free()
+		return nil, err
+	}
+	return buf, nil
+}
+
+// mappedLatency returns the latency of the zapdriver request payload, logged
+// as a duration string.
+func mappedLatency(http map[string]interface{}) (time.Duration, bool) {
+	s, _ := http["latency"].(string)
+	d, err := time.ParseDuration(s)
+	return d, err == nil
+}
+
+// setIf sets the key of m to v unless v is the zero value of its type.
+func setIf(m map[string]interface{}, key string, v interface{}) {
+	switch v := v.(type) {
+	case nil:
+		return
+	case string:
+		if v == "" {
+			return
+		}
+	case int:
+		if v == 0 {
+			return
+		}
+	}
+	m[key] = v
+}
@@ -0,0 +1,177 @@
+package logging
+
+import (
+	"crypto/rand"
+	"fmt"
+	"net"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	defaultGELFChunkSize = 1420
+	// gelfChunkHeader is the size of the header of a GELF chunk.
+	gelfChunkHeader = 12
+	gelfMaxChunks   = 128
+)
+
+// GELFOutput sends the entries to Graylog in the GELF format.
+type GELFOutput struct {
+	// Network is "udp" or "tcp", it defaults to "udp".
+	Network string `json:"network" yaml:"network"`
+	Address string `json:"address" yaml:"address"`
+	// ChunkSize is the maximum size of the UDP datagrams, larger messages
+	// are chunked. It defaults to 1420 bytes.
+	ChunkSize int `json:"chunk_size" yaml:"chunk_size"`
+}
+
+// gelfCore returns a core sending the entries to Graylog.
+func (l *Logger) gelfCore(out *GELFOutput, cfg zapcore.EncoderConfig) (zapcore.Core, *connWriter, error) {
+	network := out.Network
+	if network == "" {
+		network = "udp"
+	}
+	chunkSize := out.ChunkSize
+	if chunkSize <= gelfChunkHeader {
+		chunkSize = defaultGELFChunkSize
+	}
+	w, err := newConnWriter(func() (net.Conn, error) {
+		return net.Dial(network, out.Address)
+	}, func(conn net.Conn, msg []byte) error {
+		return sendGELF(conn, msg, chunkSize)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	host, _ := os.Hostname()
+	core := &sinkCore{
+		LevelEnabler: l.coreLevel,
+		enc:          newMappedEncoder(cfg, gelfLayout(host)),
+		write: func(_ zapcore.Entry, _ map[string]string, line []byte) error {
+			return w.write(line)
+		},
+	}
+	return core, w, nil
+}
+
+// gelfInvalidChars matches the characters not allowed in the names of GELF
+// additional fields.
+var gelfInvalidChars = regexp.MustCompile(`[^\w.\-]`)
+
+// gelfLayout returns the layout of GELF 1.1 messages from host, the labels
+// and fields being additional fields.
+func gelfLayout(host string) func(e *mappedEntry) map[string]interface{} {
+	return func(e *mappedEntry) map[string]interface{} {
+		out := map[string]interface{}{
+			"version":       "1.1",
+			"host":          host,
+			"short_message": e.Message,
+			"timestamp":     float64(e.Time.UnixNano()) / float64(time.Second),
+			"level":         syslogSeverities[e.Level],
+		}
+		stack, _ := e.pop(keyStackTrace)
+		if s, _ := stack.(string); s != "" {
+			out["full_message"] = s
+		} else if e.Stack != "" {
+			out["full_message"] = e.Stack
+		}
+		for k, v := range e.Labels {
+			gelfField(out, k, v)
+		}
+		if e.Span.HasTraceID() {
+			gelfField(out, "trace_id", e.Span.TraceID().String())
+		}
+		if e.Span.HasSpanID() {
+			gelfField(out, "span_id", e.Span.SpanID().String())
+		}
+		for k, v := range e.Source {
+			gelfField(out, k, v)
+		}
+		for k, v := range e.HTTP {
+			gelfField(out, "http_"+k, v)
+		}
+		for k, v := range e.Fields {
+			if strings.HasPrefix(k, zapdriverPrefix) {
+				continue
+			}
+			gelfField(out, k, v)
+		}
+		return out
+	}
+}
+
+// gelfField adds an additional field, flattening objects and arrays.
+func gelfField(out map[string]interface{}, key string, value interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			gelfField(out, key+"_"+k, v[k])
+		}
+		return
+	case []interface{}:
+		for i, e := range v {
+			gelfField(out, fmt.Sprintf("%s_%d", key, i), e)
+		}
+		return
+	case string, bool, float32, float64, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+	case time.Duration:
+		value = v.String()
+	case time.Time:
+		value = v.Format(time.RFC3339Nano)
+	default:
+		value = fmt.Sprintf("%+v", v)
+	}
+	key = "_" + gelfInvalidChars.ReplaceAllString(key, "_")
+	if key == "_id" {
+		// _id is reserved by Graylog.
+		key = "__id"
+	}
+	out[key] = value
+}
+
+// sendGELF sends a message over TCP terminated by a null byte, or over UDP in
+// chunks of at most chunkSize bytes.
+func sendGELF(conn net.Conn, msg []byte, chunkSize int) error {
+	if _, ok := conn.(*net.UDPConn); !ok {
+		_, err := conn.Write(append(msg, 0))
+		return err
+	}
+	if len(msg) <= chunkSize {
+		_, err := conn.Write(msg)
+		return err
+	}
+	dataSize := chunkSize - gelfChunkHeader
+	count := (len(msg) + dataSize - 1) / dataSize
+	if count > gelfMaxChunks {
+		return fmt.Errorf("logging: GELF message of %d bytes is too large", len(msg))
+	}
+	var id [8]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return err
+	}
+	chunk := make([]byte, 0, chunkSize)
+	for i := 0; i < count; i++ {
+		end := (i + 1) * dataSize
+		if end > len(msg) {
+			end = len(msg)
+		}
+		chunk = append(chunk[:0], 0x1e, 0x0f)
+		chunk = append(chunk, id[:]...)
+		chunk = append(chunk, byte(i), byte(count))
+		chunk = append(chunk, msg[i*dataSize:end]...)
+		if _, err := conn.Write(chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/net/context"
+)
+
+func TestGELF(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+
+	l, err := New(&Config{ProjectID: "test", Level: LevelDebug, GELF: &GELFOutput{Address: pc.LocalAddr().String()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	traceID := trace.TraceID{1}
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID}))
+	l.Warn(WithUserID(ctx, "u1"), "disk full")
+
+	buf := make([]byte, 8192)
+	pc.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := pc.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := map[string]interface{}{}
+	if err := json.Unmarshal(buf[:n], &msg); err != nil {
+		t.Fatalf("decoding %q: %v", buf[:n], err)
+	}
+	for key, want := range map[string]interface{}{
+		"version":       "1.1",
+		"short_message": "disk full",
+		"level":         float64(4),
+		"_request_id":   traceID.String(),
+		"_user_id":      "u1",
+	} {
+		if msg[key] != want {
+			t.Errorf("got %s %#v, want %#v", key, msg[key], want)
+		}
+	}
+	for key := range msg {
+		if key != "version" && key != "host" && key != "short_message" && key != "full_message" &&
+			key != "timestamp" && key != "level" && !strings.HasPrefix(key, "_") {
+			t.Errorf("got field %s, want additional fields prefixed by _", key)
+		}
+	}
+}
+
+func TestGELFChunks(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+	conn, err := net.Dial("udp", pc.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	msg := bytes.Repeat([]byte("0123456789"), 50)
+	if err := sendGELF(conn, msg, 112); err != nil {
+		t.Fatal(err)
+	}
+	var got []byte
+	buf := make([]byte, 1024)
+	for i := 0; i < 5; i++ {
+		pc.SetReadDeadline(time.Now().Add(5 * time.Second))
+		n, _, err := pc.ReadFrom(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n > 112 {
+			t.Errorf("got a chunk of %d bytes, want at most 112", n)
+		}
+		if buf[0] != 0x1e || buf[1] != 0x0f || buf[10] != byte(i) || buf[11] != 5 {
+			t.Fatalf("got chunk header %x, want chunk %d of 5", buf[:12], i)
+		}
+		got = append(got, buf[12:n]...)
+	}
+	if !bytes.Equal(got, msg) {
+		t.Errorf("got reassembled message %q, want %q", got, msg)
+	}
+}
@@ -0,0 +1,76 @@
+// Package gormlog logs the statements run by GORM with the logging package.
+package gormlog
+
+import (
+	"errors"
+	"time"
+
+	"github.com/cyoyu/logging"
+	"golang.org/x/net/context"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// New returns a GORM logger logging the statements with l, or with the
+// package level logger if l is nil, as logging.SQLDriver does. Records not
+// found are not errors.
+func New(l *logging.Logger) gormlogger.Interface {
+	return &gormLogger{l: l, level: gormlogger.Info}
+}
+
+// gormLogger is a GORM logger, the GORM log level further filtering the
+// entries, e.g. Warn only logging slow and failed statements.
+type gormLogger struct {
+	l     *logging.Logger
+	level gormlogger.LogLevel
+}
+
+// logger returns the logger of g, locating the entries at the callers of g.
+func (g *gormLogger) logger() *logging.Logger {
+	l := g.l
+	if l == nil {
+		l = logging.Default()
+	}
+	return l.WithCallerSkip(1)
+}
+
+func (g *gormLogger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
+	clone := *g
+	clone.level = level
+	return &clone
+}
+
+func (g *gormLogger) Info(ctx context.Context, msg string, data ...interface{}) {
+	if g.level >= gormlogger.Info {
+		g.logger().Info(ctx, msg, data...)
+	}
+}
+
+func (g *gormLogger) Warn(ctx context.Context, msg string, data ...interface{}) {
+	if g.level >= gormlogger.Warn {
+		g.logger().Warn(ctx, msg, data...)
+	}
+}
+
+func (g *gormLogger) Error(ctx context.Context, msg string, data ...interface{}) {
+	if g.level >= gormlogger.Error {
+		g.logger().Error(ctx, msg, data...)
+	}
+}
+
+func (g *gormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	l := g.logger()
+	latency := time.Since(begin)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = nil
+	}
+	switch {
+	case err != nil && g.level >= gormlogger.Error:
+	case l.SlowQuery(latency) && g.level >= gormlogger.Warn:
+	case g.level >= gormlogger.Info:
+	default:
+		return
+	}
+	query, rows := fc()
+	l.LogQuery(ctx, "sql query", "sql", query, rows, err, latency)
+}
@@ -0,0 +1,82 @@
+package gormlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cyoyu/logging"
+	"golang.org/x/net/context"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// entries decodes the entries written to buf.
+func entries(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+	var got []map[string]interface{}
+	dec := json.NewDecoder(buf)
+	for dec.More() {
+		var e map[string]interface{}
+		if err := dec.Decode(&e); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, e)
+	}
+	return got
+}
+
+func TestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	l, err := logging.New(&logging.Config{ProjectID: "test", Level: logging.LevelDebug, SlowQuery: time.Second, Output: &buf})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	statement := func(sql string, rows int64) func() (string, int64) {
+		return func() (string, int64) {
+			return sql, rows
+		}
+	}
+
+	g := New(l)
+	g.Trace(ctx, time.Now(), statement("SELECT * FROM users", 2), nil)
+	g.Trace(ctx, time.Now(), statement("SELECT * FROM users WHERE id = 1", 0), gorm.ErrRecordNotFound)
+	g.Trace(ctx, time.Now(), statement("INSERT INTO users", 0), errors.New("duplicate key"))
+	g.Trace(ctx, time.Now().Add(-2*time.Second), statement("SELECT * FROM orders", 9), nil)
+	g.Warn(ctx, "deprecated %s", "option")
+
+	quiet := g.LogMode(gormlogger.Warn)
+	quiet.Trace(ctx, time.Now(), statement("SELECT 1", 1), nil)
+	quiet.Trace(ctx, time.Now().Add(-2*time.Second), statement("SELECT 2", 1), nil)
+	quiet.Info(ctx, "ignored")
+	g.LogMode(gormlogger.Silent).Trace(ctx, time.Now(), statement("SELECT 3", 0), errors.New("ignored"))
+
+	got := entries(t, &buf)
+	want := []struct {
+		severity, message, sql string
+		rows                   interface{}
+	}{
+		{"DEBUG", "sql query", "SELECT * FROM users", 2.0},
+		{"DEBUG", "sql query", "SELECT * FROM users WHERE id = 1", 0.0},
+		{"ERROR", "sql query", "INSERT INTO users", 0.0},
+		{"WARNING", "sql query", "SELECT * FROM orders", 9.0},
+		{"WARNING", "deprecated option", "", nil},
+		{"WARNING", "sql query", "SELECT 2", 1.0},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d entries, want %d", len(got), len(want))
+	}
+	for i, w := range want {
+		e := got[i]
+		sql, _ := e["sql"].(string)
+		if e["severity"] != w.severity || e["message"] != w.message || sql != w.sql || e["rows"] != w.rows {
+			t.Errorf("entry %d: got %v, want %+v", i, e, w)
+		}
+	}
+	if got[2]["err"] != "duplicate key" {
+		t.Errorf("got %v, want the error", got[2])
+	}
+}
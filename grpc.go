@@ -0,0 +1,275 @@
+package logging
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// grpcStatuses maps gRPC codes to the HTTP statuses of the access logs.
+var grpcStatuses = map[codes.Code]int{
+	codes.OK:                 http.StatusOK,
+	codes.Canceled:           499,
+	codes.Unknown:            http.StatusInternalServerError,
+	codes.InvalidArgument:    http.StatusBadRequest,
+	codes.DeadlineExceeded:   http.StatusGatewayTimeout,
+	codes.NotFound:           http.StatusNotFound,
+	codes.AlreadyExists:      http.StatusConflict,
+	codes.PermissionDenied:   http.StatusForbidden,
+	codes.ResourceExhausted:  http.StatusTooManyRequests,
+	codes.FailedPrecondition: http.StatusBadRequest,
+	codes.Aborted:            http.StatusConflict,
+	codes.OutOfRange:         http.StatusBadRequest,
+	codes.Unimplemented:      http.StatusNotImplemented,
+	codes.Internal:           http.StatusInternalServerError,
+	codes.Unavailable:        http.StatusServiceUnavailable,
+	codes.DataLoss:           http.StatusInternalServerError,
+	codes.Unauthenticated:    http.StatusUnauthorized,
+}
+
+// grpcStatus returns the HTTP status of a gRPC code.
+func grpcStatus(code codes.Code) int {
+	if s, ok := grpcStatuses[code]; ok {
+		return s
+	}
+	return http.StatusInternalServerError
+}
+
+// UnaryServerInterceptor provides a gRPC interceptor to log unary calls as
+// RequestLogger logs HTTP requests, the route being the full method and the
+// status the HTTP equivalent of the gRPC code.
+func UnaryServerInterceptor(opts ...Option) grpc.UnaryServerInterceptor {
+	return unaryServerInterceptor(nil, opts)
+}
+
+// UnaryServerInterceptor provides a gRPC interceptor to log unary calls with
+// l.
+func (l *Logger) UnaryServerInterceptor(opts ...Option) grpc.UnaryServerInterceptor {
+	return unaryServerInterceptor(l, opts)
+}
+
+func unaryServerInterceptor(logger *Logger, opts []Option) grpc.UnaryServerInterceptor {
+	o := newMiddlewareOptions(opts)
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		l := orStd(logger)
+		if o.excludes.match(http.MethodPost, info.FullMethod) {
+			return handler(ctx, req)
+		}
+		ctx = l.withGRPCContext(ctx, info.FullMethod)
+		start := o.now(l)
+		res, err := handler(ctx, req)
+		l.logGRPC(ctx, o, info.FullMethod, err, o.since(l, start))
+		return res, err
+	}
+}
+
+// StreamServerInterceptor provides a gRPC interceptor to log streaming calls
+// as UnaryServerInterceptor logs unary calls, once the stream ends.
+func StreamServerInterceptor(opts ...Option) grpc.StreamServerInterceptor {
+	return streamServerInterceptor(nil, opts)
+}
+
+// StreamServerInterceptor provides a gRPC interceptor to log streaming calls
+// with l.
+func (l *Logger) StreamServerInterceptor(opts ...Option) grpc.StreamServerInterceptor {
+	return streamServerInterceptor(l, opts)
+}
+
+func streamServerInterceptor(logger *Logger, opts []Option) grpc.StreamServerInterceptor {
+	o := newMiddlewareOptions(opts)
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		l := orStd(logger)
+		if o.excludes.match(http.MethodPost, info.FullMethod) {
+			return handler(srv, ss)
+		}
+		ctx := l.withGRPCContext(ss.Context(), info.FullMethod)
+		start := o.now(l)
+		err := handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+		l.logGRPC(ctx, o, info.FullMethod, err, o.since(l, start))
+		return err
+	}
+}
+
+// serverStream overrides the context of a stream.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+// withGRPCContext returns a copy of the context of a call set up as the
+// middlewares set up the contexts of requests, from the incoming metadata.
+// The request ID is sent back in the x-request-id header.
+func (l *Logger) withGRPCContext(ctx context.Context, fullMethod string) context.Context {
+	md, _ := metadata.FromIncomingContext(ctx)
+	header := func(key string) string {
+		if values := md.Get(key); len(values) > 0 {
+			return values[0]
+		}
+		return ""
+	}
+	ctx = withHeaderBaggage(withHeaderTrace(ctx, header), header)
+	ctx, requestID := withRequestID(ctx, header(requestIDHeader))
+	_ = grpc.SetHeader(ctx, metadata.Pairs(requestIDHeader, requestID))
+	ctx = withRoute(ctx, fullMethod, fullMethod)
+	ctx = l.withForceDebug(ctx, header(debugHeader))
+	return withLogger(l.withDebugBuffer(ctx), l)
+}
+
+// logGRPC writes the access log of a call.
+func (l *Logger) logGRPC(ctx context.Context, o *middlewareOptions, fullMethod string, err error, latency time.Duration) {
+	code := status.Code(err)
+	httpStatus := grpcStatus(code)
+	// Failed calls are always logged.
+	if httpStatus < http.StatusInternalServerError && !o.sample(ctx, l, fullMethod, fullMethod, httpStatus) {
+		return
+	}
+	level, extra := l.slowRequest(l.statusLevel(httpStatus), nil, fullMethod, fullMethod, latency)
+	// The request is only built for the calls logged, or flushing the debug
+	// entries.
+	if httpStatus < http.StatusInternalServerError && level.zapLevel() < zapcore.ErrorLevel && !l.enabled(ctx, level) {
+		return
+	}
+	req := &http.Request{
+		Method:     http.MethodPost,
+		URL:        &url.URL{Path: fullMethod},
+		Proto:      "HTTP/2",
+		ProtoMajor: 2,
+		Header:     http.Header{},
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	if ua := md.Get("user-agent"); len(ua) > 0 {
+		req.Header.Set("User-Agent", ua[0])
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		req.RemoteAddr = p.Addr.String()
+	}
+	if l.clientIP != nil {
+		// Proxies forward the client IP as metadata.
+		for _, name := range l.clientIP.headers {
+			if v := md.Get(name); len(v) > 0 {
+				req.Header.Set(name, strings.Join(v, ","))
+			}
+		}
+	}
+	extra = append(extra, zap.String("grpc_code", code.String()))
+	if err != nil {
+		extra = append(extra, zap.NamedError(l.keyError, err))
+	}
+	l.zhttp(ctx, level, req, &http.Response{StatusCode: httpStatus, ContentLength: -1}, fullMethod, latency, extra...)
+}
+
+// retryAttemptKey is the metadata key retry interceptors, such as the one of
+// go-grpc-middleware, number the retries of a call with.
+const retryAttemptKey = "x-retry-attempt"
+
+// UnaryClientInterceptor provides a gRPC interceptor to log outgoing unary
+// calls with the request ID of their context.
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return unaryClientInterceptor(nil)
+}
+
+// UnaryClientInterceptor provides a gRPC interceptor to log outgoing unary
+// calls with l.
+func (l *Logger) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return unaryClientInterceptor(l)
+}
+
+func unaryClientInterceptor(logger *Logger) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		l := orStd(logger)
+		start := l.clock.Now()
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		l.logGRPCCall(ctx, cc.Target(), method, err, l.since(start))
+		return err
+	}
+}
+
+// StreamClientInterceptor provides a gRPC interceptor to log outgoing
+// streaming calls once they end.
+func StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return streamClientInterceptor(nil)
+}
+
+// StreamClientInterceptor provides a gRPC interceptor to log outgoing
+// streaming calls with l.
+func (l *Logger) StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return streamClientInterceptor(l)
+}
+
+func streamClientInterceptor(logger *Logger) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		l := orStd(logger)
+		start := l.clock.Now()
+		cs, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			l.logGRPCCall(ctx, cc.Target(), method, err, l.since(start))
+			return nil, err
+		}
+		return &clientStream{ClientStream: cs, done: func(err error) {
+			l.logGRPCCall(ctx, cc.Target(), method, err, l.since(start))
+		}}, nil
+	}
+}
+
+// clientStream calls done once the stream ends, with nil if it ended
+// successfully.
+type clientStream struct {
+	grpc.ClientStream
+	once sync.Once
+	done func(err error)
+}
+
+func (s *clientStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err == io.EOF {
+		s.once.Do(func() { s.done(nil) })
+	} else if err != nil {
+		s.once.Do(func() { s.done(err) })
+	}
+	return err
+}
+
+// logGRPCCall logs an outgoing call.
+func (l *Logger) logGRPCCall(ctx context.Context, target, method string, err error, latency time.Duration) {
+	code := status.Code(err)
+	level := l.statusLevel(grpcStatus(code))
+	if level.zapLevel() >= zapcore.ErrorLevel {
+		l.flushDebug(ctx)
+	}
+	if !l.enabled(ctx, level) {
+		return
+	}
+	fields := []zapcore.Field{
+		zap.String("grpc_target", target),
+		zap.String("grpc_method", method),
+		zap.String("grpc_code", code.String()),
+		zap.Int64("duration_ms", latency.Milliseconds()),
+	}
+	md, _ := metadata.FromOutgoingContext(ctx)
+	if values := md.Get(retryAttemptKey); len(values) > 0 {
+		if attempt, convErr := strconv.Atoi(values[0]); convErr == nil {
+			fields = append(fields, zap.Int("attempt", attempt+1))
+		}
+	}
+	if err != nil {
+		fields = append(fields, zap.NamedError(l.keyError, err))
+	}
+	l.zentry(ctx, level, "grpc call", "", fields)
+}
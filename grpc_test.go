@@ -0,0 +1,190 @@
+package logging
+
+import (
+	"errors"
+	"io"
+	"net"
+	"testing"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	l, buf := newTestLogger(t, &Config{})
+	interceptor := l.UnaryServerInterceptor(WithExcludes("/grpc.health.v1.Health/*"))
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 4242}})
+	ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(
+		"user-agent", "grpc-go/1.64.0",
+		"traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+	))
+	for _, tt := range []struct {
+		method string
+		err    error
+	}{
+		{"/users.v1.Users/Get", nil},
+		{"/users.v1.Users/Get", status.Error(codes.NotFound, "no such user")},
+		{"/users.v1.Users/Delete", errors.New("database down")},
+		{"/grpc.health.v1.Health/Check", nil},
+	} {
+		_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: tt.method}, func(ctx context.Context, req interface{}) (interface{}, error) {
+			l.Info(ctx, "handling")
+			return nil, tt.err
+		})
+		if err != tt.err {
+			t.Errorf("got error %v, want %v", err, tt.err)
+		}
+	}
+
+	var got []map[string]interface{}
+	for _, e := range entries(t, buf) {
+		if e["message"] == "request log" {
+			got = append(got, e)
+		}
+	}
+	if len(got) != 3 {
+		t.Fatalf("got %d access logs, want 3", len(got))
+	}
+	for i, want := range []struct {
+		code     string
+		status   float64
+		severity string
+	}{
+		{"OK", 200, "INFO"},
+		{"NotFound", 404, "WARNING"},
+		{"Unknown", 500, "ERROR"},
+	} {
+		e := got[i]
+		req, _ := e["httpRequest"].(map[string]interface{})
+		if e["grpc_code"] != want.code || req["status"] != want.status || e["severity"] != want.severity {
+			t.Errorf("entry %d: got code %v, status %v, severity %v, want %s, %v, %s", i, e["grpc_code"], req["status"], e["severity"], want.code, want.status, want.severity)
+		}
+		if req["remoteIp"] != "10.0.0.1:4242" || req["userAgent"] != "grpc-go/1.64.0" || req["protocol"] != "HTTP/2" {
+			t.Errorf("entry %d: got request %v, want the peer, user agent and protocol", i, req)
+		}
+		if labels := labels(e); labels["request_id"] != "4bf92f3577b34da6a3ce929d0e0e4736" || labels["route"] == "" {
+			t.Errorf("entry %d: got labels %v, want the trace ID and the method", i, labels)
+		}
+	}
+	if e := got[2]["err"]; e != "database down" {
+		t.Errorf("got error %v, want the error of the handler", e)
+	}
+}
+
+type testServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *testServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func TestStreamServerInterceptor(t *testing.T) {
+	l, buf := newTestLogger(t, &Config{})
+	interceptor := l.StreamServerInterceptor()
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-request-id", "req-1"))
+	err := interceptor(nil, &testServerStream{ctx: ctx}, &grpc.StreamServerInfo{FullMethod: "/chat.v1.Chat/Join"}, func(srv interface{}, ss grpc.ServerStream) error {
+		l.Info(ss.Context(), "joined")
+		return status.Error(codes.Unavailable, "shutting down")
+	})
+	if status.Code(err) != codes.Unavailable {
+		t.Errorf("got error %v, want the error of the handler", err)
+	}
+
+	got := entries(t, buf)
+	if len(got) != 2 {
+		t.Fatalf("got %d entries, want 2", len(got))
+	}
+	for _, e := range got {
+		if id := labels(e)["request_id"]; id != "req-1" {
+			t.Errorf("got request ID %v, want the ID of the metadata", id)
+		}
+	}
+	if got[1]["grpc_code"] != "Unavailable" || got[1]["severity"] != "ERROR" {
+		t.Errorf("got code %v and severity %v, want Unavailable and ERROR", got[1]["grpc_code"], got[1]["severity"])
+	}
+}
+
+func TestUnaryClientInterceptor(t *testing.T) {
+	l, buf := newTestLogger(t, &Config{})
+	cc, err := grpc.NewClient("passthrough:///users:443", grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cc.Close()
+	interceptor := l.UnaryClientInterceptor()
+	ctx := WithRequestID(context.Background(), "req-1")
+	for _, callErr := range []error{nil, status.Error(codes.Unavailable, "connection refused")} {
+		callErr := callErr
+		retryCtx := metadata.AppendToOutgoingContext(ctx, "x-retry-attempt", "2")
+		err := interceptor(retryCtx, "/users.v1.Users/Get", nil, nil, cc, func(context.Context, string, interface{}, interface{}, *grpc.ClientConn, ...grpc.CallOption) error {
+			return callErr
+		})
+		if err != callErr {
+			t.Errorf("got error %v, want %v", err, callErr)
+		}
+	}
+
+	got := entries(t, buf)
+	if len(got) != 2 {
+		t.Fatalf("got %d entries, want 2", len(got))
+	}
+	for i, want := range []struct{ code, severity string }{{"OK", "INFO"}, {"Unavailable", "ERROR"}} {
+		e := got[i]
+		if e["message"] != "grpc call" || e["grpc_code"] != want.code || e["severity"] != want.severity {
+			t.Errorf("entry %d: got %v %v %v, want grpc call %s %s", i, e["message"], e["grpc_code"], e["severity"], want.code, want.severity)
+		}
+		if e["grpc_target"] != "passthrough:///users:443" || e["grpc_method"] != "/users.v1.Users/Get" || e["attempt"] != float64(3) {
+			t.Errorf("entry %d: got target %v, method %v, attempt %v", i, e["grpc_target"], e["grpc_method"], e["attempt"])
+		}
+		if id := labels(e)["request_id"]; id != "req-1" {
+			t.Errorf("entry %d: got request ID %v, want the caller's", i, id)
+		}
+	}
+}
+
+type testClientStream struct {
+	grpc.ClientStream
+	msgs int
+}
+
+func (s *testClientStream) RecvMsg(interface{}) error {
+	if s.msgs == 0 {
+		return io.EOF
+	}
+	s.msgs--
+	return nil
+}
+
+func TestStreamClientInterceptor(t *testing.T) {
+	l, buf := newTestLogger(t, &Config{})
+	cc, err := grpc.NewClient("passthrough:///chat:443", grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cc.Close()
+	cs, err := l.StreamClientInterceptor()(context.Background(), &grpc.StreamDesc{ServerStreams: true}, cc, "/chat.v1.Chat/Join",
+		func(context.Context, *grpc.StreamDesc, *grpc.ClientConn, string, ...grpc.CallOption) (grpc.ClientStream, error) {
+			return &testClientStream{msgs: 2}, nil
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for cs.RecvMsg(nil) == nil {
+		if n := len(entries(t, buf)); n != 0 {
+			t.Fatalf("got %d entries before the stream ended", n)
+		}
+	}
+	_ = cs.RecvMsg(nil)
+
+	got := entries(t, buf)
+	if len(got) != 1 || got[0]["grpc_code"] != "OK" || got[0]["grpc_method"] != "/chat.v1.Chat/Join" {
+		t.Errorf("got %v, want one entry for the ended stream", got)
+	}
+}
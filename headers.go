@@ -0,0 +1,125 @@
+package logging
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// sensitiveHeaders are always redacted.
+var sensitiveHeaders = map[string]struct{}{
+	"Authorization":       {},
+	"Proxy-Authorization": {},
+	"Cookie":              {},
+	"Set-Cookie":          {},
+}
+
+// HeaderCapture logs request and response headers with the access logs of
+// the middlewares, as the request_headers and response_headers fields. The
+// Authorization, Proxy-Authorization, Cookie and Set-Cookie headers are
+// always redacted.
+type HeaderCapture struct {
+	// Request and Response list the headers logged, "*" logging all of
+	// them.
+	Request  []string `json:"request" yaml:"request"`
+	Response []string `json:"response" yaml:"response"`
+	// Exclude lists the headers never logged.
+	Exclude []string `json:"exclude" yaml:"exclude"`
+}
+
+// headerCapture is the header capture of a logger.
+type headerCapture struct {
+	request  headerList
+	response headerList
+	exclude  map[string]struct{}
+}
+
+// headerList is a list of canonical header names, or all of them.
+type headerList struct {
+	all   bool
+	names []string
+}
+
+func newHeaderList(names []string) headerList {
+	var list headerList
+	for _, name := range names {
+		if name == "*" {
+			list.all = true
+			continue
+		}
+		list.names = append(list.names, http.CanonicalHeaderKey(name))
+	}
+	return list
+}
+
+func newHeaderCapture(c *HeaderCapture) *headerCapture {
+	if c == nil {
+		return nil
+	}
+	h := &headerCapture{
+		request:  newHeaderList(c.Request),
+		response: newHeaderList(c.Response),
+		exclude:  map[string]struct{}{},
+	}
+	for _, name := range c.Exclude {
+		h.exclude[http.CanonicalHeaderKey(name)] = struct{}{}
+	}
+	return h
+}
+
+// fields returns the fields logging the request and response headers.
+func (h *headerCapture) fields(req, res http.Header) []zapcore.Field {
+	var fields []zapcore.Field
+	if m := h.headers(h.request, req); len(m) > 0 {
+		fields = append(fields, zap.Object("request_headers", m))
+	}
+	if m := h.headers(h.response, res); len(m) > 0 {
+		fields = append(fields, zap.Object("response_headers", m))
+	}
+	return fields
+}
+
+// headers returns the headers of the list, redacted.
+func (h *headerCapture) headers(list headerList, header http.Header) loggedHeaders {
+	names := list.names
+	if list.all {
+		names = make([]string, 0, len(header))
+		for name := range header {
+			names = append(names, http.CanonicalHeaderKey(name))
+		}
+	}
+	m := loggedHeaders{}
+	for _, name := range names {
+		if _, ok := h.exclude[name]; ok {
+			continue
+		}
+		values := header.Values(name)
+		if len(values) == 0 {
+			continue
+		}
+		if _, ok := sensitiveHeaders[name]; ok {
+			m[name] = redactedValue
+			continue
+		}
+		m[name] = strings.Join(values, ", ")
+	}
+	return m
+}
+
+// loggedHeaders marshals headers in the order of their names.
+type loggedHeaders map[string]string
+
+func (m loggedHeaders) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		enc.AddString(name, m[name])
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package logging
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestHeaderCapture(t *testing.T) {
+	l, buf := newTestLogger(t, &Config{
+		HeaderCapture: &HeaderCapture{
+			Request:  []string{"user-agent", "X-Tenant", "Authorization", "Cookie"},
+			Response: []string{"*"},
+			Exclude:  []string{"X-Internal"},
+		},
+	})
+	r := gin.New()
+	r.Use(l.RequestLogger())
+	r.GET("/", func(c *gin.Context) {
+		c.Header("Set-Cookie", "session=s")
+		c.Header("X-Internal", "i")
+		c.Header("X-Request-Id", "r")
+		c.String(200, "ok")
+	})
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("User-Agent", "test")
+	req.Header.Add("X-Tenant", "a")
+	req.Header.Add("X-Tenant", "b")
+	req.Header.Set("Authorization", "Bearer t")
+	req.Header.Set("Cookie", "session=s")
+	req.Header.Set("Accept", "*/*")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	got := entries(t, buf)
+	if len(got) != 1 {
+		t.Fatalf("got %d entries, want 1", len(got))
+	}
+	wantReq := map[string]interface{}{
+		"User-Agent":    "test",
+		"X-Tenant":      "a, b",
+		"Authorization": redactedValue,
+		"Cookie":        redactedValue,
+	}
+	if h := got[0]["request_headers"]; !reflect.DeepEqual(h, wantReq) {
+		t.Errorf("got request headers %v, want %v", h, wantReq)
+	}
+	wantRes := map[string]interface{}{
+		"Content-Type": "text/plain; charset=utf-8",
+		"Set-Cookie":   redactedValue,
+		"X-Request-Id": "r",
+	}
+	if h := got[0]["response_headers"]; !reflect.DeepEqual(h, wantRes) {
+		t.Errorf("got response headers %v, want %v", h, wantRes)
+	}
+}
+
+func TestFiberHeaderCapture(t *testing.T) {
+	l, buf := newTestLogger(t, &Config{
+		HeaderCapture: &HeaderCapture{Request: []string{"*"}, Exclude: []string{"Host"}},
+	})
+	app := fiber.New()
+	app.Use(l.FiberRequestLogger())
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString("ok")
+	})
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("X-Tenant", "a")
+	req.Header.Set("Authorization", "Bearer t")
+	if _, err := app.Test(req); err != nil {
+		t.Fatal(err)
+	}
+
+	got := entries(t, buf)
+	if len(got) != 1 {
+		t.Fatalf("got %d entries, want 1", len(got))
+	}
+	h, _ := got[0]["request_headers"].(map[string]interface{})
+	if h["X-Tenant"] != "a" || h["Authorization"] != redactedValue {
+		t.Errorf("got request headers %v, want the tenant and the authorization redacted", h)
+	}
+	if _, ok := h["Host"]; ok {
+		t.Errorf("got request headers %v, want the host excluded", h)
+	}
+	if _, ok := got[0]["response_headers"]; ok {
+		t.Errorf("got response headers %v, want none logged", got[0]["response_headers"])
+	}
+}
@@ -0,0 +1,139 @@
+package logging
+
+import (
+	"runtime"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+	"golang.org/x/net/context"
+)
+
+// Entry is a log entry as passed to hooks.
+type Entry struct {
+	Level   Level
+	Time    time.Time
+	Message string
+	// RequestID is the trace ID of the request, or its ID set by
+	// WithRequestID, empty outside of a request.
+	RequestID string
+	UserID    string
+	// Route is the route of the request logs.
+	Route string
+	// Err is the first error given with the entry, if any.
+	Err error
+	// Stack holds the program counters of the logging call stack, for
+	// entries of error severity and above.
+	Stack  []uintptr
+	Fields []Field
+}
+
+// ErrorHook is notified of the entries logged at error severity and above,
+// before they are written.
+type ErrorHook interface {
+	Fire(ctx context.Context, e *Entry)
+}
+
+// ErrorHookFunc adapts a function to ErrorHook.
+type ErrorHookFunc func(ctx context.Context, e *Entry)
+
+// Fire calls f(ctx, e).
+func (f ErrorHookFunc) Fire(ctx context.Context, e *Entry) {
+	f(ctx, e)
+}
+
+// hooks holds the hooks of a Logger.
+type hooks struct {
+	mu    sync.RWMutex
+	error []ErrorHook
+	entry []func(Entry)
+}
+
+func (h *hooks) addError(hook ErrorHook) {
+	h.mu.Lock()
+	h.error = append(h.error, hook)
+	h.mu.Unlock()
+}
+
+func (h *hooks) addEntry(hook func(Entry)) {
+	h.mu.Lock()
+	h.entry = append(h.entry, hook)
+	h.mu.Unlock()
+}
+
+func (h *hooks) get() ([]ErrorHook, []func(Entry)) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.error, h.entry
+}
+
+// AddErrorHook registers a hook notified of the entries logged by l at error
+// severity and above.
+func (l *Logger) AddErrorHook(hook ErrorHook) {
+	l.hooks.addError(hook)
+}
+
+// RegisterHook registers a function called with every entry logged by l,
+// before it is written.
+func (l *Logger) RegisterHook(hook func(Entry)) {
+	l.hooks.addEntry(hook)
+}
+
+// fireHooks notifies the hooks of the entry, and reports whether any was,
+// the hooks being free to retain its fields. skip is the number of stack
+// frames to skip to reach the logging call.
+func (l *Logger) fireHooks(ctx context.Context, level Level, msg, requestID, userID, route string, keysAndValues []interface{}, fields []zapcore.Field, skip int) bool {
+	errorHooks, entryHooks := l.hooks.get()
+	isError := level.zapLevel() >= zapcore.ErrorLevel
+	if len(entryHooks) == 0 && (len(errorHooks) == 0 || !isError) {
+		return false
+	}
+	if !validRequestID(requestID) {
+		requestID = ""
+	}
+	e := Entry{
+		Level:     level,
+		Time:      l.clock.Now(),
+		Message:   msg,
+		RequestID: requestID,
+		UserID:    userID,
+		Route:     route,
+		Err:       l.entryError(keysAndValues),
+		Fields:    fields,
+	}
+	if isError {
+		pcs := make([]uintptr, 32)
+		e.Stack = pcs[:runtime.Callers(skip+2, pcs)]
+	}
+	for _, hook := range entryHooks {
+		hook(e)
+	}
+	if isError {
+		for _, hook := range errorHooks {
+			hook.Fire(ctx, &e)
+		}
+	}
+	return true
+}
+
+// entryError returns the first error in the key/value pairs.
+func (l *Logger) entryError(keysAndValues []interface{}) error {
+	for i := 0; i < len(keysAndValues); i++ {
+		if field, ok := keysAndValues[i].(zapcore.Field); ok {
+			if err, ok := field.Interface.(error); ok && field.Type == zapcore.ErrorType {
+				return err
+			}
+			continue
+		}
+		if i == len(keysAndValues)-1 {
+			break
+		}
+		if key, ok := keysAndValues[i].(string); ok && (key == "error" || key == l.keyError) {
+			if err, ok := keysAndValues[i+1].(error); ok {
+				return err
+			}
+		}
+		i++
+	}
+	return nil
+}
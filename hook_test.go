@@ -0,0 +1,75 @@
+package logging
+
+import (
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/net/context"
+)
+
+func TestErrorHookRequestID(t *testing.T) {
+	l, _ := newTestLogger(t, &Config{})
+	var got []Entry
+	l.AddErrorHook(ErrorHookFunc(func(ctx context.Context, e *Entry) {
+		got = append(got, *e)
+	}))
+
+	traceID := trace.TraceID{1, 2, 3}
+	traced := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID}))
+	l.Info(traced, "not an error")
+	l.Error(context.Background(), "untraced")
+	l.Error(traced, "traced")
+
+	if len(got) != 2 {
+		t.Fatalf("got %d error entries, want 2", len(got))
+	}
+	if got[0].RequestID != "" {
+		t.Errorf("got request ID %q outside of a trace, want none", got[0].RequestID)
+	}
+	if got[1].RequestID != traceID.String() {
+		t.Errorf("got request ID %q, want %s", got[1].RequestID, traceID)
+	}
+	if len(got[1].Stack) == 0 {
+		t.Error("got no stack for an error entry")
+	}
+}
+
+func TestRegisterHook(t *testing.T) {
+	l, _ := newTestLogger(t, &Config{Level: LevelInfo})
+	var got []Entry
+	l.RegisterHook(func(e Entry) {
+		got = append(got, e)
+	})
+
+	ctx := WithUserID(WithRequestID(context.Background(), "4bf92f3577b34da6a3ce929d0e0e4736"), "u1")
+	l.Debug(ctx, "hidden")
+	l.Infow(ctx, "started", "port", 8080)
+	l.Warn(context.Background(), "slow")
+
+	if len(got) != 2 {
+		t.Fatalf("got %d hooked entries, want 2", len(got))
+	}
+	e := got[0]
+	if e.Level != LevelInfo || e.Message != "started" || e.RequestID != "4bf92f3577b34da6a3ce929d0e0e4736" || e.UserID != "u1" {
+		t.Errorf("got %+v, want the info entry of the request", e)
+	}
+	if e.Stack != nil {
+		t.Errorf("got a stack for an info entry, want none")
+	}
+	if got[1].Level != LevelWarn || got[1].RequestID != "" {
+		t.Errorf("got %+v, want the warning outside of a request", got[1])
+	}
+}
+
+func TestRegisterHookPackage(t *testing.T) {
+	l, _ := newTestLogger(t, &Config{})
+	useStd(t, l)
+	var messages []string
+	RegisterHook(func(e Entry) {
+		messages = append(messages, e.Message)
+	})
+	Info(context.Background(), "started")
+	if len(messages) != 1 || messages[0] != "started" {
+		t.Errorf("got %q hooked, want the entry of the package level logger", messages)
+	}
+}
@@ -0,0 +1,62 @@
+package logging
+
+import (
+	"crypto/rand"
+	"runtime/debug"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"golang.org/x/net/context"
+)
+
+// Job runs a background job or cron run with the package level logger. The
+// entries logged with the context passed to fn are labeled with the name of
+// the job and a run ID, generated in the format of trace IDs and also used as
+// request ID outside of a trace. The start of the run is logged at info
+// level, its end at info level, or error level if fn fails, with its
+// duration. A panic is logged at critical level before being propagated.
+func Job(ctx context.Context, name string, fn func(ctx context.Context) error) error {
+	return std().job(ctx, name, fn)
+}
+
+// Job runs a background job or cron run with l.
+func (l *Logger) Job(ctx context.Context, name string, fn func(ctx context.Context) error) error {
+	return l.job(ctx, name, fn)
+}
+
+func (l *Logger) job(ctx context.Context, name string, fn func(ctx context.Context) error) (err error) {
+	ctx = orBackground(ctx)
+	var id trace.TraceID
+	_, _ = rand.Read(id[:])
+	runID := id.String()
+	if _, ok := RequestIDFromContext(ctx); !ok {
+		ctx = WithRequestID(ctx, runID)
+	}
+	ctx = WithFields(ctx, "job", name, "run_id", runID)
+	ctx = withRoute(ctx, name, name)
+	ctx = withLogger(l.withDebugBuffer(ctx), l)
+
+	if l.enabled(ctx, LevelInfo) {
+		l.zentry(ctx, LevelInfo, "job started", name, nil)
+	}
+	start := l.clock.Now()
+	defer func() {
+		if v := recover(); v != nil {
+			l.logPanic(ctx, v, debug.Stack())
+			panic(v)
+		}
+		duration := l.since(start)
+		level, msg := LevelInfo, "job done"
+		fields := []zapcore.Field{zap.Int64("duration_ms", duration.Milliseconds())}
+		if err != nil {
+			level, msg = LevelError, "job failed"
+			fields = append(fields, zap.NamedError(l.keyError, err))
+			l.flushDebug(ctx)
+		}
+		if l.enabled(ctx, level) {
+			l.zentry(ctx, level, msg, name, fields)
+		}
+	}()
+	return fn(ctx)
+}
@@ -0,0 +1,73 @@
+package logging
+
+import (
+	"errors"
+	"testing"
+
+	"golang.org/x/net/context"
+)
+
+func TestJob(t *testing.T) {
+	l, buf := newTestLogger(t, &Config{Level: LevelInfo})
+	var runIDs []string
+	for _, jobErr := range []error{nil, errors.New("quota exceeded")} {
+		err := l.Job(context.Background(), "reindex", func(ctx context.Context) error {
+			runID, _ := RequestIDFromContext(ctx)
+			runIDs = append(runIDs, runID)
+			FromContext(ctx).Info("reindexing")
+			return jobErr
+		})
+		if err != jobErr {
+			t.Errorf("got error %v, want %v", err, jobErr)
+		}
+	}
+	if len(runIDs) != 2 || len(runIDs[0]) != 32 || runIDs[0] == runIDs[1] {
+		t.Fatalf("got run IDs %v, want two distinct IDs", runIDs)
+	}
+
+	got := entries(t, buf)
+	want := []struct {
+		severity, message string
+		run               int
+	}{
+		{"INFO", "job started", 0},
+		{"INFO", "reindexing", 0},
+		{"INFO", "job done", 0},
+		{"INFO", "job started", 1},
+		{"INFO", "reindexing", 1},
+		{"ERROR", "job failed", 1},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d entries, want %d", len(got), len(want))
+	}
+	for i, w := range want {
+		e := got[i]
+		labels := labels(e)
+		if e["severity"] != w.severity || e["message"] != w.message || labels["job"] != "reindex" ||
+			labels["run_id"] != runIDs[w.run] || labels["request_id"] != runIDs[w.run] {
+			t.Errorf("entry %d: got %v, want %+v", i, e, w)
+		}
+	}
+	if got[2]["duration_ms"] == nil || got[5]["err"] != "quota exceeded" {
+		t.Errorf("got %v and %v, want the duration and error of the runs", got[2], got[5])
+	}
+}
+
+func TestJobPanic(t *testing.T) {
+	l, buf := newTestLogger(t, &Config{Level: LevelInfo})
+	func() {
+		defer func() {
+			if v := recover(); v != "boom" {
+				t.Errorf("got panic %v, want the panic of the job", v)
+			}
+		}()
+		l.Job(context.Background(), "cleanup", func(ctx context.Context) error {
+			panic("boom")
+		})
+	}()
+
+	got := entries(t, buf)
+	if len(got) != 2 || got[1]["severity"] != "CRITICAL" || got[1]["message"] != "panic: boom" || labels(got[1])["job"] != "cleanup" {
+		t.Errorf("got %v, want the start of the job and its panic", got)
+	}
+}
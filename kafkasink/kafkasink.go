@@ -0,0 +1,80 @@
+// Package kafkasink publishes the entries of the logging package to a Kafka
+// topic.
+package kafkasink
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/cyoyu/logging"
+	"github.com/segmentio/kafka-go"
+	"go.uber.org/zap/zapcore"
+	"golang.org/x/net/context"
+)
+
+// Output is a logging.Sink publishing the entries to a Kafka topic in
+// batches, keyed by request ID so the entries of a request land on the same
+// partition.
+type Output struct {
+	Brokers []string `json:"brokers" yaml:"brokers"`
+	Topic   string   `json:"topic" yaml:"topic"`
+	// BatchSize and BatchInterval default to 100 entries and a second.
+	BatchSize     int           `json:"batch_size" yaml:"batch_size"`
+	BatchInterval time.Duration `json:"batch_interval" yaml:"batch_interval"`
+}
+
+// writer is the part of kafka.Writer used by the output.
+type writer interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+// newWriter returns the writer publishing to the topic, it is replaced in
+// tests.
+var newWriter = func(out *Output) writer {
+	return &kafka.Writer{
+		Addr:     kafka.TCP(out.Brokers...),
+		Topic:    out.Topic,
+		Balancer: &kafka.Hash{},
+		// The entries are already batched.
+		BatchSize:    1000,
+		BatchTimeout: 10 * time.Millisecond,
+		RequiredAcks: kafka.RequireOne,
+	}
+}
+
+// Open returns a core publishing the entries to the topic.
+func (out *Output) Open(sc *logging.SinkContext) (zapcore.Core, func(), error) {
+	w := newWriter(out)
+	core, closeCore := sc.BatchCore(out.BatchSize, out.BatchInterval, func(entries []logging.BatchEntry) error {
+		msgs := make([]kafka.Message, len(entries))
+		for i, e := range entries {
+			msgs[i] = kafka.Message{Value: e.Line, Time: e.Time}
+			if e.RequestID != "" {
+				msgs[i].Key = []byte(e.RequestID)
+			}
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		return w.WriteMessages(ctx, msgs...)
+	})
+	return core, func() {
+		closeCore()
+		w.Close()
+	}, nil
+}
+
+// Metadata returns the metadata of a Kafka message for logging.Consumer,
+// the message being identified by its partition and offset.
+func Metadata(msg kafka.Message) logging.MessageMetadata {
+	m := logging.MessageMetadata{
+		Source:      msg.Topic,
+		ID:          strconv.Itoa(msg.Partition) + "/" + strconv.FormatInt(msg.Offset, 10),
+		Attributes:  make(map[string]string, len(msg.Headers)),
+		PublishTime: msg.Time,
+	}
+	for _, h := range msg.Headers {
+		m.Attributes[h.Key] = string(h.Value)
+	}
+	return m
+}
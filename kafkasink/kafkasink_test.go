@@ -0,0 +1,111 @@
+package kafkasink
+
+import (
+	"bytes"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cyoyu/logging"
+	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/net/context"
+)
+
+type memoryWriter struct {
+	mu     sync.Mutex
+	msgs   []kafka.Message
+	closed bool
+}
+
+func (w *memoryWriter) WriteMessages(_ context.Context, msgs ...kafka.Message) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.msgs = append(w.msgs, msgs...)
+	return nil
+}
+
+func (w *memoryWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.closed = true
+	return nil
+}
+
+func TestOutput(t *testing.T) {
+	w := &memoryWriter{}
+	old := newWriter
+	newWriter = func(out *Output) writer {
+		if out.Topic != "logs" {
+			t.Errorf("got topic %q, want logs", out.Topic)
+		}
+		return w
+	}
+	defer func() { newWriter = old }()
+
+	l, err := logging.New(&logging.Config{
+		ProjectID: "test",
+		Level:     logging.LevelDebug,
+		Sinks:     []logging.Sink{&Output{Brokers: []string{"localhost:9092"}, Topic: "logs", BatchInterval: time.Hour}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	traceID := trace.TraceID{1}
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID}))
+	l.Info(ctx, "in request")
+	l.Info(context.Background(), "outside request")
+	l.Close()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.closed {
+		t.Error("got writer open after Close, want closed")
+	}
+	if len(w.msgs) != 2 {
+		t.Fatalf("got %d messages, want 2", len(w.msgs))
+	}
+	if got := string(w.msgs[0].Key); got != traceID.String() {
+		t.Errorf("got key %q, want the request ID %q", got, traceID.String())
+	}
+	if w.msgs[1].Key != nil {
+		t.Errorf("got key %q outside of a request, want none", w.msgs[1].Key)
+	}
+	entry := map[string]interface{}{}
+	if err := json.Unmarshal(w.msgs[0].Value, &entry); err != nil {
+		t.Fatal(err)
+	}
+	if entry["message"] != "in request" {
+		t.Errorf("got message %v, want in request", entry["message"])
+	}
+}
+
+func TestMetadata(t *testing.T) {
+	var buf bytes.Buffer
+	l, err := logging.New(&logging.Config{ProjectID: "test", Level: logging.LevelInfo, Output: &buf})
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := Metadata(kafka.Message{
+		Topic:     "orders",
+		Partition: 2,
+		Offset:    42,
+		Headers:   []kafka.Header{{Key: "X-Request-ID", Value: []byte("4bf92f3577b34da6a3ce929d0e0e4736")}},
+	})
+	if err := l.Consumer(context.Background(), msg, func(ctx context.Context) error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+
+	var e map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &e); err != nil {
+		t.Fatal(err)
+	}
+	if e["severity"] != "INFO" || e["message_id"] != "2/42" || e["outcome"] != "ok" || e["delivery_attempt"] != nil {
+		t.Errorf("got %v, want the handled message", e)
+	}
+	labels, _ := e["logging.googleapis.com/labels"].(map[string]interface{})
+	if labels["request_id"] != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("got labels %v, want the request ID of the message", labels)
+	}
+}
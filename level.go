@@ -0,0 +1,136 @@
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/yaml.v3"
+)
+
+// zapLevel returns the minimum zap level enabled by the level.
+func (lv Level) zapLevel() zapcore.Level {
+	switch {
+	case lv == levelDPanic:
+		return zapcore.DPanicLevel
+	case lv == levelPanic:
+		return zapcore.PanicLevel
+	case lv == levelFatal:
+		return zapcore.FatalLevel
+	case lv <= LevelFirst:
+		// Nothing is enabled.
+		return zapcore.FatalLevel + 1
+	case lv == LevelCritical:
+		return zapcore.DPanicLevel
+	case lv == LevelError:
+		return zapcore.ErrorLevel
+	case lv == LevelWarn:
+		return zapcore.WarnLevel
+	case lv == LevelInfo:
+		return zapcore.InfoLevel
+	default:
+		return zapcore.DebugLevel
+	}
+}
+
+// levelFromZap returns the level enabling the zap level.
+func levelFromZap(zl zapcore.Level) Level {
+	switch {
+	case zl > zapcore.FatalLevel:
+		return LevelFirst
+	case zl >= zapcore.DPanicLevel:
+		return LevelCritical
+	case zl == zapcore.ErrorLevel:
+		return LevelError
+	case zl == zapcore.WarnLevel:
+		return LevelWarn
+	case zl == zapcore.InfoLevel:
+		return LevelInfo
+	default:
+		return LevelDebug
+	}
+}
+
+var levelNames = map[Level]string{
+	LevelFirst:    "none",
+	LevelCritical: "critical",
+	LevelError:    "error",
+	LevelWarn:     "warn",
+	LevelInfo:     "info",
+	LevelDebug:    "debug",
+}
+
+// ParseLevel parses a level name such as "debug" or "warn".
+func ParseLevel(s string) (Level, error) {
+	name := strings.ToLower(strings.TrimSpace(s))
+	switch name {
+	case "warning":
+		return LevelWarn, nil
+	case "off":
+		return LevelFirst, nil
+	}
+	for level, levelName := range levelNames {
+		if name == levelName {
+			return level, nil
+		}
+	}
+	return LevelFirst, fmt.Errorf("logging: unknown level %q", s)
+}
+
+// String returns the name of the level.
+func (lv Level) String() string {
+	if name, ok := levelNames[lv]; ok {
+		return name
+	}
+	switch lv {
+	case levelDPanic:
+		return "dpanic"
+	case levelPanic:
+		return "panic"
+	case levelFatal:
+		return "fatal"
+	}
+	return fmt.Sprintf("Level(%d)", uint(lv))
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (lv Level) MarshalText() ([]byte, error) {
+	if _, ok := levelNames[lv]; !ok {
+		return nil, fmt.Errorf("logging: invalid level %d", uint(lv))
+	}
+	return []byte(lv.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler. Besides level names it
+// accepts the numeric values used by older configurations.
+func (lv *Level) UnmarshalText(text []byte) error {
+	if n, err := strconv.ParseUint(string(text), 10, 0); err == nil {
+		if Level(n) >= LevelLast {
+			return fmt.Errorf("logging: invalid level %d", n)
+		}
+		*lv = Level(n)
+		return nil
+	}
+	level, err := ParseLevel(string(text))
+	if err != nil {
+		return err
+	}
+	*lv = level
+	return nil
+}
+
+// UnmarshalJSON accepts both a level name and a number.
+func (lv *Level) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		return lv.UnmarshalText([]byte(s))
+	}
+	return lv.UnmarshalText(data)
+}
+
+// UnmarshalYAML accepts both a level name and a number.
+func (lv *Level) UnmarshalYAML(node *yaml.Node) error {
+	return lv.UnmarshalText([]byte(node.Value))
+}
@@ -0,0 +1,250 @@
+package logging
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"golang.org/x/net/context"
+	"gopkg.in/yaml.v3"
+)
+
+func TestParseLevel(t *testing.T) {
+	for _, tt := range []struct {
+		in      string
+		want    Level
+		wantErr bool
+	}{
+		{in: "debug", want: LevelDebug},
+		{in: "INFO", want: LevelInfo},
+		{in: " Warn ", want: LevelWarn},
+		{in: "warning", want: LevelWarn},
+		{in: "error", want: LevelError},
+		{in: "Critical", want: LevelCritical},
+		{in: "none", want: LevelFirst},
+		{in: "off", want: LevelFirst},
+		{in: "verbose", wantErr: true},
+		{in: "", wantErr: true},
+	} {
+		got, err := ParseLevel(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseLevel(%q) = %v, %v, want %v, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestLevelUnmarshalText(t *testing.T) {
+	for _, tt := range []struct {
+		in      string
+		want    Level
+		wantErr bool
+	}{
+		{in: "debug", want: LevelDebug},
+		{in: "4", want: LevelInfo},
+		{in: "0", want: LevelFirst},
+		{in: "6", wantErr: true},
+		{in: "-1", wantErr: true},
+		{in: "loud", wantErr: true},
+	} {
+		var got Level
+		err := got.UnmarshalText([]byte(tt.in))
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("UnmarshalText(%q) = %v, %v, want %v, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestLevelJSON(t *testing.T) {
+	for _, tt := range []struct {
+		in      string
+		want    Level
+		wantErr bool
+	}{
+		{in: `"warn"`, want: LevelWarn},
+		{in: `"WARNING"`, want: LevelWarn},
+		{in: `2`, want: LevelError},
+		{in: `"5"`, want: LevelDebug},
+		{in: `9`, wantErr: true},
+		{in: `"loud"`, wantErr: true},
+		{in: `true`, wantErr: true},
+	} {
+		var got Level
+		err := json.Unmarshal([]byte(tt.in), &got)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("json.Unmarshal(%s) = %v, %v, want %v, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestLevelYAML(t *testing.T) {
+	for _, tt := range []struct {
+		in      string
+		want    Level
+		wantErr bool
+	}{
+		{in: `info`, want: LevelInfo},
+		{in: `"Debug"`, want: LevelDebug},
+		{in: `1`, want: LevelCritical},
+		{in: `loud`, wantErr: true},
+	} {
+		var got Level
+		err := yaml.Unmarshal([]byte(tt.in), &got)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("yaml.Unmarshal(%s) = %v, %v, want %v, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestLevelRoundTrip(t *testing.T) {
+	for level := LevelFirst; level < LevelLast; level++ {
+		data, err := json.Marshal(level)
+		if err != nil {
+			t.Fatalf("json.Marshal(%v): %v", level, err)
+		}
+		var got Level
+		if err := json.Unmarshal(data, &got); err != nil || got != level {
+			t.Errorf("JSON round trip of %v through %s = %v, %v", level, data, got, err)
+		}
+
+		data, err = yaml.Marshal(level)
+		if err != nil {
+			t.Fatalf("yaml.Marshal(%v): %v", level, err)
+		}
+		got = LevelFirst
+		if err := yaml.Unmarshal(data, &got); err != nil || got != level {
+			t.Errorf("YAML round trip of %v through %q = %v, %v", level, data, got, err)
+		}
+	}
+}
+
+func TestLevelMarshalInvalid(t *testing.T) {
+	for _, level := range []Level{LevelLast, levelFatal} {
+		if _, err := level.MarshalText(); err == nil {
+			t.Errorf("MarshalText(%v) got no error", level)
+		}
+	}
+}
+
+func TestLevelSeverities(t *testing.T) {
+	l, buf := newTestLogger(t, &Config{})
+	// Fatal panics instead of exiting.
+	l.SetZapLogger(l.zapLogger().WithOptions(zap.WithFatalHook(fatalHook{})))
+	ctx := context.Background()
+	for _, tt := range []struct {
+		log  func(ctx context.Context, format string, args ...interface{})
+		want string
+	}{
+		{log: l.Debug, want: "DEBUG"},
+		{log: l.Info, want: "INFO"},
+		{log: l.Warn, want: "WARNING"},
+		{log: l.Error, want: "ERROR"},
+		{log: l.Critical, want: "CRITICAL"},
+		{log: l.DPanic, want: "CRITICAL"},
+		{log: l.Panic, want: "ALERT"},
+		{log: l.Fatal, want: "EMERGENCY"},
+	} {
+		buf.Reset()
+		recovered(func() { tt.log(ctx, "entry") })
+		got := entries(t, buf)
+		if len(got) != 1 || got[0]["severity"] != tt.want {
+			t.Errorf("got %v, want severity %s", got, tt.want)
+		}
+	}
+}
+
+func TestLevelZapLevel(t *testing.T) {
+	for _, tt := range []struct {
+		level Level
+		want  zapcore.Level
+	}{
+		{level: LevelDebug, want: zapcore.DebugLevel},
+		{level: LevelInfo, want: zapcore.InfoLevel},
+		{level: LevelWarn, want: zapcore.WarnLevel},
+		{level: LevelError, want: zapcore.ErrorLevel},
+		{level: LevelCritical, want: zapcore.DPanicLevel},
+		{level: levelDPanic, want: zapcore.DPanicLevel},
+		{level: levelPanic, want: zapcore.PanicLevel},
+		{level: levelFatal, want: zapcore.FatalLevel},
+	} {
+		if got := tt.level.zapLevel(); got != tt.want {
+			t.Errorf("%v.zapLevel() = %v, want %v", tt.level, got, tt.want)
+		}
+	}
+	for _, tt := range []struct {
+		level zapcore.Level
+		want  Level
+	}{
+		{level: zapcore.DebugLevel, want: LevelDebug},
+		{level: zapcore.WarnLevel, want: LevelWarn},
+		{level: zapcore.DPanicLevel, want: LevelCritical},
+		{level: zapcore.PanicLevel, want: LevelCritical},
+	} {
+		if got := levelFromZap(tt.level); got != tt.want {
+			t.Errorf("levelFromZap(%v) = %v, want %v", tt.level, got, tt.want)
+		}
+	}
+}
+
+func TestSetLevel(t *testing.T) {
+	l, buf := newTestLogger(t, &Config{Level: LevelInfo})
+	ctx := context.Background()
+	l.Debug(ctx, "hidden")
+	l.SetLevel(LevelDebug)
+	if got := l.GetLevel(); got != LevelDebug {
+		t.Errorf("got level %v, want debug", got)
+	}
+	l.Debug(ctx, "shown")
+	l.SetLevel(LevelError)
+	l.Warn(ctx, "hidden")
+
+	got := entries(t, buf)
+	if len(got) != 1 || got[0]["message"] != "shown" {
+		t.Errorf("got %v, want only the entry logged at debug level", got)
+	}
+}
+
+func TestSetLevelPackage(t *testing.T) {
+	l, buf := newTestLogger(t, &Config{Level: LevelInfo})
+	useStd(t, l)
+	SetLevel(LevelWarn)
+	if got := GetLevel(); got != LevelWarn {
+		t.Errorf("got level %v, want warning", got)
+	}
+	if got := l.GetLevel(); got != LevelWarn {
+		t.Errorf("got logger level %v, want the package level set", got)
+	}
+	Info(context.Background(), "hidden")
+	if got := entries(t, buf); len(got) != 0 {
+		t.Errorf("got %v, want no entry below the level", got)
+	}
+}
+
+func TestLevelHandler(t *testing.T) {
+	l, _ := newTestLogger(t, &Config{Level: LevelInfo})
+	h := l.LevelHandler()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/level", nil))
+	if body := strings.TrimSpace(rec.Body.String()); rec.Code != http.StatusOK || body != `{"level":"info"}` {
+		t.Errorf("GET: got %d %s, want the info level", rec.Code, body)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/level", strings.NewReader(`{"level":"debug"}`)))
+	if rec.Code != http.StatusOK {
+		t.Errorf("PUT: got %d %s, want 200", rec.Code, rec.Body)
+	}
+	if got := l.GetLevel(); got != LevelDebug {
+		t.Errorf("got level %v after PUT, want debug", got)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/level", strings.NewReader(`{"level":"verbose"}`)))
+	if rec.Code != http.StatusBadRequest || l.GetLevel() != LevelDebug {
+		t.Errorf("PUT verbose: got %d and level %v, want 400 and the level kept", rec.Code, l.GetLevel())
+	}
+}
@@ -0,0 +1,121 @@
+package logging
+
+import (
+	"crypto/subtle"
+	"path"
+	"sort"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+	"golang.org/x/net/context"
+)
+
+// routeKey is the context key of the route of a request.
+type routeKey struct{}
+
+// requestRoute is the route pattern and URL path of a request.
+type requestRoute struct {
+	route string
+	path  string
+}
+
+// withRoute returns a copy of ctx carrying the route pattern and URL path of
+// the request, for the route levels.
+func withRoute(ctx context.Context, route, urlPath string) context.Context {
+	return context.WithValue(ctx, routeKey{}, requestRoute{route: route, path: urlPath})
+}
+
+// debugHeader is the header of the requests logged at debug level, carrying
+// Config.DebugToken.
+const debugHeader = "X-Debug-Log"
+
+// forceDebugKey is the context key marking the requests logged at debug
+// level.
+type forceDebugKey struct{}
+
+// withForceDebug returns a copy of ctx logged at debug level if the request
+// carries the debug header token.
+func (l *Logger) withForceDebug(ctx context.Context, header string) context.Context {
+	if l.debugToken == "" || subtle.ConstantTimeCompare([]byte(header), []byte(l.debugToken)) != 1 {
+		return ctx
+	}
+	return context.WithValue(ctx, forceDebugKey{}, true)
+}
+
+// routeLevel is the level of the requests matching a pattern.
+type routeLevel struct {
+	pattern string
+	level   zapcore.Level
+}
+
+// setLevelOverrides sets the levels of the requests by route and scope.
+func (l *Logger) setLevelOverrides(routes, scopes map[string]Level) {
+	for pattern, level := range routes {
+		l.routeLevels = append(l.routeLevels, routeLevel{pattern: pattern, level: level.zapLevel()})
+	}
+	// Longer patterns are more specific.
+	sort.Slice(l.routeLevels, func(i, j int) bool {
+		return len(l.routeLevels[i].pattern) > len(l.routeLevels[j].pattern)
+	})
+	if len(scopes) > 0 {
+		l.scopeLevels = make(map[string]zapcore.Level, len(scopes))
+		for scope, level := range scopes {
+			l.scopeLevels[scope] = level.zapLevel()
+		}
+	}
+}
+
+// enabled reports whether entries of the level are logged with ctx: all of
+// them for the requests and users debugged, otherwise those at the level of
+// the scope of ctx, of its route, or of l.
+func (l *Logger) enabled(ctx context.Context, level Level) bool {
+	zl := level.zapLevel()
+	if forced, _ := ctx.Value(forceDebugKey{}).(bool); forced {
+		return true
+	}
+	if len(l.debugUsers) > 0 {
+		if userID, ok := l.userID(ctx); ok {
+			if _, ok := l.debugUsers[userID]; ok {
+				return true
+			}
+		}
+	}
+	if len(l.scopeLevels) > 0 {
+		if scope, ok := l.scope(ctx); ok {
+			if min, ok := l.scopeLevels[scope]; ok {
+				return zl >= min
+			}
+		}
+	}
+	if len(l.routeLevels) > 0 {
+		if r, ok := ctx.Value(routeKey{}).(requestRoute); ok {
+			for _, rl := range l.routeLevels {
+				if routeMatch(rl.pattern, r.route) || routeMatch(rl.pattern, r.path) {
+					return zl >= rl.level
+				}
+			}
+		}
+	}
+	return l.level.Enabled(zl)
+}
+
+// Enabled reports whether entries of the level are logged with ctx, for the
+// callers to skip building expensive arguments. Debug entries held for a
+// request in case it fails are enabled.
+func (l *Logger) Enabled(ctx context.Context, level Level) bool {
+	ctx = orBackground(ctx)
+	return l.enabled(ctx, level) || debugBufferFrom(ctx, level) != nil
+}
+
+// routeMatch reports whether the route or path matches the pattern, a
+// pattern ending with * matching all the paths it prefixes.
+func routeMatch(pattern, route string) bool {
+	if route == "" {
+		return false
+	}
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok && !strings.ContainsAny(prefix, "*?[") {
+		return strings.HasPrefix(route, prefix)
+	}
+	matched, _ := path.Match(pattern, route)
+	return matched
+}
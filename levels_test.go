@@ -0,0 +1,116 @@
+package logging
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/context"
+)
+
+func TestLevelOverrides(t *testing.T) {
+	l, buf := newTestLogger(t, &Config{
+		Level:       LevelInfo,
+		RouteLevels: map[string]Level{"/api/v2/experimental/*": LevelDebug, "/health": LevelWarn},
+		ScopeLevels: map[string]Level{"billing": LevelDebug},
+	})
+	r := gin.New()
+	r.Use(l.RequestLogger())
+	handler := func(c *gin.Context) {
+		l.Debug(c.Request.Context(), "debugging "+c.FullPath())
+		c.Status(200)
+	}
+	r.GET("/api/v2/experimental/:id", handler)
+	r.GET("/api/v2/stable", handler)
+	r.GET("/health", handler)
+	for _, path := range []string{"/api/v2/experimental/1", "/api/v2/stable", "/health"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+	l.Debug(WithScope(context.Background(), "billing"), "charging")
+	l.Debug(WithScope(context.Background(), "search"), "searching")
+
+	var got []string
+	for _, e := range entries(t, buf) {
+		got = append(got, e["message"].(string))
+	}
+	want := []string{
+		"debugging /api/v2/experimental/:id", "request log",
+		"request log",
+		"charging",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("got %q, want %q", got[i], want[i])
+		}
+	}
+}
+
+func TestRouteMatch(t *testing.T) {
+	for _, tt := range []struct {
+		pattern, route string
+		want           bool
+	}{
+		{"/api/*", "/api/users/1", true},
+		{"/api/*", "/apis", false},
+		{"/users/:id", "/users/:id", true},
+		{"/users/*/orders", "/users/1/orders", true},
+		{"/users/*/orders", "/users/1/2/orders", false},
+		{"/health", "", false},
+	} {
+		if got := routeMatch(tt.pattern, tt.route); got != tt.want {
+			t.Errorf("routeMatch(%q, %q) = %v, want %v", tt.pattern, tt.route, got, tt.want)
+		}
+	}
+}
+
+func TestForceDebug(t *testing.T) {
+	l, buf := newTestLogger(t, &Config{Level: LevelInfo, DebugToken: "secret", DebugUsers: []string{"u1"}})
+	r := gin.New()
+	r.Use(l.RequestLogger())
+	r.GET("/users/:id", func(c *gin.Context) {
+		l.Debug(c.Request.Context(), "debugging "+c.GetHeader("X-Debug-Log"))
+		c.Status(200)
+	})
+	for _, token := range []string{"secret", "guess", ""} {
+		req := httptest.NewRequest("GET", "/users/1", nil)
+		req.Header.Set("X-Debug-Log", token)
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	l.Debug(WithUserID(context.Background(), "u1"), "debugging u1")
+	l.Debug(WithUserID(context.Background(), "u2"), "debugging u2")
+
+	var got []string
+	for _, e := range entries(t, buf) {
+		if e["message"] != "request log" {
+			got = append(got, e["message"].(string))
+		}
+	}
+	if len(got) != 2 || got[0] != "debugging secret" || got[1] != "debugging u1" {
+		t.Errorf("got %q, want the debug entries of the request with the token and of u1", got)
+	}
+}
+
+func TestEnabled(t *testing.T) {
+	l, _ := newTestLogger(t, &Config{Level: LevelWarn, ScopeLevels: map[string]Level{"billing": LevelDebug}, DebugBufferSize: 10})
+	ctx := context.Background()
+	if l.Enabled(ctx, LevelInfo) || !l.Enabled(ctx, LevelWarn) {
+		t.Error("got the level of the logger ignored")
+	}
+	if !l.Enabled(WithScope(ctx, "billing"), LevelDebug) {
+		t.Error("got debug entries disabled for a scope logged at debug level")
+	}
+	if !l.Enabled(l.withDebugBuffer(ctx), LevelDebug) {
+		t.Error("got buffered debug entries disabled")
+	}
+	allocs := testing.AllocsPerRun(100, func() {
+		l.Infow(ctx, "below")
+		l.Info(ctx, "below")
+		l.Debug(ctx, "below")
+	})
+	if allocs != 0 {
+		t.Errorf("got %v allocations per entry below the level, want none", allocs)
+	}
+}
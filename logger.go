@@ -0,0 +1,735 @@
+package logging
+
+import (
+	"fmt"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/blendle/zapdriver"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"golang.org/x/net/context"
+)
+
+// Logger logs with its own configuration, independently of the package level
+// logger set up by Initialize.
+type Logger struct {
+	// zlogger is shared with the sub-loggers.
+	zlogger *atomic.Pointer[zap.Logger]
+	level   zap.AtomicLevel
+	// coreLevel enables the cores, it is level unless entries below level
+	// are logged for some requests, the level being checked when logging.
+	coreLevel zap.AtomicLevel
+
+	// name is the name of a sub-logger, levels the levels of the
+	// sub-loggers by name.
+	name   string
+	levels map[string]zap.AtomicLevel
+
+	projectID    string
+	keyRequestID string
+	keyUserID    string
+	keyError     string
+	keyScope     string
+	keyRemoteIP  string
+	keyRoute     string
+	// userIDValueKey and scopeValueKey are keyUserID and keyScope converted
+	// once to the context keys of the user ID and scope of the contexts set
+	// up before WithUserID and WithScope, not to allocate per lookup.
+	userIDValueKey interface{}
+	scopeValueKey  interface{}
+
+	clock          Clock
+	warnEscalation *warnEscalator
+	redactDefault  map[string]struct{}
+	redactPolicies map[string]map[string]struct{}
+	redactor       *redactor
+	truncator      *truncator
+	userIDHashKey  []byte
+	routeSampling  map[string]float64
+	statusSampling []statusSample
+	routeLevels    []routeLevel
+	scopeLevels    map[string]zapcore.Level
+	statusLevels   map[string]Level
+	slowThreshold  time.Duration
+	slowRoutes     []routeThreshold
+	slowQuery      time.Duration
+	bodyCapture    *bodyCapture
+	headerCapture  *headerCapture
+	clientIP       *clientIPResolver
+	debugToken     string
+	debugUsers     map[string]struct{}
+	contextKeys    []contextKey
+	baggageKeys    []contextKey
+	payloadFields  bool
+	errorReporting bool
+	// sampledTraces omits the trace of the entries of unsampled traces.
+	sampledTraces bool
+	// spanEventLevel is the minimum level of the entries added to their
+	// span as events.
+	spanEventLevel Level
+	// serviceName is the service of the Cloud Error Reporting events of
+	// Config.Format, the other entries carrying their service context.
+	serviceName string
+	hooks       *hooks
+	callSites   *callSites
+	// debugBufferSize is the number of debug entries held per request.
+	debugBufferSize int
+	closers         []func()
+	// closeOnce closes the outputs of l and its sub-loggers once.
+	closeOnce *sync.Once
+	// callerSkip is the number of frames skipped to locate the entries
+	// above the caller of the logging functions.
+	callerSkip int
+	// noSourceLocation leaves out the source location of the entries.
+	noSourceLocation bool
+	// stackReport adds to the entries of stackLevel and above their stack
+	// trace formatted for Cloud Error Reporting.
+	stackReport bool
+	stackLevel  zapcore.Level
+	// auditLogger writes the audit events.
+	auditLogger *zap.Logger
+}
+
+// newLogger returns a Logger with the default settings and no zap logger.
+func newLogger() *Logger {
+	level := zap.NewAtomicLevelAt(LevelDebug.zapLevel())
+	return &Logger{
+		zlogger:        &atomic.Pointer[zap.Logger]{},
+		level:          level,
+		coreLevel:      level,
+		keyRequestID:   "request_id",
+		keyUserID:      "user_id",
+		keyError:       "err",
+		keyScope:       "scope",
+		keyRemoteIP:    "remote_ip",
+		keyRoute:       "route",
+		clock:          systemClock{},
+		userIDValueKey: "user_id",
+		scopeValueKey:  "scope",
+		hooks:          &hooks{},
+		callSites:      &callSites{},
+		closeOnce:      &sync.Once{},
+	}
+}
+
+// New creates a Logger from the configuration. A nil configuration logs to
+// the development console at debug level.
+func New(c *Config) (*Logger, error) {
+
+	var zlogger *zap.Logger
+	var err error
+
+	l := newLogger()
+	if c != nil {
+		l.level.SetLevel(c.Level.zapLevel())
+		l.projectID = c.ProjectID
+		if c.KeyRequestID != "" {
+			l.keyRequestID = c.KeyRequestID
+		}
+		if c.KeyUserID != "" {
+			l.keyUserID = c.KeyUserID
+			l.userIDValueKey = c.KeyUserID
+		}
+		if c.KeyError != "" {
+			l.keyError = c.KeyError
+		}
+		if c.KeyScope != "" {
+			l.keyScope = c.KeyScope
+			l.scopeValueKey = c.KeyScope
+		}
+		if c.Clock != nil {
+			l.clock = c.Clock
+		}
+		l.warnEscalation = newWarnEscalator(c.WarnEscalation)
+		l.setRedaction(c.RedactKeys, c.RedactPolicies)
+		l.truncator = newTruncator(c.MaxMessageLength, c.MaxFieldLength, "labels."+l.keyRequestID, "labels."+l.keyUserID)
+		if c.UserIDHashKey != "" {
+			l.userIDHashKey = []byte(c.UserIDHashKey)
+		}
+		if l.redactor, err = newRedactor(c.Redaction, "labels."+l.keyRequestID); err != nil {
+			return nil, err
+		}
+		l.routeSampling = c.RouteSampling
+		l.setStatusSampling(c.StatusSampling)
+		l.setContextKeys(c.ContextKeys)
+		l.setBaggageKeys(c.BaggageKeys)
+		l.payloadFields = c.PayloadFields
+		l.errorReporting = c.ErrorReporting
+		l.sampledTraces = c.SampledTracesOnly
+		l.spanEventLevel = c.SpanEventLevel
+		if c.Format != "" {
+			l.serviceName = c.ServiceName
+		}
+		l.debugBufferSize = c.DebugBufferSize
+		l.noSourceLocation = c.DisableSourceLocation
+		l.setLevels(c.Levels)
+		l.setLevelOverrides(c.RouteLevels, c.ScopeLevels)
+		l.statusLevels = c.StatusLevels
+		l.setSlowRequests(c.SlowRequest, c.SlowRoutes)
+		l.slowQuery = c.SlowQuery
+		l.bodyCapture = newBodyCapture(c.BodyCapture)
+		l.headerCapture = newHeaderCapture(c.HeaderCapture)
+		if l.clientIP, err = newClientIPResolver(c.ClientIP); err != nil {
+			return nil, err
+		}
+		l.debugToken = c.DebugToken
+		if len(c.DebugUsers) > 0 {
+			l.debugUsers = make(map[string]struct{}, len(c.DebugUsers))
+			for _, userID := range c.DebugUsers {
+				l.debugUsers[userID] = struct{}{}
+			}
+		}
+		if c.perRequestLevels() {
+			l.coreLevel = zap.NewAtomicLevelAt(zapcore.DebugLevel)
+		}
+	}
+	static := staticFields(c)
+	if c != nil && c.Metadata {
+		static = append(static, labelFields(detectMetadata())...)
+	}
+	if c != nil && c.BuildInfo {
+		info, _ := debug.ReadBuildInfo()
+		static = append(static, labelFields(buildInfoLabels(info))...)
+	}
+	opts := []zap.Option{zap.WithClock(zapClock{l.clock})}
+	stackOpts, err := l.stacktraceOptions(c)
+	if err != nil {
+		return nil, err
+	}
+	if l.noSourceLocation {
+		// Nor is the caller of zap looked up.
+		opts = append(opts, zap.WithCaller(false))
+	}
+	if c != nil && c.Format != "" {
+		config := zap.NewProductionConfig()
+		if c.Development {
+			// The formats lay out the fields of the production encoder.
+			config = zap.NewDevelopmentConfig()
+			config.EncoderConfig = zap.NewProductionEncoderConfig()
+		}
+		config.Level = l.coreLevel
+		config.DisableCaller = true
+		if config.Encoding, err = formatEncoding(c.Format); err != nil {
+			return nil, err
+		}
+		limits := append(append(samplingOptions(&config, c), dedupOptions(c)...), stackOpts...)
+		if opts, err = l.withOutputs(opts, config, c); err == nil {
+			opts = append(opts, zap.Fields(static...))
+			zlogger, err = config.Build(append(opts, limits...)...)
+		}
+		if err == nil {
+			l.auditLogger, err = l.newAuditLogger(config, c, zap.Fields(static...))
+		}
+	} else if l.projectID == "" {
+		config := zap.NewDevelopmentConfig()
+		config.Level = l.coreLevel
+		if c != nil && c.PrettyConsole {
+			config.Encoding = prettyEncoding
+		}
+		limits := append(append(samplingOptions(&config, c), dedupOptions(c)...), stackOpts...)
+		if opts, err = l.withOutputs(opts, config, c); err == nil {
+			// Only the console gets colored levels.
+			config.EncoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
+			opts = append(opts, zap.AddStacktrace(zap.ErrorLevel), zap.Fields(static...))
+			zlogger, err = config.Build(append(opts, limits...)...)
+		}
+		if err == nil {
+			l.auditLogger, err = l.newAuditLogger(config, c, zap.Fields(static...))
+		}
+	} else {
+		config := zapdriver.NewProductionConfig()
+		if c.Development {
+			config = zapdriver.NewDevelopmentConfig()
+		}
+		config.Level = l.coreLevel
+		limits := append(append(samplingOptions(&config, c), dedupOptions(c)...), stackOpts...)
+		if opts, err = l.withOutputs(opts, config, c); err == nil {
+			// The static labels are added once zapdriver collects labels.
+			opts = append(opts, zapdriver.WrapCore(), zap.Fields(static...))
+			zlogger, err = config.Build(append(opts, limits...)...)
+		}
+		if err == nil {
+			l.auditLogger, err = l.newAuditLogger(config, c, zapdriver.WrapCore(), zap.Fields(static...))
+		}
+	}
+	if err != nil {
+		// The outputs opened before the error are closed.
+		for _, close := range l.closers {
+			close()
+		}
+		return nil, err
+	}
+	l.zlogger.Store(zlogger)
+	if c != nil {
+		l.startFlushing(c.FlushInterval, c.FlushOnSignal)
+	}
+	return l, nil
+}
+
+// SetLevel changes the minimum level of the entries logged by l.
+func (l *Logger) SetLevel(level Level) {
+	l.level.SetLevel(level.zapLevel())
+}
+
+// GetLevel returns the minimum level of the entries logged by l.
+func (l *Logger) GetLevel() Level {
+	return levelFromZap(l.level.Level())
+}
+
+// LevelHandler returns an HTTP handler reporting the level of l on GET and
+// changing it on PUT, as documented by zap.AtomicLevel.ServeHTTP.
+func (l *Logger) LevelHandler() http.Handler {
+	return l.level
+}
+
+// SetZapLogger atomically replaces the zap logger entries are written to by
+// l and its sub-loggers, e.g. after reloading the output configuration.
+func (l *Logger) SetZapLogger(z *zap.Logger) {
+	l.zlogger.Store(z)
+}
+
+// fallbackZapLogger returns the zap logger of the loggers not configured,
+// such as the package level logger before Initialize, writing the entries as
+// JSON to the standard error output.
+var fallbackZapLogger = sync.OnceValue(func() *zap.Logger {
+	config := zapdriver.NewProductionConfig()
+	config.Level = zap.NewAtomicLevelAt(zapcore.DebugLevel)
+	config.Sampling = nil
+	z, err := config.Build(zapdriver.WrapCore())
+	if err != nil {
+		return zap.NewNop()
+	}
+	return z
+})
+
+// zapLogger returns the zap logger entries are written to.
+func (l *Logger) zapLogger() *zap.Logger {
+	z := l.zlogger.Load()
+	if z == nil {
+		z = fallbackZapLogger()
+	}
+	if l.name != "" {
+		return z.Named(l.name)
+	}
+	return z
+}
+
+// Sync flushes any buffered log entries.
+func (l *Logger) Sync() error {
+	err := l.zapLogger().Sync()
+	if l.auditLogger != nil {
+		if auditErr := l.auditLogger.Sync(); err == nil {
+			err = auditErr
+		}
+	}
+	return err
+}
+
+// Close flushes any buffered log entries and closes the outputs. Closing
+// again only flushes.
+func (l *Logger) Close() error {
+	err := l.Sync()
+	l.closeOnce.Do(func() {
+		for _, close := range l.closers {
+			close()
+		}
+	})
+	return err
+}
+
+// HTTP is a helper function for logging API request/response
+func (l *Logger) HTTP(ctx context.Context, req *http.Request, res *http.Response, path string, latency time.Duration) {
+	l.zhttp(ctx, LevelInfo, req, res, path, latency)
+}
+
+// Critical logs a message of critical severity. Unlike Fatal it doesn't exit.
+func (l *Logger) Critical(ctx context.Context, format string, args ...interface{}) {
+	l.zlog(ctx, LevelCritical, format, args, nil)
+}
+
+// DPanic logs a message of critical severity, then panics in development.
+func (l *Logger) DPanic(ctx context.Context, format string, args ...interface{}) {
+	l.zlog(ctx, levelDPanic, format, args, nil)
+}
+
+// Panic logs a message of alert severity, then panics.
+func (l *Logger) Panic(ctx context.Context, format string, args ...interface{}) {
+	l.zlog(ctx, levelPanic, format, args, nil)
+}
+
+// Fatal logs a message of emergency severity, then exits the process.
+func (l *Logger) Fatal(ctx context.Context, format string, args ...interface{}) {
+	l.zlog(ctx, levelFatal, format, args, nil)
+}
+
+// Error logs a message of error severity.
+func (l *Logger) Error(ctx context.Context, format string, args ...interface{}) {
+	l.zlog(ctx, LevelError, format, args, nil)
+}
+
+// Errorw logs a message with additional context
+func (l *Logger) Errorw(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	l.zlog(ctx, LevelError, msg, nil, keysAndValues)
+}
+
+// Warn logs a message of warning severity.
+func (l *Logger) Warn(ctx context.Context, format string, args ...interface{}) {
+	l.zlog(ctx, LevelWarn, format, args, nil)
+}
+
+// Info logs a message of informational severity.
+func (l *Logger) Info(ctx context.Context, format string, args ...interface{}) {
+	l.zlog(ctx, LevelInfo, format, args, nil)
+}
+
+// Infow logs a message with additional context
+//
+// The context is given as alternating keys and values logged as labels, or
+// as typed fields such as String or Int logged in the payload.
+func (l *Logger) Infow(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	l.zlog(ctx, LevelInfo, msg, nil, keysAndValues)
+}
+
+// Debug logs a message of debugging severity.
+func (l *Logger) Debug(ctx context.Context, format string, args ...interface{}) {
+	l.zlog(ctx, LevelDebug, format, args, nil)
+}
+
+// TimeBlock logs the start of a named block and returns a function that logs
+// its duration when called, usually deferred. The completion entry is logged
+// at warning severity if it took longer than the optional threshold.
+func (l *Logger) TimeBlock(ctx context.Context, name string, threshold ...time.Duration) func() {
+	l.zlog(ctx, LevelDebug, "start", nil, []interface{}{"block", name})
+	return l.timeBlockDone(ctx, name, threshold)
+}
+
+// timeBlockDone returns the function logging the duration of a block
+// started now.
+func (l *Logger) timeBlockDone(ctx context.Context, name string, threshold []time.Duration) func() {
+	start := l.clock.Now()
+	return func() {
+		elapsed := l.clock.Now().Sub(start)
+		level := LevelInfo
+		if len(threshold) > 0 && elapsed > threshold[0] {
+			level = LevelWarn
+		}
+		l.zlog(ctx, level, "done", nil, []interface{}{"block", name, Int64("duration_ms", elapsed.Milliseconds())})
+	}
+}
+
+func (l *Logger) zhttp(ctx context.Context, level Level, req *http.Request, res *http.Response, path string, latency time.Duration, extra ...zapcore.Field) {
+	ctx = orBackground(ctx)
+	if level.zapLevel() >= zapcore.ErrorLevel || (res != nil && res.StatusCode >= http.StatusInternalServerError) {
+		l.flushDebug(ctx)
+	}
+	if !l.enabled(ctx, level) {
+		return
+	}
+	fields := []zapcore.Field{
+		zapdriver.HTTP(httpPayload(req, res, latency)),
+		zapdriver.Label(l.keyRemoteIP, l.clientIP.resolve(req.Header.Get, req.RemoteAddr)),
+		zapdriver.Label(l.keyRoute, path),
+	}
+	l.zentry(ctx, level, "request log", path, append(fields, extra...))
+}
+
+// httpPayload returns the HTTP payload of a request.
+func httpPayload(req *http.Request, res *http.Response, latency time.Duration) *zapdriver.HTTPPayload {
+	payload := zapdriver.NewHTTP(req, res)
+	payload.Latency = latency.String()
+	// The bodies have usually been consumed by then, the content lengths
+	// give their sizes.
+	if req.ContentLength > 0 {
+		payload.RequestSize = strconv.FormatInt(req.ContentLength, 10)
+	}
+	if res != nil && res.ContentLength >= 0 && payload.ResponseSize == "" {
+		payload.ResponseSize = strconv.FormatInt(res.ContentLength, 10)
+	}
+	return payload
+}
+
+// zentry logs an entry that has no source location, such as an access log,
+// with the request fields of ctx followed by extra.
+func (l *Logger) zentry(ctx context.Context, level Level, msg, route string, extra []zapcore.Field) {
+	fields, requestID, userID := l.entryFields(ctx)
+	msg, fields = l.redactor.entry(msg, append(fields, extra...))
+	msg, fields = l.truncator.entry(msg, fields)
+	l.fireHooks(ctx, level, msg, requestID, userID, route, nil, fields, 2)
+
+	switch level {
+	case LevelCritical:
+		// DPanic is reported with critical severity, keep it from panicking.
+		l.zapLogger().WithOptions(zap.WithPanicHook(noopHook{})).DPanic(msg, fields...)
+	case LevelError:
+		l.zapLogger().Error(msg, fields...)
+	case LevelWarn:
+		l.zapLogger().Warn(msg, fields...)
+	case LevelDebug:
+		l.zapLogger().Debug(msg, fields...)
+	default:
+		l.zapLogger().Info(msg, fields...)
+	}
+}
+
+// entryFields returns the fields of the entries logged with ctx without a
+// source location, with their request ID and user ID.
+func (l *Logger) entryFields(ctx context.Context) ([]zapcore.Field, string, string) {
+	requestID := requestID(ctx)
+	fields := []zapcore.Field{
+		contextField(ctx),
+		zapdriver.Label(l.keyRequestID, requestID),
+	}
+	fields = append(fields, l.traceFields(ctx)...)
+	userID, ok := l.loggedUserID(ctx)
+	if ok {
+		fields = append(fields, zapdriver.Label(l.keyUserID, userID))
+	}
+
+	scope, ok := l.scope(ctx)
+	if ok {
+		fields = append(fields, zapdriver.Label(l.keyScope, scope))
+	}
+	fields = l.appendLabels(fields, l.contextKeysAndValues(ctx), l.redactKeysFor(ctx))
+	return fields, requestID, userID
+}
+
+// appendLabels appends the fields of the key/value pairs to fields, the
+// typed fields being passed through.
+func (l *Logger) appendLabels(fields []zapcore.Field, args []interface{}, redact map[string]struct{}) []zapcore.Field {
+	for i := 0; i < len(args); {
+		// Typed fields are passed through as structured payload fields.
+		if field, ok := args[i].(zapcore.Field); ok {
+			if _, ok := redact[field.Key]; ok {
+				field = zap.String(field.Key, redactedValue)
+			}
+			fields = append(fields, field)
+			i++
+			continue
+		}
+		if i == len(args)-1 {
+			break
+		}
+		key, val := args[i], args[i+1]
+		if keyStr, ok := key.(string); ok {
+			switch keyStr {
+			case "error", l.keyError:
+				if err, ok := val.(error); ok {
+					if l.payloadFields {
+						fields = append(fields, zap.NamedError(l.keyError, err))
+					} else {
+						fields = append(fields, zapdriver.Label(l.keyError, err.Error()))
+					}
+					if stack, ok := errorStack(err); ok {
+						fields = append(fields, zap.String(keyStackTrace, stack))
+					}
+				}
+			default:
+				if _, ok := redact[keyStr]; ok {
+					fields = append(fields, l.label(keyStr, redactedValue))
+					break
+				}
+				if l.payloadFields {
+					fields = append(fields, payloadField(keyStr, val))
+					break
+				}
+				switch v := val.(type) {
+				case string:
+					fields = append(fields, zapdriver.Label(keyStr, v))
+				case *string:
+					if v != nil {
+						fields = append(fields, zapdriver.Label(keyStr, *v))
+					}
+				case []byte:
+					fields = append(fields, zapdriver.Label(keyStr, string(v)))
+				case *[]byte:
+					if v != nil {
+						fields = append(fields, zapdriver.Label(keyStr, string(*v)))
+					}
+				case int:
+					fields = append(fields, zapdriver.Label(keyStr, strconv.Itoa(v)))
+				case int32:
+					fields = append(fields, zapdriver.Label(keyStr, strconv.Itoa(int(v))))
+				case int64:
+					fields = append(fields, zapdriver.Label(keyStr, strconv.FormatInt(v, 10)))
+				case uint:
+					fields = append(fields, zapdriver.Label(keyStr, strconv.FormatUint(uint64(v), 10)))
+				case uint64:
+					fields = append(fields, zapdriver.Label(keyStr, strconv.FormatUint(v, 10)))
+				case bool:
+					fields = append(fields, zapdriver.Label(keyStr, strconv.FormatBool(v)))
+				case float64:
+					fields = append(fields, zapdriver.Label(keyStr, strconv.FormatFloat(v, 'g', -1, 64)))
+				default:
+					fields = append(fields, zapdriver.Label(keyStr, fmt.Sprintf("%+v", v)))
+				}
+			}
+		}
+		i += 2
+	}
+	return fields
+}
+
+// label returns a label field, or a payload field if labels are reserved for
+// the request metadata.
+func (l *Logger) label(key, value string) zapcore.Field {
+	if l.payloadFields {
+		return zap.String(key, value)
+	}
+	return zapdriver.Label(key, value)
+}
+
+// payloadField returns a typed payload field for the value.
+func payloadField(key string, val interface{}) zapcore.Field {
+	switch v := val.(type) {
+	case *string:
+		if v == nil {
+			return zap.Skip()
+		}
+		return zap.String(key, *v)
+	case []byte:
+		return zap.String(key, string(v))
+	case *[]byte:
+		if v == nil {
+			return zap.Skip()
+		}
+		return zap.String(key, string(*v))
+	default:
+		return zap.Any(key, v)
+	}
+}
+
+// fieldsPool holds the field slices of the entries logged by zlog.
+var fieldsPool = sync.Pool{
+	New: func() interface{} {
+		fields := make([]zapcore.Field, 0, 16)
+		return &fields
+	},
+}
+
+// maxPooledFields is the capacity of the largest field slices recycled.
+const maxPooledFields = 64
+
+// putFields recycles a field slice once its entry is written.
+func putFields(fields *[]zapcore.Field) {
+	if cap(*fields) > maxPooledFields {
+		return
+	}
+	clear(*fields)
+	*fields = (*fields)[:0]
+	fieldsPool.Put(fields)
+}
+
+// zlog must be called directly from the exported logging function so that
+// the source location points at its caller.
+func (l *Logger) zlog(ctx context.Context, level Level, format string, args []interface{}, keysAndValues []interface{}) {
+	l.zlogAt(ctx, level, 0, format, args, keysAndValues)
+}
+
+// zlogAt logs an entry located at the program counter pc, or at the caller
+// of the exported logging function calling zlog if pc is 0.
+func (l *Logger) zlogAt(ctx context.Context, level Level, pc uintptr, format string, args []interface{}, keysAndValues []interface{}) {
+	ctx = orBackground(ctx)
+	if level == LevelWarn && l.warnEscalation.escalate(format, l.clock.Now()) {
+		level = LevelError
+	}
+	if level <= LevelFirst || level == LevelLast {
+		return
+	}
+	// Levels above LevelLast panic or exit and can't be disabled.
+	var buffer *debugBuffer
+	if level < LevelLast && !l.enabled(ctx, level) {
+		if buffer = debugBufferFrom(ctx, level); buffer == nil {
+			return
+		}
+	}
+	msg := fmt.Sprintf(format, args...)
+	requestID := requestID(ctx)
+
+	// Error reports are located even without source locations.
+	reported := l.errorReporting && level.zapLevel() >= zapcore.ErrorLevel
+	var caller zapcore.EntryCaller
+	if !l.noSourceLocation || reported {
+		if pc == 0 {
+			pc, caller.File, caller.Line, caller.Defined = runtime.Caller(3 + l.callerSkip)
+		} else {
+			frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+			caller.File, caller.Line, caller.Defined = frame.File, frame.Line, frame.PC != 0
+		}
+		caller.PC = pc
+	}
+	pooled := fieldsPool.Get().(*[]zapcore.Field)
+	fields := append((*pooled)[:0],
+		contextField(ctx),
+		zapdriver.Label(l.keyRequestID, requestID),
+	)
+	if !l.noSourceLocation {
+		fields = append(fields, zapdriver.SourceLocation(caller.PC, caller.File, caller.Line, caller.Defined))
+	}
+	fields = append(fields, l.traceFields(ctx)...)
+	if reported {
+		fields = append(fields, l.errorReport(caller.PC, caller.File, caller.Line, caller.Defined)...)
+	}
+
+	userID, ok := l.loggedUserID(ctx)
+	if ok {
+		fields = append(fields, zapdriver.Label(l.keyUserID, userID))
+	}
+
+	scope, ok := l.scope(ctx)
+	if ok {
+		fields = append(fields, zapdriver.Label(l.keyScope, scope))
+	}
+
+	if ctxFields := l.contextKeysAndValues(ctx); len(ctxFields) > 0 {
+		keysAndValues = append(ctxFields[:len(ctxFields):len(ctxFields)], keysAndValues...)
+	}
+	fields = l.appendLabels(fields, keysAndValues, l.redactKeysFor(ctx))
+	if l.stackReport && level.zapLevel() >= l.stackLevel && !hasField(fields, keyStackTrace) {
+		fields = append(fields, zap.String(keyStackTrace, callerStack(msg, 3+l.callerSkip)))
+	}
+	// The fields built are recycled once written, unless retained.
+	*pooled = fields
+	msg, fields = l.redactor.entry(msg, fields)
+	msg, fields = l.truncator.entry(msg, fields)
+	if buffer != nil {
+		buffer.add(zapcore.Entry{Level: zapcore.DebugLevel, Time: l.clock.Now(), Message: msg, Caller: caller}, fields)
+		return
+	}
+	if level.zapLevel() >= zapcore.ErrorLevel {
+		l.flushDebug(ctx)
+	}
+	l.recordSpanEvent(ctx, level, msg, l.entryError(keysAndValues), fields)
+	if !l.fireHooks(ctx, level, msg, requestID, userID, "", keysAndValues, fields, 3+l.callerSkip) {
+		defer putFields(pooled)
+	}
+	switch level {
+	case LevelInfo:
+		l.zapLogger().Info(msg, fields...)
+	case LevelError:
+		l.zapLogger().Error(msg, fields...)
+	case LevelCritical:
+		// DPanic is reported with critical severity, keep it from panicking.
+		l.zapLogger().WithOptions(zap.WithPanicHook(noopHook{})).DPanic(msg, fields...)
+	case levelDPanic:
+		l.zapLogger().DPanic(msg, fields...)
+	case levelPanic:
+		l.zapLogger().Panic(msg, fields...)
+	case levelFatal:
+		l.zapLogger().Fatal(msg, fields...)
+	case LevelWarn:
+		l.zapLogger().Warn(msg, fields...)
+	default:
+		l.zapLogger().Debug(msg, fields...)
+	}
+}
+
+// noopHook is a zapcore.CheckWriteHook doing nothing after writing.
+type noopHook struct{}
+
+func (noopHook) OnWrite(*zapcore.CheckedEntry, []zapcore.Field) {}
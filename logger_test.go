@@ -0,0 +1,204 @@
+package logging
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// newTestLogger returns a logger writing its entries as JSON to the returned
+// buffer.
+func newTestLogger(t *testing.T, c *Config) (*Logger, *bytes.Buffer) {
+	t.Helper()
+	buf := &bytes.Buffer{}
+	if c.ProjectID == "" {
+		c.ProjectID = "test"
+	}
+	if c.Level == LevelFirst {
+		c.Level = LevelDebug
+	}
+	c.Output = buf
+	l, err := New(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return l, buf
+}
+
+// entries decodes the entries written to buf.
+func entries(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+	var entries []map[string]interface{}
+	scanner := bufio.NewScanner(bytes.NewReader(buf.Bytes()))
+	for scanner.Scan() {
+		entry := map[string]interface{}{}
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("decoding entry %q: %v", scanner.Text(), err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// labels returns the labels of an entry.
+func labels(entry map[string]interface{}) map[string]interface{} {
+	labels, _ := entry["logging.googleapis.com/labels"].(map[string]interface{})
+	return labels
+}
+
+// fixedClock is a Clock always returning the same time.
+type fixedClock time.Time
+
+func (c fixedClock) Now() time.Time {
+	return time.Time(c)
+}
+
+func TestClock(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 6000, time.UTC)
+	l, buf := newTestLogger(t, &Config{Clock: fixedClock(now)})
+	l.Info(context.Background(), "hello")
+
+	got := entries(t, buf)
+	if len(got) != 1 {
+		t.Fatalf("got %d entries, want 1", len(got))
+	}
+	if ts := got[0]["timestamp"]; ts != "2024-01-02T03:04:05.000006Z" {
+		t.Errorf("got timestamp %v, want 2024-01-02T03:04:05.000006Z", ts)
+	}
+}
+
+func TestClockLatency(t *testing.T) {
+	clock := &manualClock{now: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)}
+	l, buf := newTestLogger(t, &Config{Clock: clock})
+	h := l.Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		clock.now = clock.now.Add(1500 * time.Millisecond)
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users", nil))
+
+	got := entries(t, buf)
+	if len(got) != 1 {
+		t.Fatalf("got %d entries, want 1", len(got))
+	}
+	if req, _ := got[0]["httpRequest"].(map[string]interface{}); req["latency"] != "1.5s" {
+		t.Errorf("got latency %v, want 1.5s", req["latency"])
+	}
+	if ts := got[0]["timestamp"]; ts != "2024-01-02T03:04:06.5Z" {
+		t.Errorf("got timestamp %v, want 2024-01-02T03:04:06.5Z", ts)
+	}
+}
+
+func TestFieldsRecycled(t *testing.T) {
+	l, buf := newTestLogger(t, &Config{})
+	var retained []Field
+	ctx := context.Background()
+	l.Infow(ctx, "first", "a", 1, "ok", true, "ratio", 0.25, "n", uint64(7))
+	l.RegisterHook(func(e Entry) {
+		if retained == nil {
+			retained = e.Fields
+		}
+	})
+	l.Infow(ctx, "hooked", "b", "2")
+	l.Infow(ctx, "third", "c", "3", "d", "4", "e", "5")
+
+	got := entries(t, buf)
+	if len(got) != 3 {
+		t.Fatalf("got %d entries, want 3", len(got))
+	}
+	for key, want := range map[string]string{"a": "1", "ok": "true", "ratio": "0.25", "n": "7"} {
+		if got := labels(got[0])[key]; got != want {
+			t.Errorf("got %s %q, want %q", key, got, want)
+		}
+	}
+	lbls := labels(got[2])
+	if _, ok := lbls["a"]; ok || lbls["e"] != "5" {
+		t.Errorf("got labels %v, want only those of the entry", lbls)
+	}
+	if _, ok := lbls["b"]; ok {
+		t.Errorf("got labels %v, want only those of the entry", lbls)
+	}
+	found := false
+	for _, f := range retained {
+		if f.Key == "labels.b" && f.String == "2" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("got fields %v retained by the hook, want them left intact", retained)
+	}
+}
+
+// logVia logs as a helper wrapping the logging functions.
+func logVia(l *Logger, ctx context.Context, msg string) {
+	l.Info(ctx, msg)
+}
+
+func TestCallerSkip(t *testing.T) {
+	l, buf := newTestLogger(t, &Config{})
+	logVia(l.WithCallerSkip(1), context.Background(), "wrapped")
+	logVia(l, context.Background(), "unwrapped")
+
+	got := entries(t, buf)
+	if len(got) != 2 {
+		t.Fatalf("got %d entries, want 2", len(got))
+	}
+	for i, want := range []string{"TestCallerSkip", "logVia"} {
+		loc, _ := got[i]["logging.googleapis.com/sourceLocation"].(map[string]interface{})
+		if fn, _ := loc["function"].(string); !strings.HasSuffix(fn, want) {
+			t.Errorf("entry %d: got function %v, want %s", i, loc["function"], want)
+		}
+	}
+}
+
+func TestDisableSourceLocation(t *testing.T) {
+	l, buf := newTestLogger(t, &Config{DisableSourceLocation: true, ErrorReporting: true})
+	l.Info(context.Background(), "hot path")
+	l.Error(context.Background(), "failed")
+
+	got := entries(t, buf)
+	if len(got) != 2 {
+		t.Fatalf("got %d entries, want 2", len(got))
+	}
+	for i, e := range got {
+		if loc, ok := e["logging.googleapis.com/sourceLocation"]; ok {
+			t.Errorf("entry %d: got source location %v, want none", i, loc)
+		}
+	}
+	reportCtx, _ := got[1]["context"].(map[string]interface{})
+	if _, ok := reportCtx["reportLocation"]; !ok {
+		t.Errorf("got error report context %v, want its location", got[1]["context"])
+	}
+}
+
+func TestPayloadFields(t *testing.T) {
+	l, buf := newTestLogger(t, &Config{PayloadFields: true})
+	ctx := WithRequestID(context.Background(), "4bf92f3577b34da6a3ce929d0e0e4736")
+	var missing *string
+	l.Infow(ctx, "charged", "count", 3, "ok", true, "name", []byte("bob"), "missing", missing, "error", errors.New("declined"))
+
+	got := entries(t, buf)
+	if len(got) != 1 {
+		t.Fatalf("got %d entries, want 1", len(got))
+	}
+	e := got[0]
+	if e["count"] != 3.0 || e["ok"] != true || e["name"] != "bob" || e["err"] != "declined" {
+		t.Errorf("got %v, want the typed payload fields", e)
+	}
+	if _, ok := e["missing"]; ok {
+		t.Errorf("got %v, want no field for a nil pointer", e)
+	}
+	lbls := labels(e)
+	if _, ok := lbls["count"]; ok {
+		t.Errorf("got labels %v, want only the request metadata", lbls)
+	}
+	if lbls["request_id"] != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("got labels %v, want the request ID", lbls)
+	}
+}
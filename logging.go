@@ -2,213 +2,229 @@
 package logging
 
 import (
-	"fmt"
 	"net/http"
-	"runtime"
-	"strconv"
+	"sync/atomic"
 	"time"
 
-	"github.com/blendle/zapdriver"
-	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
-	"go.uber.org/zap/zapcore"
+
 	"golang.org/x/net/context"
 )
 
-// Static configuration variables initalized at runtime.
-var logLevel = LevelDebug
-var projectID string
-var keyRequestID = "request_id"
-var keyUserID = "user_id"
-var keyError = "err"
-var keyScope = "scope"
-var keyRemoteIP = "remote_ip"
-var keyRoute = "route"
-
-var zlogger *zap.Logger
+// stdLogger holds the logger used by the package level functions.
+var stdLogger atomic.Pointer[Logger]
 
-// Initialize initializes the logger module.
-func Initialize(c *Config) error {
+func init() {
+	stdLogger.Store(newLogger())
+}
 
-	var err error
+// std returns the logger used by the package level functions.
+func std() *Logger {
+	return stdLogger.Load()
+}
 
-	if c != nil {
-		logLevel = c.Level
-		projectID = c.ProjectID
-		keyRequestID = c.KeyRequestID
-		keyUserID = c.KeyUserID
-		keyError = c.KeyError
-		keyScope = c.KeyScope
-	}
-	if projectID == "" {
-		config := zap.NewDevelopmentConfig()
-		config.EncoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
-		zlogger, err = config.Build(zap.AddStacktrace(zap.ErrorLevel))
-	} else if c.Development {
-		zlogger, err = zapdriver.NewDevelopment()
-	} else {
-		zlogger, err = zapdriver.NewProduction()
+// orStd returns l, or the package level logger if l is nil.
+func orStd(l *Logger) *Logger {
+	if l == nil {
+		return std()
 	}
+	return l
+}
+
+// systemClock is the default Clock backed by the real time.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// since returns the time elapsed since start by the clock of l.
+func (l *Logger) since(start time.Time) time.Duration {
+	return l.clock.Now().Sub(start)
+}
+
+// zapClock adapts a Clock to zapcore.Clock.
+type zapClock struct {
+	Clock
+}
+
+func (zapClock) NewTicker(d time.Duration) *time.Ticker {
+	return time.NewTicker(d)
+}
+
+// closeGracePeriod is the time the outputs of a replaced logger are kept open
+// for the entries still being written to them.
+var closeGracePeriod = 10 * time.Second
+
+// Initialize initializes the logger module. It may be called again, also
+// concurrently with logging, to replace the configuration. Hooks added with
+// AddErrorHook and RegisterHook are kept. The replaced logger is flushed at
+// once, but its outputs are only closed after a grace period so that entries
+// being written to them concurrently aren't lost.
+func Initialize(c *Config) error {
+	l, err := New(c)
 	if err != nil {
 		return err
 	}
+	lastConfig.Store(c)
+	replaceStd(l)
 	return nil
 }
 
+// Disable replaces the package level logger with one discarding all the
+// entries, as NewNop, e.g. for benchmarks. Initialize enables it again.
+func Disable() {
+	replaceStd(NewNop())
+}
+
+// replaceStd replaces the package level logger with l, keeping its hooks.
+func replaceStd(l *Logger) {
+	l.hooks = std().hooks
+	l.callSites = std().callSites
+	if old := stdLogger.Swap(l); old.zlogger.Load() != nil {
+		reportOutputError(old.Sync())
+		time.AfterFunc(closeGracePeriod, func() { old.Close() })
+	}
+}
+
+// NewNop returns a Logger discarding all the entries, for libraries and
+// benchmarks not to pay for logging. The entries are disabled at all levels,
+// so logging a message without arguments doesn't allocate.
+func NewNop() *Logger {
+	l := newLogger()
+	l.level.SetLevel(LevelFirst.zapLevel())
+	l.zlogger.Store(zap.NewNop())
+	l.auditLogger = zap.NewNop()
+	return l
+}
+
+// SetLogger replaces the logger used by the package level functions with l,
+// e.g. to record the entries of a test, and returns the logger it replaced.
+// Unlike Initialize it neither keeps the hooks nor closes the replaced
+// logger.
+func SetLogger(l *Logger) *Logger {
+	return stdLogger.Swap(l)
+}
+
+// Default returns the logger used by the package level functions. Initialize
+// and SetLogger replace it, so get it when logging rather than keeping it.
+func Default() *Logger {
+	return std()
+}
+
+// SetZapLogger atomically replaces the zap logger the package level
+// functions write to.
+func SetZapLogger(z *zap.Logger) {
+	std().SetZapLogger(z)
+}
+
+// AddErrorHook registers a hook notified of the entries logged at error
+// severity and above, e.g. to forward them to an error tracker.
+func AddErrorHook(hook ErrorHook) {
+	std().AddErrorHook(hook)
+}
+
+// RegisterHook registers a function called with every entry logged, before
+// it is written, e.g. to count entries or annotate traces.
+func RegisterHook(hook func(Entry)) {
+	std().RegisterHook(hook)
+}
+
 // Finalize finalizes the logging module.
 func Finalize() {
-	// Check if client and logger are valid.
-	if zlogger != nil {
-		zlogger.Sync()
+	// The fallback logger of an uninitialized logger is left open.
+	if l := std(); l.zlogger.Load() != nil {
+		reportOutputError(l.Close())
 	}
 }
 
+// SetLevel changes the minimum level of the entries logged at runtime.
+func SetLevel(level Level) {
+	std().SetLevel(level)
+}
+
+// Enabled reports whether entries of the level are logged with ctx by the
+// package level logger, see Logger.Enabled.
+func Enabled(ctx context.Context, level Level) bool {
+	return std().Enabled(ctx, level)
+}
+
+// GetLevel returns the minimum level of the entries logged.
+func GetLevel() Level {
+	return std().GetLevel()
+}
+
+// LevelHandler returns an HTTP handler to get the current level with GET and
+// change it with PUT, e.g. `curl -X PUT -d '{"level":"debug"}'`.
+func LevelHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		std().level.ServeHTTP(w, r)
+	})
+}
+
 // HTTP is a helper function for logging API request/response
 func HTTP(ctx context.Context, req *http.Request, res *http.Response, path string, latency time.Duration) {
-	requestID := trace.SpanContextFromContext(ctx).TraceID().String()
-	spanID := trace.SpanContextFromContext(ctx).SpanID().String()
-	payload := zapdriver.NewHTTP(req, res)
-	payload.Latency = latency.String()
-	fields := []zapcore.Field{
-		zapdriver.HTTP(payload),
-		zapdriver.Label(keyRequestID, requestID),
-		zapdriver.Label(keyRemoteIP, req.Header.Get("true-client-ip")),
-		zapdriver.Label(keyRoute, path),
-	}
-	if projectID != "" {
-		fields = append(fields, zapdriver.TraceContext(requestID, spanID, true, projectID)...)
-	}
-	userID, ok := ctx.Value(keyUserID).(string)
-	if ok {
-		fields = append(fields, zapdriver.Label(keyUserID, userID))
-	}
+	std().zhttp(ctx, LevelInfo, req, res, path, latency)
+}
 
-	scope, ok := ctx.Value(keyScope).(string)
-	if ok {
-		fields = append(fields, zapdriver.Label(keyScope, scope))
-	}
+// Critical logs a message of critical severity. Unlike Fatal it doesn't exit.
+func Critical(ctx context.Context, format string, args ...interface{}) {
+	std().zlog(ctx, LevelCritical, format, args, nil)
+}
 
-	zlogger.Info("request log", fields...)
+// DPanic logs a message of critical severity, then panics in development,
+// i.e. without a project ID or with Config.Development set.
+func DPanic(ctx context.Context, format string, args ...interface{}) {
+	std().zlog(ctx, levelDPanic, format, args, nil)
 }
 
-// Critical logs a message of critical severity.
-func Critical(ctx context.Context, format string, args ...interface{}) {
-	zlog(ctx, LevelCritical, format, args, nil)
+// Panic logs a message of alert severity, then panics.
+func Panic(ctx context.Context, format string, args ...interface{}) {
+	std().zlog(ctx, levelPanic, format, args, nil)
+}
+
+// Fatal logs a message of emergency severity, then exits the process.
+func Fatal(ctx context.Context, format string, args ...interface{}) {
+	std().zlog(ctx, levelFatal, format, args, nil)
 }
 
 // Error logs a message of error severity.
 func Error(ctx context.Context, format string, args ...interface{}) {
-	zlog(ctx, LevelError, format, args, nil)
+	std().zlog(ctx, LevelError, format, args, nil)
 }
 
 // Errorw logs a message with additional context
 func Errorw(ctx context.Context, msg string, keysAndValues ...interface{}) {
-	zlog(ctx, LevelError, msg, nil, keysAndValues)
+	std().zlog(ctx, LevelError, msg, nil, keysAndValues)
 }
 
 // Warn logs a message of warning severity.
 func Warn(ctx context.Context, format string, args ...interface{}) {
-	zlog(ctx, LevelWarn, format, args, nil)
+	std().zlog(ctx, LevelWarn, format, args, nil)
 }
 
 // Info logs a message of informational severity.
 func Info(ctx context.Context, format string, args ...interface{}) {
-	zlog(ctx, LevelInfo, format, args, nil)
+	std().zlog(ctx, LevelInfo, format, args, nil)
 }
 
 // Infow logs a message with additional context
+//
+// The context is given as alternating keys and values logged as labels, or
+// as typed fields such as String or Int logged in the payload.
 func Infow(ctx context.Context, msg string, keysAndValues ...interface{}) {
-	zlog(ctx, LevelInfo, msg, nil, keysAndValues)
+	std().zlog(ctx, LevelInfo, msg, nil, keysAndValues)
 }
 
 // Debug logs a message of debugging severity.
 func Debug(ctx context.Context, format string, args ...interface{}) {
-	zlog(ctx, LevelDebug, format, args, nil)
+	std().zlog(ctx, LevelDebug, format, args, nil)
 }
 
-func parseLabels(args []interface{}) []zapcore.Field {
-	if len(args) == 0 {
-		return nil
-	}
-	fields := []zapcore.Field{}
-	for i := 0; i < len(args); {
-		if i == len(args)-1 {
-			break
-		}
-		key, val := args[i], args[i+1]
-		if keyStr, ok := key.(string); ok {
-			switch keyStr {
-			case "error", keyError:
-				if err, ok := val.(error); ok {
-					fields = append(fields, zapdriver.Label(keyError, err.Error()))
-				}
-			default:
-				switch v := val.(type) {
-				case string:
-					fields = append(fields, zapdriver.Label(keyStr, v))
-				case *string:
-					if v != nil {
-						fields = append(fields, zapdriver.Label(keyStr, *v))
-					}
-				case []byte:
-					fields = append(fields, zapdriver.Label(keyStr, string(v)))
-				case *[]byte:
-					if v != nil {
-						fields = append(fields, zapdriver.Label(keyStr, string(*v)))
-					}
-				case int:
-					fields = append(fields, zapdriver.Label(keyStr, strconv.Itoa(v)))
-				case int32:
-					fields = append(fields, zapdriver.Label(keyStr, strconv.Itoa(int(v))))
-				case int64:
-					fields = append(fields, zapdriver.Label(keyStr, strconv.Itoa(int(v))))
-				default:
-					fields = append(fields, zapdriver.Label(keyStr, fmt.Sprintf("%+v", v)))
-				}
-			}
-		}
-		i += 2
-	}
-	return fields
-}
-
-func zlog(ctx context.Context, level Level, format string, args []interface{}, keysAndValues []interface{}) {
-	if level <= LevelFirst || level >= LevelLast || level > logLevel {
-		return
-	}
-	msg := fmt.Sprintf(format, args...)
-	requestID := trace.SpanContextFromContext(ctx).TraceID().String()
-	spanID := trace.SpanContextFromContext(ctx).SpanID().String()
-
-	fields := []zapcore.Field{
-		zapdriver.Label(keyRequestID, requestID),
-		zapdriver.SourceLocation(runtime.Caller(2)),
-	}
-	if projectID != "" {
-		fields = append(fields, zapdriver.TraceContext(requestID, spanID, true, projectID)...)
-	}
-
-	userID, ok := ctx.Value(keyUserID).(string)
-	if ok {
-		fields = append(fields, zapdriver.Label(keyUserID, userID))
-	}
-
-	scope, ok := ctx.Value(keyScope).(string)
-	if ok {
-		fields = append(fields, zapdriver.Label(keyScope, scope))
-	}
-
-	fields = append(fields, parseLabels(keysAndValues)...)
-	switch level {
-	case LevelInfo:
-		zlogger.Info(msg, fields...)
-	case LevelError:
-		zlogger.Error(msg, fields...)
-	case LevelCritical:
-		zlogger.Fatal(msg, fields...)
-	case LevelWarn:
-		zlogger.Warn(msg, fields...)
-	default:
-		zlogger.Debug(msg, fields...)
-	}
+// TimeBlock logs the start of a named block and returns a function that logs
+// its duration when called, usually deferred.
+func TimeBlock(ctx context.Context, name string, threshold ...time.Duration) func() {
+	l := std()
+	l.zlog(ctx, LevelDebug, "start", nil, []interface{}{"block", name})
+	return l.timeBlockDone(ctx, name, threshold)
 }
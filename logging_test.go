@@ -0,0 +1,189 @@
+package logging
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"golang.org/x/net/context"
+)
+
+// useStd makes l the package level logger for the duration of the test.
+func useStd(t *testing.T, l *Logger) {
+	t.Helper()
+	old := stdLogger.Swap(l)
+	t.Cleanup(func() { stdLogger.Store(old) })
+}
+
+func TestTimeBlock(t *testing.T) {
+	l, buf := newTestLogger(t, &Config{})
+	useStd(t, l)
+	func() {
+		defer TimeBlock(context.Background(), "import", time.Hour)()
+	}()
+	func() {
+		defer l.TimeBlock(context.Background(), "export")()
+	}()
+
+	got := entries(t, buf)
+	if len(got) != 4 {
+		t.Fatalf("got %d entries, want 4", len(got))
+	}
+	for i, want := range []struct {
+		message string
+		block   string
+	}{
+		{"start", "import"},
+		{"done", "import"},
+		{"start", "export"},
+		{"done", "export"},
+	} {
+		entry := got[i]
+		if entry["message"] != want.message || labels(entry)["block"] != want.block {
+			t.Errorf("entry %d: got %v of block %v, want %s of %s", i, entry["message"], labels(entry)["block"], want.message, want.block)
+		}
+		loc, _ := entry["logging.googleapis.com/sourceLocation"].(map[string]interface{})
+		if file, _ := loc["file"].(string); !strings.HasSuffix(file, "logging_test.go") {
+			t.Errorf("entry %d: got source file %v, want the caller", i, loc["file"])
+		}
+		if want.message == "done" {
+			if _, ok := entry["duration_ms"].(float64); !ok {
+				t.Errorf("entry %d: got duration_ms %#v, want a number", i, entry["duration_ms"])
+			}
+		}
+	}
+}
+
+func TestInitializeKeepsOldOutputsOpen(t *testing.T) {
+	useStd(t, newLogger())
+	path := filepath.Join(t.TempDir(), "old.log")
+	if err := Initialize(&Config{Level: LevelDebug, Outputs: []Output{{Path: path, Format: "json"}}}); err != nil {
+		t.Fatal(err)
+	}
+	old := std()
+	if err := Initialize(&Config{Level: LevelDebug, Output: io.Discard}); err != nil {
+		t.Fatal(err)
+	}
+	// A write racing with the reload still reaches the old output.
+	old.Info(context.Background(), "late")
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"late"`) {
+		t.Errorf("old output %q lacks the entry written during the grace period", data)
+	}
+	Finalize()
+}
+
+func TestDisable(t *testing.T) {
+	useStd(t, newLogger())
+	Disable()
+	l := std()
+	ctx := context.Background()
+	allocs := testing.AllocsPerRun(100, func() {
+		Info(ctx, "discarded")
+		l.Debug(ctx, "discarded")
+	})
+	if allocs != 0 {
+		t.Errorf("got %v allocations per run, want none", allocs)
+	}
+	if err := Audit(ctx, AuditEvent{Actor: "u1", Action: "a", Resource: "r", Outcome: AuditSuccess}); err != nil {
+		t.Error(err)
+	}
+	if l.enabled(ctx, LevelCritical) {
+		t.Error("got critical entries enabled, want all the levels disabled")
+	}
+}
+
+func TestUninitialized(t *testing.T) {
+	useStd(t, newLogger())
+	var ctx context.Context
+	Info(ctx, "before Initialize")
+	Errorw(ctx, "before Initialize", "attempt", 1)
+	HTTP(ctx, httptest.NewRequest("GET", "/", nil), &http.Response{StatusCode: 200}, "/", time.Millisecond)
+	SecurityEvent(ctx, SecurityAuthFailure)
+	if err := Audit(ctx, AuditEvent{Actor: "u1", Action: "a", Resource: "r", Outcome: AuditSuccess}); err != nil {
+		t.Error(err)
+	}
+	if err := Job(ctx, "cleanup", func(context.Context) error { return nil }); err != nil {
+		t.Error(err)
+	}
+	Finalize()
+}
+
+func TestNilContext(t *testing.T) {
+	l, buf := newTestLogger(t, &Config{})
+	var ctx context.Context
+	l.Infow(ctx, "no context", "attempt", 1)
+	l.Logr(ctx).Info("no context")
+
+	got := entries(t, buf)
+	if len(got) != 2 {
+		t.Fatalf("got %d entries, want 2", len(got))
+	}
+	if labels(got[0])["attempt"] != "1" {
+		t.Errorf("got labels %v, want attempt", labels(got[0]))
+	}
+}
+
+// fatalHook panics instead of exiting after Fatal writes its entry.
+type fatalHook struct{}
+
+func (fatalHook) OnWrite(*zapcore.CheckedEntry, []zapcore.Field) {
+	panic("fatal")
+}
+
+// recovered reports whether f panicked.
+func recovered(f func()) (panicked bool) {
+	defer func() { panicked = recover() != nil }()
+	f()
+	return false
+}
+
+func TestSevereLevels(t *testing.T) {
+	for _, tt := range []struct {
+		name        string
+		log         func(ctx context.Context, format string, args ...interface{})
+		development bool
+		want        zapcore.Level
+		wantPanic   bool
+	}{
+		{name: "Critical", log: Critical, want: zapcore.DPanicLevel},
+		{name: "Critical", log: Critical, development: true, want: zapcore.DPanicLevel},
+		{name: "DPanic", log: DPanic, want: zapcore.DPanicLevel},
+		{name: "DPanic", log: DPanic, development: true, want: zapcore.DPanicLevel, wantPanic: true},
+		{name: "Panic", log: Panic, want: zapcore.PanicLevel, wantPanic: true},
+		{name: "Fatal", log: Fatal, want: zapcore.FatalLevel, wantPanic: true},
+	} {
+		core, logs := observer.New(zapcore.DebugLevel)
+		opts := []zap.Option{zap.WithFatalHook(fatalHook{})}
+		if tt.development {
+			opts = append(opts, zap.Development())
+		}
+		l := newLogger()
+		l.SetZapLogger(zap.New(core, opts...))
+		useStd(t, l)
+
+		panicked := recovered(func() { tt.log(context.Background(), "%s failed", "db") })
+		if panicked != tt.wantPanic {
+			t.Errorf("%s (development %v): got panicked %v, want %v", tt.name, tt.development, panicked, tt.wantPanic)
+		}
+		entries := logs.All()
+		if len(entries) != 1 {
+			t.Fatalf("%s: got %d entries, want 1", tt.name, len(entries))
+		}
+		if got := entries[0]; got.Level != tt.want || got.Message != "db failed" {
+			t.Errorf("%s: got %v %q, want %v", tt.name, got.Level, got.Message, tt.want)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+// Package loggingtest records the entries of the logging package in memory
+// for tests to assert on.
+package loggingtest
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/cyoyu/logging"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// Entry is an entry recorded.
+type Entry struct {
+	Level   logging.Level
+	Message string
+	// Fields holds the fields and labels of the entry, the labels under
+	// their key without the "labels." prefix.
+	Fields map[string]interface{}
+}
+
+// Recorder records the entries logged by its Logger.
+type Recorder struct {
+	// Logger is the logger recording the entries, installed as the package
+	// level logger for the duration of the test.
+	Logger *logging.Logger
+	t      testing.TB
+	logs   *observer.ObservedLogs
+}
+
+// New returns a Recorder recording the entries of all levels, and installs
+// its logger as the package level logger until the end of the test.
+func New(t testing.TB) *Recorder {
+	t.Helper()
+	l, err := logging.New(&logging.Config{Level: logging.LevelDebug, Output: io.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	core, logs := observer.New(zapcore.DebugLevel)
+	l.SetZapLogger(zap.New(core))
+	old := logging.SetLogger(l)
+	t.Cleanup(func() { logging.SetLogger(old) })
+	return &Recorder{Logger: l, t: t, logs: logs}
+}
+
+// levels maps the zap levels to the levels of the entries.
+var levels = map[zapcore.Level]logging.Level{
+	zapcore.DebugLevel:  logging.LevelDebug,
+	zapcore.InfoLevel:   logging.LevelInfo,
+	zapcore.WarnLevel:   logging.LevelWarn,
+	zapcore.ErrorLevel:  logging.LevelError,
+	zapcore.DPanicLevel: logging.LevelCritical,
+	zapcore.PanicLevel:  logging.LevelCritical,
+	zapcore.FatalLevel:  logging.LevelCritical,
+}
+
+// Entries returns the entries recorded.
+func (r *Recorder) Entries() []Entry {
+	logged := r.logs.All()
+	entries := make([]Entry, len(logged))
+	for i, e := range logged {
+		fields := map[string]interface{}{}
+		for k, v := range e.ContextMap() {
+			fields[strings.TrimPrefix(k, "labels.")] = v
+		}
+		entries[i] = Entry{Level: levels[e.Level], Message: e.Message, Fields: fields}
+	}
+	return entries
+}
+
+// Reset forgets the entries recorded.
+func (r *Recorder) Reset() {
+	r.logs.TakeAll()
+}
+
+// AssertLogged fails the test unless an entry was recorded at the level
+// with a message containing msg.
+func (r *Recorder) AssertLogged(level logging.Level, msg string) {
+	r.t.Helper()
+	for _, e := range r.Entries() {
+		if e.Level == level && strings.Contains(e.Message, msg) {
+			return
+		}
+	}
+	r.t.Errorf("no %s entry containing %q in %s", level, msg, r)
+}
+
+// AssertField fails the test unless an entry was recorded with the field or
+// label, its value being compared in its string form, e.g. "404" for a
+// status label.
+func (r *Recorder) AssertField(key string, value interface{}) {
+	r.t.Helper()
+	want := fmt.Sprint(value)
+	for _, e := range r.Entries() {
+		if v, ok := e.Fields[key]; ok && fmt.Sprint(v) == want {
+			return
+		}
+	}
+	r.t.Errorf("no entry with %s=%s in %s", key, want, r)
+}
+
+// String lists the entries recorded, one per line.
+func (r *Recorder) String() string {
+	var b strings.Builder
+	for _, e := range r.Entries() {
+		fmt.Fprintf(&b, "\n\t%s %q %v", e.Level, e.Message, e.Fields)
+	}
+	return b.String()
+}
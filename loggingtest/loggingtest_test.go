@@ -0,0 +1,54 @@
+package loggingtest
+
+import (
+	"testing"
+
+	"github.com/cyoyu/logging"
+	"golang.org/x/net/context"
+)
+
+func TestRecorder(t *testing.T) {
+	r := New(t)
+	ctx := logging.WithRequestID(context.Background(), "req-1")
+	logging.Infow(ctx, "order placed", "order_id", "42", logging.Int("items", 3))
+	logging.Warn(ctx, "stock low")
+
+	entries := r.Entries()
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(entries))
+	}
+	if entries[1].Level != logging.LevelWarn || entries[1].Message != "stock low" {
+		t.Errorf("got %v %q, want a warning", entries[1].Level, entries[1].Message)
+	}
+	r.AssertLogged(logging.LevelInfo, "placed")
+	r.AssertField("order_id", "42")
+	r.AssertField("items", 3)
+
+	r.Reset()
+	if entries := r.Entries(); len(entries) != 0 {
+		t.Errorf("got %d entries after Reset, want none", len(entries))
+	}
+}
+
+func TestRecorderFailures(t *testing.T) {
+	r := New(t)
+	logging.Info(context.Background(), "started")
+
+	rt := &recordingT{TB: t}
+	r.t = rt
+	r.AssertLogged(logging.LevelError, "started")
+	r.AssertField("missing", "x")
+	if rt.errors != 2 {
+		t.Errorf("got %d errors, want 2", rt.errors)
+	}
+}
+
+// recordingT counts the errors of the assertions.
+type recordingT struct {
+	testing.TB
+	errors int
+}
+
+func (t *recordingT) Errorf(string, ...interface{}) {
+	t.errors++
+}
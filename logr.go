@@ -0,0 +1,98 @@
+package logging
+
+import (
+	"runtime"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"golang.org/x/net/context"
+)
+
+// logrSink is a logr.LogSink logging with a Logger, the package level logger
+// if l is nil, in the context it was created with.
+type logrSink struct {
+	l             *Logger
+	ctx           context.Context
+	name          string
+	keysAndValues []interface{}
+	// callDepth is the number of frames between the logging call and the
+	// methods of the sink.
+	callDepth int
+}
+
+// Logr returns a logr.Logger logging with the package level logger, so the
+// entries of Kubernetes clients and controllers are filtered, encoded and
+// linked to the trace of ctx as the others. V-level 0 is logged at info
+// level, the higher levels at debug level, and the names of the logger are
+// logged joined by dots under the "logger" key.
+func Logr(ctx context.Context) logr.Logger {
+	return (*Logger)(nil).Logr(ctx)
+}
+
+// Logr returns a logr.Logger logging with l in the context ctx.
+func (l *Logger) Logr(ctx context.Context) logr.Logger {
+	return logr.New(&logrSink{l: l, ctx: orBackground(ctx)})
+}
+
+// logrLevel maps a V-level to the level of the entries.
+func logrLevel(level int) Level {
+	if level > 0 {
+		return LevelDebug
+	}
+	return LevelInfo
+}
+
+func (s *logrSink) Init(info logr.RuntimeInfo) {
+	s.callDepth += info.CallDepth
+}
+
+func (s *logrSink) Enabled(level int) bool {
+	return orStd(s.l).Enabled(s.ctx, logrLevel(level))
+}
+
+func (s *logrSink) Info(level int, msg string, keysAndValues ...interface{}) {
+	s.log(logrLevel(level), msg, keysAndValues)
+}
+
+func (s *logrSink) Error(err error, msg string, keysAndValues ...interface{}) {
+	if err != nil {
+		keysAndValues = append([]interface{}{"error", err}, keysAndValues...)
+	}
+	s.log(LevelError, msg, keysAndValues)
+}
+
+// log logs an entry located at the caller of the logr.Logger.
+func (s *logrSink) log(level Level, msg string, keysAndValues []interface{}) {
+	var pcs [1]uintptr
+	// Skip runtime.Callers, log and the method of the sink.
+	runtime.Callers(3+s.callDepth, pcs[:])
+	kv := make([]interface{}, 0, 2+len(s.keysAndValues)+len(keysAndValues))
+	if s.name != "" {
+		kv = append(kv, "logger", s.name)
+	}
+	kv = append(append(kv, s.keysAndValues...), keysAndValues...)
+	format := strings.ReplaceAll(msg, "%", "%%")
+	orStd(s.l).zlogAt(s.ctx, level, pcs[0], format, nil, kv)
+}
+
+func (s *logrSink) WithValues(keysAndValues ...interface{}) logr.LogSink {
+	clone := *s
+	clone.keysAndValues = append(s.keysAndValues[:len(s.keysAndValues):len(s.keysAndValues)], keysAndValues...)
+	return &clone
+}
+
+func (s *logrSink) WithName(name string) logr.LogSink {
+	clone := *s
+	if s.name != "" {
+		name = s.name + "." + name
+	}
+	clone.name = name
+	return &clone
+}
+
+// WithCallDepth implements logr.CallDepthLogSink.
+func (s *logrSink) WithCallDepth(depth int) logr.LogSink {
+	clone := *s
+	clone.callDepth += depth
+	return &clone
+}
@@ -0,0 +1,42 @@
+package logging
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/net/context"
+)
+
+func TestLogr(t *testing.T) {
+	l, buf := newTestLogger(t, &Config{Level: LevelInfo})
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID}))
+	logger := l.Logr(ctx).WithName("controller").WithName("pods").WithValues("namespace", "prod")
+	logger.V(1).Info("skipped")
+	logger.Info("reconciled", "pod", "api-1")
+	logger.Error(errors.New("conflict"), "update failed")
+
+	got := entries(t, buf)
+	if len(got) != 2 {
+		t.Fatalf("got %d entries, want 2", len(got))
+	}
+	for key, want := range map[string]string{"logger": "controller.pods", "namespace": "prod", "pod": "api-1"} {
+		if got := labels(got[0])[key]; got != want {
+			t.Errorf("got %s %q, want %q", key, got, want)
+		}
+	}
+	if got[0]["logging.googleapis.com/trace"] != "projects/test/traces/4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("got trace %v, want the trace of the context", got[0]["logging.googleapis.com/trace"])
+	}
+	for i, e := range got {
+		loc, _ := e["logging.googleapis.com/sourceLocation"].(map[string]interface{})
+		if file, _ := loc["file"].(string); !strings.HasSuffix(file, "logr_test.go") {
+			t.Errorf("entry %d: got source file %v, want the caller", i, loc["file"])
+		}
+	}
+	if got[1]["severity"] != "ERROR" || labels(got[1])["err"] != "conflict" {
+		t.Errorf("got %v err %v, want ERROR conflict", got[1]["severity"], labels(got[1])["err"])
+	}
+}
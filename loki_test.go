@@ -0,0 +1,116 @@
+package logging
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/net/context"
+)
+
+func TestLoki(t *testing.T) {
+	var mu sync.Mutex
+	var pushes []lokiPush
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Scope-OrgID") != "tenant" {
+			t.Errorf("got tenant %q, want tenant", r.Header.Get("X-Scope-OrgID"))
+		}
+		var push lokiPush
+		if err := json.NewDecoder(r.Body).Decode(&push); err != nil {
+			t.Error(err)
+		}
+		mu.Lock()
+		pushes = append(pushes, push)
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	l, err := New(&Config{
+		ProjectID: "test",
+		Level:     LevelDebug,
+		Loki: &LokiOutput{
+			URL:           srv.URL,
+			TenantID:      "tenant",
+			Labels:        map[string]string{"app": "api"},
+			BatchInterval: time.Hour,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	traceID := trace.TraceID{1}
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID}))
+	req := httptest.NewRequest("GET", "/users/1", nil)
+	l.HTTP(ctx, req, &http.Response{StatusCode: 200}, "/users/:id", time.Millisecond)
+	l.Error(context.Background(), "failed")
+	l.Error(context.Background(), "failed again")
+	if err := l.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	l.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(pushes) != 1 {
+		t.Fatalf("got %d pushes, want 1", len(pushes))
+	}
+	streams := pushes[0].Streams
+	if len(streams) != 2 {
+		t.Fatalf("got %d streams, want 2", len(streams))
+	}
+	want := map[string]string{"app": "api", "level": "info", "route": "/users/:id"}
+	if got := streams[0].Stream; lokiStreamKey(got) != lokiStreamKey(want) {
+		t.Errorf("got stream %v, want %v", got, want)
+	}
+	if line := streams[0].Values[0][1]; !strings.Contains(line, traceID.String()) {
+		t.Errorf("got line %q, want the request ID", line)
+	}
+	want = map[string]string{"app": "api", "level": "error"}
+	if got := streams[1].Stream; lokiStreamKey(got) != lokiStreamKey(want) {
+		t.Errorf("got stream %v, want %v", got, want)
+	}
+	if n := len(streams[1].Values); n != 2 {
+		t.Fatalf("got %d error entries, want 2", n)
+	}
+	if line := streams[1].Values[0][1]; !strings.Contains(line, `"message":"failed"`) {
+		t.Errorf("got line %q, want the JSON entry", line)
+	}
+}
+
+func TestLokiDown(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+	fallback := useFallbackOutput(t)
+
+	l, err := New(&Config{
+		ProjectID: "test",
+		Level:     LevelDebug,
+		Loki:      &LokiOutput{URL: srv.URL, BatchInterval: time.Hour},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := OutputErrors()
+	l.Error(context.Background(), "failed")
+	l.Error(context.Background(), "failed again")
+	if err := l.Sync(); err == nil {
+		t.Error("got no error flushing to Loki down")
+	}
+	l.Close()
+
+	if got := OutputErrors() - before; got != 2 {
+		t.Errorf("counted %d output errors, want the 2 entries lost", got)
+	}
+	got := entries(t, fallback)
+	if len(got) != 2 || got[0]["message"] != "failed" || got[1]["message"] != "failed again" {
+		t.Errorf("fell back to %v, want the entries lost", got)
+	}
+}
@@ -0,0 +1,53 @@
+package logging
+
+import (
+	"os"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/compute/metadata"
+	"golang.org/x/net/context"
+)
+
+// metadataEnv maps the labels of the Kubernetes metadata to the environment
+// variables the downward API is expected to set them in.
+var metadataEnv = map[string]string{
+	"pod_name":       "POD_NAME",
+	"namespace_name": "POD_NAMESPACE",
+	"node_name":      "NODE_NAME",
+	"container_name": "CONTAINER_NAME",
+}
+
+// metadataTimeout bounds the requests to the metadata server.
+const metadataTimeout = 2 * time.Second
+
+// detectMetadata returns the labels of the Kubernetes metadata set by the
+// downward API and, on GCE and GKE, of the zone, instance ID and cluster
+// name served by the metadata server. The metadata missing are left out.
+func detectMetadata() map[string]string {
+	labels := map[string]string{}
+	for label, env := range metadataEnv {
+		if v := os.Getenv(env); v != "" {
+			labels[label] = v
+		}
+	}
+	if os.Getenv("GCE_METADATA_HOST") == "" && !metadata.OnGCE() {
+		return labels
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), metadataTimeout)
+	defer cancel()
+	client := metadata.NewClient(nil)
+	for label, suffix := range map[string]string{
+		"zone":         "instance/zone",
+		"instance_id":  "instance/id",
+		"cluster_name": "instance/attributes/cluster-name",
+	} {
+		v, err := client.GetWithContext(ctx, suffix)
+		if err != nil || v == "" {
+			continue
+		}
+		// The zone is served as projects/<number>/zones/<zone>.
+		labels[label] = v[strings.LastIndex(v, "/")+1:]
+	}
+	return labels
+}
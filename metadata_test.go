@@ -0,0 +1,51 @@
+package logging
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/context"
+)
+
+func TestMetadata(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/computeMetadata/v1/instance/zone":
+			w.Write([]byte("projects/123/zones/europe-west1-b"))
+		case "/computeMetadata/v1/instance/id":
+			w.Write([]byte("42"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	t.Setenv("GCE_METADATA_HOST", strings.TrimPrefix(server.URL, "http://"))
+	t.Setenv("POD_NAME", "api-7d9f")
+	t.Setenv("POD_NAMESPACE", "prod")
+
+	l, buf := newTestLogger(t, &Config{Metadata: true})
+	l.Info(context.Background(), "started")
+
+	got := entries(t, buf)
+	if len(got) != 1 {
+		t.Fatalf("got %d entries, want 1", len(got))
+	}
+	lbls := labels(got[0])
+	for label, want := range map[string]string{
+		"pod_name":       "api-7d9f",
+		"namespace_name": "prod",
+		"zone":           "europe-west1-b",
+		"instance_id":    "42",
+	} {
+		if lbls[label] != want {
+			t.Errorf("got %s %q, want %q", label, lbls[label], want)
+		}
+	}
+	for _, label := range []string{"cluster_name", "node_name"} {
+		if v, ok := lbls[label]; ok {
+			t.Errorf("got %s %q, want none", label, v)
+		}
+	}
+}
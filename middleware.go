@@ -2,46 +2,199 @@ package logging
 
 import (
 	"net/http"
-	"strings"
+	"sort"
+	"strconv"
 	"time"
 
+	"github.com/blendle/zapdriver"
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
-// RequestLogger provides a gin middleware to log HTTP requests
-func RequestLogger(excludes []string) gin.HandlerFunc {
+// RequestLogger provides a gin middleware to log HTTP requests. Requests
+// without a trace get a request ID, taken from their X-Request-ID header or
+// generated, and the ID is echoed in the X-Request-ID response header. The
+// middleware should be installed after the tracing one.
+func RequestLogger(opts ...Option) gin.HandlerFunc {
+	return requestLogger(nil, opts)
+}
 
-	requestLogExcludes := map[string]struct{}{}
-	for _, s := range excludes {
-		requestLogExcludes[s] = struct{}{}
-	}
+// RequestLogger provides a gin middleware to log HTTP requests with l.
+func (l *Logger) RequestLogger(opts ...Option) gin.HandlerFunc {
+	return requestLogger(l, opts)
+}
+
+func requestLogger(logger *Logger, opts []Option) gin.HandlerFunc {
+
+	o := newMiddlewareOptions(opts)
 
 	return func(ctx *gin.Context) {
-		// Do nothing if the request URL is on the blacklist.
-		url := ctx.Request.URL.EscapedPath()
-		if _, exists := requestLogExcludes[url]; exists {
+		l := orStd(logger)
+		// Do nothing if the request is on the blacklist.
+		if o.excludes.match(ctx.Request.Method, ctx.Request.URL.EscapedPath()) {
 			return
 		}
-		forwardChain := strings.Split(ctx.GetHeader("X-Forwarded-For"), ",")
-		remoteIP := ""
-		if len(forwardChain) > 0 && forwardChain[0] != "" {
-			remoteIP = forwardChain[0]
-		} else {
-			remoteIP = strings.Split(ctx.Request.RemoteAddr, ":")[0]
+		reqCtx := withHeaderBaggage(withHeaderTrace(ctx.Request.Context(), ctx.GetHeader), ctx.GetHeader)
+		reqCtx, requestID := withRequestID(reqCtx, ctx.GetHeader(requestIDHeader))
+		ctx.Header(requestIDHeader, requestID)
+		reqCtx = withRoute(reqCtx, ctx.FullPath(), ctx.Request.URL.Path)
+		reqCtx = l.withForceDebug(reqCtx, ctx.GetHeader(debugHeader))
+		ctx.Request = ctx.Request.WithContext(withLogger(l.withDebugBuffer(reqCtx), l))
+		var reqBody []byte
+		var reqTruncated bool
+		var resBody *bodyWriter
+		bodyCapture, headerCapture := o.captures(l)
+		if b := bodyCapture; b != nil {
+			if b.captures(ctx.GetHeader("Content-Type")) {
+				reqBody, reqTruncated, ctx.Request.Body = b.captureRequestBody(ctx.Request.Body)
+			}
+			resBody = &bodyWriter{ResponseWriter: ctx.Writer, capturedBody: capturedBody{max: b.maxBytes}}
+			ctx.Writer = resBody
 		}
-		ctx.Request.Header.Add("x-forwarded-for", remoteIP)
-		ctx.Request.Header.Add("true-client-ip", remoteIP)
-		start := time.Now()
+		start := o.now(l)
 		ctx.Next()
-		duration := time.Since(start)
-		HTTP(ctx.Request.Context(),
+		duration := o.since(l, start)
+		// Failed requests are always logged.
+		failed := len(ctx.Errors) > 0 || ctx.Writer.Status() >= http.StatusInternalServerError
+		if !failed && !o.sample(ctx.Request.Context(), l, ctx.FullPath(), ctx.Request.URL.Path, ctx.Writer.Status()) {
+			return
+		}
+		level := l.statusLevel(ctx.Writer.Status())
+		var extra []zapcore.Field
+		if len(ctx.Errors) > 0 {
+			level = LevelError
+			extra = append(extra, zap.Array("errors", ginErrors(ctx.Errors)))
+		}
+		level, extra = l.slowRequest(level, extra, ctx.FullPath(), ctx.Request.URL.Path, duration)
+		if h := headerCapture; h != nil {
+			extra = append(extra, h.fields(ctx.Request.Header, ctx.Writer.Header())...)
+		}
+		if b := bodyCapture; b != nil {
+			reqCtx := ctx.Request.Context()
+			if f, ok := b.field(reqCtx, l, "request_body", ctx.GetHeader("Content-Type"), reqBody, reqTruncated); ok {
+				extra = append(extra, f)
+			}
+			if f, ok := b.field(reqCtx, l, "response_body", resBody.Header().Get("Content-Type"), resBody.body.Bytes(), resBody.truncated); ok {
+				extra = append(extra, f)
+			}
+		}
+		l.zhttp(ctx.Request.Context(),
+			level,
 			ctx.Request,
 			&http.Response{
-				StatusCode: ctx.Writer.Status(),
+				StatusCode:    ctx.Writer.Status(),
+				ContentLength: int64(responseSize(ctx.Writer)),
 			},
 			ctx.FullPath(),
 			duration,
+			extra...,
 		)
 
 	}
 }
+
+// responseSize returns the size of the response body written, gin reporting
+// -1 when nothing was.
+func responseSize(w gin.ResponseWriter) int {
+	if size := w.Size(); size > 0 {
+		return size
+	}
+	return 0
+}
+
+// defaultStatusLevels are the levels of the access logs by status.
+var defaultStatusLevels = map[string]Level{
+	"5xx": LevelError,
+	"4xx": LevelWarn,
+}
+
+// statusLevel returns the level of the access log of a request answered with
+// the status, looked up by code then by class.
+func (l *Logger) statusLevel(status int) Level {
+	levels := l.statusLevels
+	if levels == nil {
+		levels = defaultStatusLevels
+	}
+	if level, ok := levels[strconv.Itoa(status)]; ok {
+		return level
+	}
+	if level, ok := levels[strconv.Itoa(status/100)+"xx"]; ok {
+		return level
+	}
+	return LevelInfo
+}
+
+// slowRequest raises the level of the access log of a request slower than
+// the threshold of its route to warning, and labels it.
+func (l *Logger) slowRequest(level Level, extra []zapcore.Field, route, urlPath string, latency time.Duration) (Level, []zapcore.Field) {
+	threshold := l.slowThreshold
+	for _, rt := range l.slowRoutes {
+		if routeMatch(rt.pattern, route) || routeMatch(rt.pattern, urlPath) {
+			threshold = rt.threshold
+			break
+		}
+	}
+	if threshold <= 0 || latency < threshold {
+		return level, extra
+	}
+	if level > LevelWarn {
+		level = LevelWarn
+	}
+	return level, append(extra, zapdriver.Label("slow_request", "true"))
+}
+
+// routeThreshold is the slow request threshold of the routes matching a
+// pattern.
+type routeThreshold struct {
+	pattern   string
+	threshold time.Duration
+}
+
+// setSlowRequests sets the slow request thresholds.
+func (l *Logger) setSlowRequests(threshold time.Duration, routes map[string]time.Duration) {
+	l.slowThreshold = threshold
+	for pattern, threshold := range routes {
+		l.slowRoutes = append(l.slowRoutes, routeThreshold{pattern: pattern, threshold: threshold})
+	}
+	// Longer patterns are more specific.
+	sort.Slice(l.slowRoutes, func(i, j int) bool {
+		return len(l.slowRoutes[i].pattern) > len(l.slowRoutes[j].pattern)
+	})
+}
+
+// ginErrors marshals the errors attached to a gin context with their type and
+// metadata.
+type ginErrors []*gin.Error
+
+func (errs ginErrors) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	for _, e := range errs {
+		e := e
+		enc.AppendObject(zapcore.ObjectMarshalerFunc(func(oe zapcore.ObjectEncoder) error {
+			oe.AddString("type", ginErrorType(e.Type))
+			oe.AddString("error", e.Error())
+			if e.Meta != nil {
+				return oe.AddReflected("meta", e.Meta)
+			}
+			return nil
+		}))
+	}
+	return nil
+}
+
+func ginErrorType(t gin.ErrorType) string {
+	switch {
+	case t == gin.ErrorTypeAny:
+		return "any"
+	case t&gin.ErrorTypeBind != 0:
+		return "bind"
+	case t&gin.ErrorTypeRender != 0:
+		return "render"
+	case t&gin.ErrorTypePublic != 0:
+		return "public"
+	case t&gin.ErrorTypePrivate != 0:
+		return "private"
+	default:
+		return "unknown"
+	}
+}
@@ -0,0 +1,99 @@
+package logging
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp/fasthttpadaptor"
+	"go.uber.org/zap"
+)
+
+// FiberRequestLogger provides a fiber middleware to log HTTP requests, with
+// request IDs as RequestLogger.
+func FiberRequestLogger(opts ...Option) fiber.Handler {
+	return fiberRequestLogger(nil, opts)
+}
+
+// FiberRequestLogger provides a fiber middleware to log HTTP requests with l.
+func (l *Logger) FiberRequestLogger(opts ...Option) fiber.Handler {
+	return fiberRequestLogger(l, opts)
+}
+
+func fiberRequestLogger(logger *Logger, opts []Option) fiber.Handler {
+
+	o := newMiddlewareOptions(opts)
+
+	return func(c *fiber.Ctx) error {
+		l := orStd(logger)
+		// The route of the handler is only known once it is matched.
+		header := func(key string) string { return c.Get(key) }
+		reqCtx := withHeaderBaggage(withHeaderTrace(c.UserContext(), header), header)
+		reqCtx, requestID := withRequestID(reqCtx, c.Get(requestIDHeader))
+		c.Set(requestIDHeader, requestID)
+		reqCtx = l.withForceDebug(withRoute(reqCtx, "", c.Path()), c.Get(debugHeader))
+		c.SetUserContext(withLogger(l.withDebugBuffer(reqCtx), l))
+		start := o.now(l)
+		err := c.Next()
+		if err != nil {
+			// Let the error handler write the response so the logged
+			// status matches what the client receives.
+			if err := c.App().ErrorHandler(c, err); err != nil {
+				_ = c.SendStatus(fiber.StatusInternalServerError)
+			}
+		}
+		duration := o.since(l, start)
+
+		// Do not log if the request is on the blacklist.
+		if o.excludes.match(c.Method(), c.Path()) {
+			return nil
+		}
+		// Failed requests are always logged.
+		failed := c.Response().StatusCode() >= fiber.StatusInternalServerError
+		if !failed && !o.sample(c.UserContext(), l, c.Route().Path, c.Path(), c.Response().StatusCode()) {
+			return nil
+		}
+		req := &http.Request{}
+		if convErr := fasthttpadaptor.ConvertRequest(c.Context(), req, true); convErr != nil {
+			// Still log what is known of a request the adaptor rejects.
+			req = &http.Request{
+				Method:     c.Method(),
+				URL:        &url.URL{Path: c.Path()},
+				Header:     http.Header{},
+				RemoteAddr: c.Context().RemoteAddr().String(),
+			}
+		}
+		// The body has already been handled, don't count it twice.
+		req.Body = nil
+		level, extra := l.slowRequest(l.statusLevel(c.Response().StatusCode()), nil, c.Route().Path, c.Path(), duration)
+		if err != nil {
+			extra = append(extra, zap.NamedError(l.keyError, err))
+		}
+		bodyCapture, headerCapture := o.captures(l)
+		if h := headerCapture; h != nil {
+			extra = append(extra, h.fields(req.Header, c.GetRespHeaders())...)
+		}
+		if b := bodyCapture; b != nil {
+			reqBody, reqTruncated := b.truncate(c.Body())
+			if f, ok := b.field(c.UserContext(), l, "request_body", c.Get(fiber.HeaderContentType), reqBody, reqTruncated); ok {
+				extra = append(extra, f)
+			}
+			resBody, resTruncated := b.truncate(c.Response().Body())
+			if f, ok := b.field(c.UserContext(), l, "response_body", c.GetRespHeader(fiber.HeaderContentType), resBody, resTruncated); ok {
+				extra = append(extra, f)
+			}
+		}
+		l.zhttp(c.UserContext(),
+			level,
+			req,
+			&http.Response{
+				StatusCode:    c.Response().StatusCode(),
+				ContentLength: int64(len(c.Response().Body())),
+			},
+			c.Route().Path,
+			duration,
+			extra...,
+		)
+		return nil
+	}
+}
@@ -0,0 +1,81 @@
+package logging
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestFiberRequestLogger(t *testing.T) {
+	l, buf := newTestLogger(t, &Config{})
+	app := fiber.New()
+	app.Use(l.FiberRequestLogger(WithExcludes("/health")))
+	app.Get("/users/:id", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusCreated)
+	})
+	app.Get("/missing/:id", func(c *fiber.Ctx) error {
+		return fiber.ErrNotFound
+	})
+	app.Get("/health", func(c *fiber.Ctx) error {
+		return nil
+	})
+
+	for _, path := range []string{"/users/1", "/missing/2", "/health"} {
+		if _, err := app.Test(httptest.NewRequest("GET", path, nil)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got := entries(t, buf)
+	if len(got) != 2 {
+		t.Fatalf("got %d entries, want 2", len(got))
+	}
+	for i, want := range []struct {
+		route    string
+		status   float64
+		severity string
+	}{
+		{"/users/:id", 201, "INFO"},
+		{"/missing/:id", 404, "WARNING"},
+	} {
+		if severity := got[i]["severity"]; severity != want.severity {
+			t.Errorf("entry %d: got severity %v, want %s", i, severity, want.severity)
+		}
+		if route := labels(got[i])["route"]; route != want.route {
+			t.Errorf("entry %d: got route %v, want %s", i, route, want.route)
+		}
+		req, _ := got[i]["httpRequest"].(map[string]interface{})
+		if status := req["status"]; status != want.status {
+			t.Errorf("entry %d: got status %v, want %v", i, status, want.status)
+		}
+	}
+	if e := got[1]["err"]; e != "Not Found" {
+		t.Errorf("got error %v, want the error of the handler", e)
+	}
+	if e, ok := got[0]["err"]; ok {
+		t.Errorf("got error %v, want none", e)
+	}
+}
+
+func TestFiberRequestLoggerSizes(t *testing.T) {
+	l, buf := newTestLogger(t, &Config{})
+	app := fiber.New()
+	app.Use(l.FiberRequestLogger())
+	app.Post("/echo", func(c *fiber.Ctx) error {
+		return c.Send(append(c.Body(), c.Body()...))
+	})
+	if _, err := app.Test(httptest.NewRequest("POST", "/echo", strings.NewReader("hello"))); err != nil {
+		t.Fatal(err)
+	}
+
+	got := entries(t, buf)
+	if len(got) != 1 {
+		t.Fatalf("got %d entries, want 1", len(got))
+	}
+	req, _ := got[0]["httpRequest"].(map[string]interface{})
+	if req["requestSize"] != "5" || req["responseSize"] != "10" {
+		t.Errorf("got sizes %v and %v, want 5 and 10", req["requestSize"], req["responseSize"])
+	}
+}
@@ -0,0 +1,152 @@
+package logging
+
+import (
+	"errors"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func TestRequestLoggerGinErrors(t *testing.T) {
+	l, buf := newTestLogger(t, &Config{})
+	r := gin.New()
+	r.Use(l.RequestLogger())
+	r.GET("/ok", func(c *gin.Context) {
+		c.Status(200)
+	})
+	r.GET("/fail", func(c *gin.Context) {
+		_ = c.Error(errors.New("bind failed")).SetType(gin.ErrorTypeBind).SetMeta(gin.H{"field": "email"})
+		_ = c.Error(errors.New("audit failed"))
+		c.Status(400)
+	})
+	for _, path := range []string{"/ok", "/fail"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+
+	got := entries(t, buf)
+	if len(got) != 2 {
+		t.Fatalf("got %d entries, want 2", len(got))
+	}
+	if severity := got[0]["severity"]; severity != "INFO" {
+		t.Errorf("got severity %v without errors, want INFO", severity)
+	}
+	if severity := got[1]["severity"]; severity != "ERROR" {
+		t.Errorf("got severity %v with errors, want ERROR", severity)
+	}
+	errs, _ := got[1]["errors"].([]interface{})
+	if len(errs) != 2 {
+		t.Fatalf("got errors %v, want two", got[1]["errors"])
+	}
+	if e, _ := errs[0].(map[string]interface{}); e["type"] != "bind" || e["error"] != "bind failed" || !reflect.DeepEqual(e["meta"], map[string]interface{}{"field": "email"}) {
+		t.Errorf("got error %v, want the bind error with its meta", e)
+	}
+	if e, _ := errs[1].(map[string]interface{}); e["type"] != "private" || e["error"] != "audit failed" || e["meta"] != nil {
+		t.Errorf("got error %v, want the private error", e)
+	}
+}
+
+func TestRequestLoggerStatusLevels(t *testing.T) {
+	for _, tt := range []struct {
+		levels map[string]Level
+		status int
+		want   string
+	}{
+		{nil, 200, "INFO"},
+		{nil, 302, "INFO"},
+		{nil, 404, "WARNING"},
+		{nil, 503, "ERROR"},
+		{map[string]Level{"404": LevelInfo, "4xx": LevelError}, 404, "INFO"},
+		{map[string]Level{"404": LevelInfo, "4xx": LevelError}, 409, "ERROR"},
+		{map[string]Level{"404": LevelInfo}, 503, "INFO"},
+	} {
+		l, buf := newTestLogger(t, &Config{StatusLevels: tt.levels})
+		r := gin.New()
+		r.Use(l.RequestLogger())
+		r.GET("/", func(c *gin.Context) {
+			c.Status(tt.status)
+		})
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+		got := entries(t, buf)
+		if len(got) != 1 || got[0]["severity"] != tt.want {
+			t.Errorf("%v: got %v for status %d, want %s", tt.levels, got, tt.status, tt.want)
+		}
+	}
+}
+
+func TestRequestLoggerSlowRequests(t *testing.T) {
+	l, buf := newTestLogger(t, &Config{
+		SlowRequest: time.Hour,
+		SlowRoutes:  map[string]time.Duration{"/slow/*": time.Nanosecond},
+	})
+	r := gin.New()
+	r.Use(l.RequestLogger())
+	r.GET("/fast", func(c *gin.Context) {
+		c.Status(200)
+	})
+	r.GET("/slow/:id", func(c *gin.Context) {
+		time.Sleep(time.Millisecond)
+		c.Status(200)
+	})
+	r.GET("/slow/fail/:id", func(c *gin.Context) {
+		time.Sleep(time.Millisecond)
+		c.Status(500)
+	})
+	for _, path := range []string{"/fast", "/slow/1", "/slow/fail/1"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+
+	got := entries(t, buf)
+	if len(got) != 3 {
+		t.Fatalf("got %d entries, want 3", len(got))
+	}
+	for i, want := range []struct {
+		severity string
+		slow     interface{}
+	}{
+		{"INFO", nil},
+		{"WARNING", "true"},
+		{"ERROR", "true"},
+	} {
+		if got[i]["severity"] != want.severity || labels(got[i])["slow_request"] != want.slow {
+			t.Errorf("entry %d: got severity %v and slow_request %v, want %s and %v",
+				i, got[i]["severity"], labels(got[i])["slow_request"], want.severity, want.slow)
+		}
+	}
+}
+
+func TestRequestLoggerSizes(t *testing.T) {
+	l, buf := newTestLogger(t, &Config{})
+	r := gin.New()
+	r.Use(l.RequestLogger())
+	r.POST("/echo", func(c *gin.Context) {
+		body, _ := c.GetRawData()
+		c.Data(200, "text/plain", append(body, body...))
+	})
+	r.GET("/empty", func(c *gin.Context) {
+		c.Status(204)
+	})
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/echo", strings.NewReader("hello")))
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/empty", nil))
+
+	got := entries(t, buf)
+	if len(got) != 2 {
+		t.Fatalf("got %d entries, want 2", len(got))
+	}
+	for i, want := range []struct{ request, response interface{} }{
+		{"5", "10"},
+		{"0", "0"},
+	} {
+		req, _ := got[i]["httpRequest"].(map[string]interface{})
+		if req["requestSize"] != want.request || req["responseSize"] != want.response {
+			t.Errorf("entry %d: got sizes %v and %v, want %v and %v", i, req["requestSize"], req["responseSize"], want.request, want.response)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package logging
+
+import (
+	"go.uber.org/zap"
+)
+
+// setLevels sets the levels of the sub-loggers.
+func (l *Logger) setLevels(levels map[string]Level) {
+	if len(levels) == 0 {
+		return
+	}
+	l.levels = make(map[string]zap.AtomicLevel, len(levels))
+	for name, level := range levels {
+		l.levels[name] = zap.NewAtomicLevelAt(level.zapLevel())
+	}
+}
+
+// Named returns a sub-logger of l logging the entries of a component under
+// its name, dot-separated from the name of l if any. It has the level
+// configured for its name in Config.Levels, otherwise it shares the level of
+// l: changing it with SetLevel changes both.
+func (l *Logger) Named(name string) *Logger {
+	child := *l
+	if l.name != "" {
+		name = l.name + "." + name
+	}
+	child.name = name
+	if level, ok := l.levels[name]; ok {
+		child.level = level
+	}
+	return &child
+}
+
+// WithCallerSkip returns a copy of l locating its entries skip more frames
+// up the stack, for the helpers wrapping its logging functions to locate the
+// entries at their callers.
+func (l *Logger) WithCallerSkip(skip int) *Logger {
+	child := *l
+	child.callerSkip += skip
+	return &child
+}
+
+// WithCallerSkip returns a copy of the package level logger, see
+// Logger.WithCallerSkip. Like Named, it must be called after Initialize.
+func WithCallerSkip(skip int) *Logger {
+	return std().WithCallerSkip(skip)
+}
+
+// Named returns a sub-logger of the package level logger, see Logger.Named.
+// It keeps writing to the package level logger it was created from, so it
+// must be created after Initialize.
+func Named(name string) *Logger {
+	return std().Named(name)
+}
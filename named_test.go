@@ -0,0 +1,43 @@
+package logging
+
+import (
+	"testing"
+
+	"golang.org/x/net/context"
+)
+
+func TestNamed(t *testing.T) {
+	l, buf := newTestLogger(t, &Config{
+		Level:  LevelInfo,
+		Levels: map[string]Level{"storage": LevelDebug, "http": LevelWarn},
+	})
+	storage := l.Named("storage")
+	storage.Debug(context.Background(), "reading")
+	storage.Named("gcs").Debug(context.Background(), "fetching")
+	l.Named("http").Info(context.Background(), "serving")
+	l.Named("http").Warn(context.Background(), "slow")
+	l.Named("cache").Info(context.Background(), "hit")
+	l.Debug(context.Background(), "root")
+
+	var got []string
+	for _, e := range entries(t, buf) {
+		got = append(got, e["logger"].(string)+": "+e["message"].(string))
+	}
+	want := []string{"storage: reading", "storage.gcs: fetching", "http: slow", "cache: hit"}
+	if len(got) != len(want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("got %q, want %q", got[i], want[i])
+		}
+	}
+
+	l.Named("storage").SetLevel(LevelInfo)
+	if level := storage.GetLevel(); level != LevelInfo {
+		t.Errorf("got level %v, want the level set on another storage logger", level)
+	}
+	if level := l.GetLevel(); level != LevelInfo {
+		t.Errorf("got root level %v, want it unchanged", level)
+	}
+}
@@ -0,0 +1,149 @@
+package logging
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+)
+
+// Middleware provides a net/http middleware to log HTTP requests as
+// RequestLogger, for the standard library, chi or gorilla services. The route
+// of the requests is set by WithRouteFunc.
+func Middleware(opts ...Option) func(http.Handler) http.Handler {
+	return middleware(nil, opts)
+}
+
+// Middleware provides a net/http middleware to log HTTP requests with l.
+func (l *Logger) Middleware(opts ...Option) func(http.Handler) http.Handler {
+	return middleware(l, opts)
+}
+
+func middleware(logger *Logger, opts []Option) func(http.Handler) http.Handler {
+	o := newMiddlewareOptions(opts)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			l := orStd(logger)
+			// Do nothing if the request is on the blacklist.
+			if o.excludes.match(r.Method, r.URL.EscapedPath()) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			header := r.Header.Get
+			ctx := withHeaderBaggage(withHeaderTrace(r.Context(), header), header)
+			ctx, requestID := withRequestID(ctx, header(requestIDHeader))
+			w.Header().Set(requestIDHeader, requestID)
+			// The route of the handler is only known once it is matched.
+			ctx = l.withForceDebug(withRoute(ctx, "", r.URL.Path), header(debugHeader))
+			r = r.WithContext(withLogger(l.withDebugBuffer(ctx), l))
+			bodyCapture, headerCapture := o.captures(l)
+			var reqBody []byte
+			var reqTruncated bool
+			rw := &responseWriter{ResponseWriter: w}
+			if b := bodyCapture; b != nil {
+				if b.captures(header("Content-Type")) {
+					reqBody, reqTruncated, r.Body = b.captureRequestBody(r.Body)
+				}
+				rw.body = &capturedBody{max: b.maxBytes}
+			}
+			start := o.now(l)
+			next.ServeHTTP(rw, r)
+			duration := o.since(l, start)
+
+			route := ""
+			if o.route != nil {
+				route = o.route(r)
+			}
+			status := rw.statusCode()
+			// Failed requests are always logged.
+			failed := status >= http.StatusInternalServerError
+			if !failed && !o.sample(ctx, l, route, r.URL.Path, status) {
+				return
+			}
+			level, extra := l.slowRequest(l.statusLevel(status), nil, route, r.URL.Path, duration)
+			if h := headerCapture; h != nil {
+				extra = append(extra, h.fields(r.Header, w.Header())...)
+			}
+			if b := bodyCapture; b != nil {
+				if f, ok := b.field(ctx, l, "request_body", header("Content-Type"), reqBody, reqTruncated); ok {
+					extra = append(extra, f)
+				}
+				if f, ok := b.field(ctx, l, "response_body", w.Header().Get("Content-Type"), rw.body.body.Bytes(), rw.body.truncated); ok {
+					extra = append(extra, f)
+				}
+			}
+			// The body has already been handled, don't count it twice.
+			logged := *r
+			logged.Body = nil
+			l.zhttp(ctx,
+				level,
+				&logged,
+				&http.Response{
+					StatusCode:    status,
+					ContentLength: rw.size,
+				},
+				route,
+				duration,
+				extra...,
+			)
+		})
+	}
+}
+
+// responseWriter records the status and size of a response, and captures
+// the beginning of its body if body is set.
+type responseWriter struct {
+	http.ResponseWriter
+	status int
+	size   int64
+	body   *capturedBody
+}
+
+// statusCode returns the status of the response, 200 if the handler didn't
+// write it.
+func (w *responseWriter) statusCode() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	// Informational responses precede the final one.
+	if w.status == 0 && status >= http.StatusOK {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if w.body != nil {
+		w.body.capture(p)
+	}
+	n, err := w.ResponseWriter.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// Flush supports streaming responses.
+func (w *responseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack supports websockets.
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if h, ok := w.ResponseWriter.(http.Hijacker); ok {
+		return h.Hijack()
+	}
+	return nil, nil, errors.New("logging: the response writer doesn't support hijacking")
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
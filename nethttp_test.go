@@ -0,0 +1,88 @@
+package logging
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMiddleware(t *testing.T) {
+	l, buf := newTestLogger(t, &Config{
+		BodyCapture: &BodyCapture{},
+		ClientIP:    &ClientIP{TrustedProxies: []string{"192.0.2.0/24", "10.0.0.0/8"}},
+	})
+	mux := http.NewServeMux()
+	mux.HandleFunc("/users/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Forwarded-For") != "203.0.113.7, 10.0.0.1" {
+			t.Error("the request headers were modified")
+		}
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != `{"name":"a"}` {
+			t.Errorf("handler got body %q", body)
+		}
+		l.Info(r.Context(), "handling")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		io.WriteString(w, `{"id":1}`)
+	})
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {})
+	h := l.Middleware(WithExcludes("/health"), WithRouteFunc(func(r *http.Request) string {
+		if strings.HasPrefix(r.URL.Path, "/users/") {
+			return "/users/{id}"
+		}
+		return ""
+	}))(mux)
+
+	req := httptest.NewRequest("POST", "/users/1", strings.NewReader(`{"name":"a"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Forwarded-For", "203.0.113.7, 10.0.0.1")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/health", nil))
+
+	if w.Code != http.StatusCreated || w.Body.String() != `{"id":1}` {
+		t.Errorf("got response %d %q, want it unchanged", w.Code, w.Body)
+	}
+	requestID := w.Header().Get("X-Request-ID")
+	if requestID == "" {
+		t.Error("got no request ID in the response")
+	}
+	got := entries(t, buf)
+	if len(got) != 2 {
+		t.Fatalf("got %d entries, want 2", len(got))
+	}
+	e := got[1]
+	req2, _ := e["httpRequest"].(map[string]interface{})
+	if req2["status"] != float64(201) || req2["responseSize"] != "8" || req2["requestSize"] != "12" || req2["requestMethod"] != "POST" {
+		t.Errorf("got request %v, want the status and sizes", req2)
+	}
+	labels := labels(e)
+	if labels["route"] != "/users/{id}" || labels["remote_ip"] != "203.0.113.7" || labels["request_id"] != requestID {
+		t.Errorf("got labels %v, want the route, client IP and request ID", labels)
+	}
+	if e["request_body"] != `{"name":"a"}` || e["response_body"] != `{"id":1}` {
+		t.Errorf("got bodies %v and %v", e["request_body"], e["response_body"])
+	}
+	if id := got[0]["logging.googleapis.com/labels"].(map[string]interface{})["request_id"]; id != requestID {
+		t.Errorf("got request ID %v in the handler, want %s", id, requestID)
+	}
+}
+
+func TestResponseWriterStatus(t *testing.T) {
+	for _, tt := range []struct {
+		handler func(w http.ResponseWriter)
+		want    int
+	}{
+		{func(w http.ResponseWriter) {}, 200},
+		{func(w http.ResponseWriter) { io.WriteString(w, "ok") }, 200},
+		{func(w http.ResponseWriter) { w.WriteHeader(103); w.WriteHeader(404) }, 404},
+	} {
+		rw := &responseWriter{ResponseWriter: httptest.NewRecorder()}
+		tt.handler(rw)
+		if got := rw.statusCode(); got != tt.want {
+			t.Errorf("got status %d, want %d", got, tt.want)
+		}
+	}
+}
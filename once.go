@@ -0,0 +1,72 @@
+package logging
+
+import (
+	"runtime"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// callSites records when the call sites of Once and Every last logged.
+type callSites struct {
+	mu   sync.Mutex
+	last map[uintptr]time.Time
+}
+
+// allow reports whether the call site at pc logs at now, at most once per
+// interval or once at all with a zero interval, and records it if so.
+func (s *callSites) allow(pc uintptr, now time.Time, interval time.Duration) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	last, ok := s.last[pc]
+	if ok && (interval <= 0 || now.Sub(last) < interval) {
+		return false
+	}
+	if s.last == nil {
+		s.last = map[uintptr]time.Time{}
+	}
+	s.last[pc] = now
+	return true
+}
+
+// callSite returns the program counter of the caller of the logging
+// function.
+func callSite() uintptr {
+	pc, _, _, _ := runtime.Caller(2)
+	return pc
+}
+
+// Once logs a message of the level the first time its call site is reached,
+// e.g. for deprecation warnings.
+func (l *Logger) Once(ctx context.Context, level Level, format string, args ...interface{}) {
+	if l.callSites.allow(callSite(), l.clock.Now(), 0) {
+		l.zlog(ctx, level, format, args, nil)
+	}
+}
+
+// Every logs a message of the level at most once per interval from its call
+// site.
+func (l *Logger) Every(interval time.Duration, ctx context.Context, level Level, format string, args ...interface{}) {
+	if l.callSites.allow(callSite(), l.clock.Now(), interval) {
+		l.zlog(ctx, level, format, args, nil)
+	}
+}
+
+// Once logs a message of the level the first time its call site is reached,
+// e.g. for deprecation warnings.
+func Once(ctx context.Context, level Level, format string, args ...interface{}) {
+	l := std()
+	if l.callSites.allow(callSite(), l.clock.Now(), 0) {
+		l.zlog(ctx, level, format, args, nil)
+	}
+}
+
+// Every logs a message of the level at most once per interval from its call
+// site.
+func Every(interval time.Duration, ctx context.Context, level Level, format string, args ...interface{}) {
+	l := std()
+	if l.callSites.allow(callSite(), l.clock.Now(), interval) {
+		l.zlog(ctx, level, format, args, nil)
+	}
+}
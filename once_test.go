@@ -0,0 +1,46 @@
+package logging
+
+import (
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestOnce(t *testing.T) {
+	l, buf := newTestLogger(t, &Config{})
+	useStd(t, l)
+	for i := 0; i < 3; i++ {
+		Once(context.Background(), LevelWarn, "deprecated option %d", i)
+		l.Once(context.Background(), LevelInfo, "fallback")
+	}
+	Once(context.Background(), LevelWarn, "other call site")
+
+	got := entries(t, buf)
+	if len(got) != 3 {
+		t.Fatalf("got %d entries, want one per call site: %v", len(got), got)
+	}
+	if got[0]["message"] != "deprecated option 0" || got[0]["severity"] != "WARNING" {
+		t.Errorf("got %v, want the first warning", got[0])
+	}
+	if got[2]["message"] != "other call site" {
+		t.Errorf("got %v, want the other call site logged", got[2])
+	}
+}
+
+func TestEvery(t *testing.T) {
+	clock := &manualClock{now: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)}
+	l, buf := newTestLogger(t, &Config{Clock: clock})
+	for i := 0; i < 6; i++ {
+		l.Every(time.Minute, context.Background(), LevelInfo, "polling %d", i)
+		clock.now = clock.now.Add(20 * time.Second)
+	}
+
+	var got []interface{}
+	for _, e := range entries(t, buf) {
+		got = append(got, e["message"])
+	}
+	if len(got) != 2 || got[0] != "polling 0" || got[1] != "polling 3" {
+		t.Errorf("got %v, want polling 0 and 3", got)
+	}
+}
@@ -0,0 +1,119 @@
+package logging
+
+import (
+	"net/http"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// Option configures the request logging middlewares.
+type Option func(*middlewareOptions)
+
+// Sampler reports whether to keep the access log of a request to the route
+// answered with the status. It is not called for failed requests, which are
+// always logged.
+type Sampler func(ctx context.Context, route string, status int) bool
+
+// middlewareOptions are the options of a request logging middleware.
+type middlewareOptions struct {
+	excludes      requestExcludes
+	bodyCapture   *bodyCapture
+	headerCapture *headerCapture
+	sampler       Sampler
+	route         func(*http.Request) string
+	clock         Clock
+}
+
+func newMiddlewareOptions(opts []Option) *middlewareOptions {
+	o := &middlewareOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithExcludes skips the requests matching the patterns. A pattern is a
+// path, a prefix ending with *, a glob or a regular expression prefixed with
+// ~, optionally preceded by a method, e.g. "GET /healthz*" or "OPTIONS *".
+// It panics if a regular expression is invalid.
+func WithExcludes(patterns ...string) Option {
+	excludes := newRequestExcludes(patterns)
+	return func(o *middlewareOptions) {
+		o.excludes = append(o.excludes, excludes...)
+	}
+}
+
+// WithBodyCapture logs the request and response bodies, overriding
+// Config.BodyCapture.
+func WithBodyCapture(c *BodyCapture) Option {
+	return func(o *middlewareOptions) {
+		o.bodyCapture = newBodyCapture(c)
+	}
+}
+
+// WithHeaderCapture logs request and response headers, overriding
+// Config.HeaderCapture.
+func WithHeaderCapture(c *HeaderCapture) Option {
+	return func(o *middlewareOptions) {
+		o.headerCapture = newHeaderCapture(c)
+	}
+}
+
+// WithSampler keeps only the access logs the sampler selects, in addition to
+// Config.RouteSampling and Config.StatusSampling.
+func WithSampler(s Sampler) Option {
+	return func(o *middlewareOptions) {
+		o.sampler = s
+	}
+}
+
+// WithRouteFunc sets the function returning the route of the requests logged
+// by Middleware once they are handled, e.g. the route pattern of chi.
+func WithRouteFunc(route func(*http.Request) string) Option {
+	return func(o *middlewareOptions) {
+		o.route = route
+	}
+}
+
+// WithClock measures the latency of the requests with the clock, overriding
+// Config.Clock, e.g. to log deterministic latencies in tests.
+func WithClock(clock Clock) Option {
+	return func(o *middlewareOptions) {
+		o.clock = clock
+	}
+}
+
+// now returns the current time by the clock of the middleware with l.
+func (o *middlewareOptions) now(l *Logger) time.Time {
+	if o.clock != nil {
+		return o.clock.Now()
+	}
+	return l.clock.Now()
+}
+
+// since returns the time elapsed since start by the clock of the middleware
+// with l.
+func (o *middlewareOptions) since(l *Logger, start time.Time) time.Duration {
+	return o.now(l).Sub(start)
+}
+
+// captures returns the body and header captures of the middleware with l.
+func (o *middlewareOptions) captures(l *Logger) (*bodyCapture, *headerCapture) {
+	b, h := o.bodyCapture, o.headerCapture
+	if b == nil {
+		b = l.bodyCapture
+	}
+	if h == nil {
+		h = l.headerCapture
+	}
+	return b, h
+}
+
+// sample reports whether the access log of a successful request is kept.
+func (o *middlewareOptions) sample(ctx context.Context, l *Logger, route, urlPath string, status int) bool {
+	if !l.sampleRoute(ctx, route) || !l.sampleStatus(ctx, route, urlPath, status) {
+		return false
+	}
+	return o.sampler == nil || o.sampler(ctx, route, status)
+}
@@ -0,0 +1,80 @@
+package logging
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/context"
+)
+
+func TestRequestLoggerOptions(t *testing.T) {
+	l, buf := newTestLogger(t, &Config{HeaderCapture: &HeaderCapture{Request: []string{"X-Config"}}})
+	var sampled []int
+	r := gin.New()
+	r.Use(l.RequestLogger(
+		WithExcludes("/health"),
+		WithHeaderCapture(&HeaderCapture{Request: []string{"X-Option"}}),
+		WithBodyCapture(&BodyCapture{}),
+		WithSampler(func(_ context.Context, route string, status int) bool {
+			sampled = append(sampled, status)
+			return route != "/dropped"
+		}),
+	))
+	for _, route := range []string{"/health", "/kept", "/dropped", "/failed"} {
+		route := route
+		r.GET(route, func(c *gin.Context) {
+			if route == "/failed" {
+				c.Status(500)
+				return
+			}
+			c.JSON(200, gin.H{"route": route})
+		})
+	}
+	for _, path := range []string{"/health", "/kept", "/dropped", "/failed"} {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("X-Config", "c")
+		req.Header.Set("X-Option", "o")
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	got := entries(t, buf)
+	if len(got) != 2 {
+		t.Fatalf("got %d entries, want 2", len(got))
+	}
+	if len(sampled) != 2 {
+		t.Errorf("sampled statuses %v, want the successful requests only", sampled)
+	}
+	if route := labels(got[0])["route"]; route != "/kept" {
+		t.Errorf("got route %v, want /kept", route)
+	}
+	if h, _ := got[0]["request_headers"].(map[string]interface{}); len(h) != 1 || h["X-Option"] != "o" {
+		t.Errorf("got request headers %v, want the headers of the option", h)
+	}
+	if body := got[0]["response_body"]; body != `{"route":"/kept"}` {
+		t.Errorf("got response body %v, want it captured", body)
+	}
+	if route := labels(got[1])["route"]; route != "/failed" {
+		t.Errorf("got route %v, want the failed request logged", route)
+	}
+}
+
+func TestWithClock(t *testing.T) {
+	l, buf := newTestLogger(t, &Config{})
+	clock := &manualClock{now: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)}
+	r := gin.New()
+	r.Use(l.RequestLogger(WithClock(clock)))
+	r.GET("/users", func(c *gin.Context) {
+		clock.now = clock.now.Add(250 * time.Millisecond)
+	})
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users", nil))
+
+	got := entries(t, buf)
+	if len(got) != 1 {
+		t.Fatalf("got %d entries, want 1", len(got))
+	}
+	if req, _ := got[0]["httpRequest"].(map[string]interface{}); req["latency"] != "250ms" {
+		t.Errorf("got latency %v, want 250ms", req["latency"])
+	}
+}
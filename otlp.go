@@ -0,0 +1,213 @@
+package logging
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploghttp"
+	otellog "go.opentelemetry.io/otel/log"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"golang.org/x/net/context"
+)
+
+// otelScope is the instrumentation scope of the OpenTelemetry records.
+const otelScope = "github.com/cyoyu/logging"
+
+// OTLPOutput exports the entries as OpenTelemetry log records over OTLP/HTTP,
+// correlated with the trace of their context.
+type OTLPOutput struct {
+	// Endpoint is the URL of the collector, e.g. http://collector:4318. It
+	// defaults to the OTEL_EXPORTER_OTLP_ENDPOINT environment variable.
+	Endpoint string            `json:"endpoint" yaml:"endpoint"`
+	Headers  map[string]string `json:"headers" yaml:"headers"`
+}
+
+// contextKeyField is the key of the field carrying the context of an entry.
+const contextKeyField = "context"
+
+// contextField returns a field carrying ctx to the cores that need it, which
+// encoders skip.
+func contextField(ctx context.Context) zapcore.Field {
+	return zap.Field{Key: contextKeyField, Type: zapcore.SkipType, Interface: ctx}
+}
+
+// otelCore is a zapcore.Core emitting entries to an OpenTelemetry logger.
+type otelCore struct {
+	zapcore.LevelEnabler
+	logger otellog.Logger
+	fields []zapcore.Field
+	flush  func() error
+}
+
+// otelCore returns a core emitting the entries to the logger provider, or to
+// one exporting over OTLP/HTTP, and the function shutting it down on close.
+func (l *Logger) otelCore(out *OTLPOutput, provider otellog.LoggerProvider) (zapcore.Core, func(), error) {
+	core := &otelCore{LevelEnabler: l.coreLevel}
+	closeCore := func() {}
+	if provider == nil {
+		var opts []otlploghttp.Option
+		if out.Endpoint != "" {
+			opts = append(opts, otlploghttp.WithEndpointURL(out.Endpoint))
+		}
+		if len(out.Headers) > 0 {
+			opts = append(opts, otlploghttp.WithHeaders(out.Headers))
+		}
+		exporter, err := otlploghttp.New(context.Background(), opts...)
+		if err != nil {
+			return nil, nil, err
+		}
+		sdkProvider := sdklog.NewLoggerProvider(sdklog.WithProcessor(sdklog.NewBatchProcessor(exporter)))
+		core.flush = func() error { return sdkProvider.ForceFlush(context.Background()) }
+		closeCore = func() { sdkProvider.Shutdown(context.Background()) }
+		provider = sdkProvider
+	} else if flusher, ok := provider.(interface{ ForceFlush(context.Context) error }); ok {
+		core.flush = func() error { return flusher.ForceFlush(context.Background()) }
+	}
+	core.logger = provider.Logger(otelScope)
+	return core, closeCore, nil
+}
+
+func (c *otelCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := *c
+	clone.fields = append(c.fields[:len(c.fields):len(c.fields)], fields...)
+	return &clone
+}
+
+func (c *otelCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *otelCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	if !c.Enabled(ent.Level) {
+		return nil
+	}
+	ctx := context.Background()
+	m := zapcore.NewMapObjectEncoder()
+	for _, fieldSet := range [][]zapcore.Field{c.fields, fields} {
+		for _, f := range fieldSet {
+			if entryCtx, ok := f.Interface.(context.Context); ok && f.Type == zapcore.SkipType {
+				ctx = entryCtx
+				continue
+			}
+			if f.Key == zapdriverLabelsKey || strings.HasPrefix(f.Key, "labels.") {
+				continue
+			}
+			f.AddTo(m)
+		}
+	}
+
+	var r otellog.Record
+	r.SetTimestamp(ent.Time)
+	r.SetSeverity(otelSeverity(ent.Level))
+	r.SetSeverityText(levelFromZap(ent.Level).String())
+	r.SetBody(otellog.StringValue(ent.Message))
+	for k, v := range entryLabels(c.fields, fields) {
+		r.AddAttributes(otellog.String(k, v))
+	}
+	keys := make([]string, 0, len(m.Fields))
+	for k := range m.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		// The trace is carried by the record itself.
+		if strings.HasPrefix(k, zapdriverPrefix) {
+			continue
+		}
+		r.AddAttributes(otellog.KeyValue{Key: k, Value: otelValue(m.Fields[k])})
+	}
+	if ent.Stack != "" {
+		r.AddAttributes(otellog.String(keyStackTrace, ent.Stack))
+	}
+	c.logger.Emit(ctx, r)
+	return nil
+}
+
+func (c *otelCore) Sync() error {
+	if c.flush == nil {
+		return nil
+	}
+	return c.flush()
+}
+
+// otelSeverity maps zap levels to OpenTelemetry severities.
+func otelSeverity(level zapcore.Level) otellog.Severity {
+	switch level {
+	case zapcore.DebugLevel:
+		return otellog.SeverityDebug
+	case zapcore.InfoLevel:
+		return otellog.SeverityInfo
+	case zapcore.WarnLevel:
+		return otellog.SeverityWarn
+	case zapcore.ErrorLevel:
+		return otellog.SeverityError
+	case zapcore.DPanicLevel:
+		return otellog.SeverityFatal1
+	case zapcore.PanicLevel:
+		return otellog.SeverityFatal2
+	default:
+		return otellog.SeverityFatal4
+	}
+}
+
+// otelValue converts a value encoded by zapcore.MapObjectEncoder.
+func otelValue(v interface{}) otellog.Value {
+	switch v := v.(type) {
+	case string:
+		return otellog.StringValue(v)
+	case bool:
+		return otellog.BoolValue(v)
+	case int:
+		return otellog.IntValue(v)
+	case int8:
+		return otellog.Int64Value(int64(v))
+	case int16:
+		return otellog.Int64Value(int64(v))
+	case int32:
+		return otellog.Int64Value(int64(v))
+	case int64:
+		return otellog.Int64Value(v)
+	case uint:
+		return otellog.Int64Value(int64(v))
+	case uint8:
+		return otellog.Int64Value(int64(v))
+	case uint16:
+		return otellog.Int64Value(int64(v))
+	case uint32:
+		return otellog.Int64Value(int64(v))
+	case uint64:
+		return otellog.Int64Value(int64(v))
+	case float32:
+		return otellog.Float64Value(float64(v))
+	case float64:
+		return otellog.Float64Value(v)
+	case []byte:
+		return otellog.BytesValue(v)
+	case time.Time:
+		return otellog.StringValue(v.Format(time.RFC3339Nano))
+	case time.Duration:
+		return otellog.StringValue(v.String())
+	case map[string]interface{}:
+		kvs := make([]otellog.KeyValue, 0, len(v))
+		for k, e := range v {
+			kvs = append(kvs, otellog.KeyValue{Key: k, Value: otelValue(e)})
+		}
+		sort.Slice(kvs, func(i, j int) bool { return kvs[i].Key < kvs[j].Key })
+		return otellog.MapValue(kvs...)
+	case []interface{}:
+		values := make([]otellog.Value, len(v))
+		for i, e := range v {
+			values[i] = otelValue(e)
+		}
+		return otellog.SliceValue(values...)
+	default:
+		return otellog.StringValue(fmt.Sprintf("%+v", v))
+	}
+}
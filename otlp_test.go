@@ -0,0 +1,68 @@
+package logging
+
+import (
+	"sync"
+	"testing"
+
+	otellog "go.opentelemetry.io/otel/log"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/net/context"
+)
+
+// memoryExporter is an sdklog.Exporter keeping the records in memory.
+type memoryExporter struct {
+	mu      sync.Mutex
+	records []sdklog.Record
+}
+
+func (e *memoryExporter) Export(ctx context.Context, records []sdklog.Record) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, r := range records {
+		e.records = append(e.records, r.Clone())
+	}
+	return nil
+}
+
+func (e *memoryExporter) ForceFlush(context.Context) error { return nil }
+func (e *memoryExporter) Shutdown(context.Context) error   { return nil }
+
+func TestOTLPLoggerProvider(t *testing.T) {
+	exporter := &memoryExporter{}
+	provider := sdklog.NewLoggerProvider(sdklog.WithProcessor(sdklog.NewSimpleProcessor(exporter)))
+	l, err := New(&Config{ProjectID: "test", Level: LevelDebug, LoggerProvider: provider})
+	if err != nil {
+		t.Fatal(err)
+	}
+	traceID := trace.TraceID{1, 2}
+	spanID := trace.SpanID{3}
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: spanID}))
+	l.Errorw(WithUserID(ctx, "u1"), "failed", Int("attempt", 2))
+	l.Sync()
+
+	if len(exporter.records) != 1 {
+		t.Fatalf("got %d records, want 1", len(exporter.records))
+	}
+	r := exporter.records[0]
+	if r.Body().AsString() != "failed" || r.Severity() != otellog.SeverityError {
+		t.Errorf("got %q at %v, want failed at error", r.Body().AsString(), r.Severity())
+	}
+	if r.TraceID() != traceID || r.SpanID() != spanID {
+		t.Errorf("got trace %s/%s, want %s/%s", r.TraceID(), r.SpanID(), traceID, spanID)
+	}
+	attrs := map[string]otellog.Value{}
+	r.WalkAttributes(func(kv otellog.KeyValue) bool {
+		attrs[kv.Key] = kv.Value
+		return true
+	})
+	if attrs["user_id"].AsString() != "u1" {
+		t.Errorf("got user_id %v, want u1", attrs["user_id"])
+	}
+	if attrs["attempt"].AsInt64() != 2 {
+		t.Errorf("got attempt %v, want 2", attrs["attempt"])
+	}
+	if _, ok := attrs["logging.googleapis.com/trace"]; ok {
+		t.Error("got the Stackdriver trace as an attribute")
+	}
+}
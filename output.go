@@ -0,0 +1,267 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// Output is a destination of log entries.
+type Output struct {
+	// Path is "stdout", "stderr" or the path of a file.
+	Path string `json:"path" yaml:"path"`
+	// Format is "json", "console" or one of the formats of Config.Format. It
+	// defaults to the format of the logger.
+	Format string `json:"format" yaml:"format"`
+	// Level restricts the entries written to the output to those at or above
+	// the level, on top of the level of the logger. Zero writes all of them.
+	Level Level `json:"level" yaml:"level"`
+}
+
+// FileOutput is a log file rotated once it reaches MaxSizeMB megabytes.
+type FileOutput struct {
+	Path string `json:"path" yaml:"path"`
+	// MaxSizeMB defaults to 100 megabytes.
+	MaxSizeMB int `json:"max_size_mb" yaml:"max_size_mb"`
+	// MaxBackups and MaxAgeDays limit the rotated files kept, zero keeps
+	// them all.
+	MaxBackups int  `json:"max_backups" yaml:"max_backups"`
+	MaxAgeDays int  `json:"max_age_days" yaml:"max_age_days"`
+	Compress   bool `json:"compress" yaml:"compress"`
+}
+
+// outputsCore returns a core writing to the configured outputs, encoded with
+// the encoder configuration of config.
+func (l *Logger) outputsCore(config zap.Config, c *Config) (zapcore.Core, error) {
+	var cores []zapcore.Core
+	if c.Async != nil {
+		if err := c.Async.validate(); err != nil {
+			return nil, err
+		}
+	}
+	if c.Output != nil {
+		enc, err := newEncoder(config.Encoding, config.EncoderConfig)
+		if err != nil {
+			return nil, err
+		}
+		cores = append(cores, zapcore.NewCore(enc, l.asyncSink(zapcore.Lock(zapcore.AddSync(c.Output)), c.Async), l.coreLevel))
+	}
+	if c.SplitStreams {
+		cfg := config.EncoderConfig
+		if l.projectID == "" && config.Encoding != "json" {
+			cfg.EncodeLevel = zapcore.CapitalColorLevelEncoder
+		}
+		enc, err := newEncoder(config.Encoding, cfg)
+		if err != nil {
+			return nil, err
+		}
+		belowWarn := zap.LevelEnablerFunc(func(lvl zapcore.Level) bool {
+			return lvl < zapcore.WarnLevel && l.coreLevel.Enabled(lvl)
+		})
+		cores = append(cores,
+			levelCore{zapcore.NewCore(enc, l.asyncSink(zapcore.Lock(os.Stdout), c.Async), belowWarn)},
+			levelCore{zapcore.NewCore(enc.Clone(), l.asyncSink(zapcore.Lock(os.Stderr), c.Async), l.outputLevel(LevelWarn))},
+		)
+	}
+	if c.File != nil {
+		enc, err := newEncoder(config.Encoding, config.EncoderConfig)
+		if err != nil {
+			return nil, err
+		}
+		file := &lumberjack.Logger{
+			Filename:   c.File.Path,
+			MaxSize:    c.File.MaxSizeMB,
+			MaxBackups: c.File.MaxBackups,
+			MaxAge:     c.File.MaxAgeDays,
+			Compress:   c.File.Compress,
+		}
+		sink := l.asyncSink(zapcore.AddSync(file), c.Async)
+		l.closers = append(l.closers, func() { file.Close() })
+		cores = append(cores, zapcore.NewCore(enc, sink, l.coreLevel))
+	}
+	if c.Syslog != nil {
+		enc := zapcore.NewJSONEncoder(plainEncoderConfig(config.EncoderConfig))
+		core, err := newSyslogCore(c.Syslog, enc, l.coreLevel)
+		if err != nil {
+			return nil, err
+		}
+		l.closers = append(l.closers, core.w.close)
+		cores = append(cores, core)
+	}
+	if c.Loki != nil {
+		core, closeCore := l.lokiCore(c.Loki, zapcore.NewJSONEncoder(plainEncoderConfig(config.EncoderConfig)))
+		l.closers = append(l.closers, closeCore)
+		cores = append(cores, core)
+	}
+	if c.GELF != nil {
+		core, w, err := l.gelfCore(c.GELF, plainEncoderConfig(config.EncoderConfig))
+		if err != nil {
+			return nil, err
+		}
+		l.closers = append(l.closers, w.close)
+		cores = append(cores, core)
+	}
+	if c.Fluentd != nil {
+		core, closeCore, err := l.fluentdCore(c.Fluentd, zapcore.NewJSONEncoder(plainEncoderConfig(config.EncoderConfig)))
+		if err != nil {
+			return nil, err
+		}
+		l.closers = append(l.closers, closeCore)
+		cores = append(cores, core)
+	}
+	if c.OTLP != nil || c.LoggerProvider != nil {
+		out := c.OTLP
+		if out == nil {
+			out = &OTLPOutput{}
+		}
+		core, closeCore, err := l.otelCore(out, c.LoggerProvider)
+		if err != nil {
+			return nil, err
+		}
+		l.closers = append(l.closers, closeCore)
+		cores = append(cores, core)
+	}
+	sinkCores, err := l.sinkCores(c.Sinks, config.EncoderConfig)
+	if err != nil {
+		return nil, err
+	}
+	cores = append(cores, sinkCores...)
+	for _, out := range c.Outputs {
+		format := out.Format
+		if format == "" {
+			format = config.Encoding
+		}
+		cfg := config.EncoderConfig
+		if l.projectID == "" && format != "json" && (out.Path == "stdout" || out.Path == "stderr") {
+			cfg.EncodeLevel = zapcore.CapitalColorLevelEncoder
+		}
+		enc, err := newEncoder(format, cfg)
+		if err != nil {
+			return nil, err
+		}
+		sink, closeSink, err := zap.Open(out.Path)
+		if err != nil {
+			return nil, err
+		}
+		queued := l.asyncSink(sink, c.Async)
+		l.closers = append(l.closers, closeSink)
+		cores = append(cores, levelCore{zapcore.NewCore(enc, queued, l.outputLevel(out.Level))})
+	}
+	if !c.hasOutputs() {
+		// The standard error output is only queued.
+		cfg := config.EncoderConfig
+		if l.projectID == "" && config.Encoding != "json" {
+			cfg.EncodeLevel = zapcore.CapitalColorLevelEncoder
+		}
+		enc, err := newEncoder(config.Encoding, cfg)
+		if err != nil {
+			return nil, err
+		}
+		stderr := zapcore.Lock(zapcore.AddSync(struct{ io.Writer }{os.Stderr}))
+		cores = append(cores, zapcore.NewCore(enc, l.asyncSink(stderr, c.Async), l.coreLevel))
+	}
+	// The entries an output fails to write aren't dropped.
+	fallbackEnc, err := newEncoder(config.Encoding, config.EncoderConfig)
+	if err != nil {
+		return nil, err
+	}
+	for i, core := range cores {
+		cores[i] = newFallbackCore(core, fallbackEnc)
+	}
+	return zapcore.NewTee(cores...), nil
+}
+
+// outputLevel returns the level enabler of an output restricted to level.
+func (l *Logger) outputLevel(level Level) zapcore.LevelEnabler {
+	if level == LevelFirst {
+		return l.coreLevel
+	}
+	min := level.zapLevel()
+	return zap.LevelEnablerFunc(func(lvl zapcore.Level) bool {
+		return lvl >= min && l.coreLevel.Enabled(lvl)
+	})
+}
+
+// levelCore checks the level of the entries it writes. The zapdriver core
+// writes to the wrapped core without checking the entries first.
+type levelCore struct {
+	zapcore.Core
+}
+
+func (c levelCore) With(fields []zapcore.Field) zapcore.Core {
+	return levelCore{c.Core.With(fields)}
+}
+
+func (c levelCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c levelCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	if !c.Enabled(ent.Level) {
+		return nil
+	}
+	return c.Core.Write(ent, fields)
+}
+
+func newEncoder(format string, cfg zapcore.EncoderConfig) (zapcore.Encoder, error) {
+	switch format {
+	case "json":
+		return zapcore.NewJSONEncoder(cfg), nil
+	case "console":
+		return zapcore.NewConsoleEncoder(cfg), nil
+	case prettyEncoding:
+		return newPrettyEncoder(cfg), nil
+	default:
+		if layout, ok := formatLayouts[format]; ok {
+			return newMappedEncoder(cfg, layout), nil
+		}
+		return nil, fmt.Errorf("logging: unknown output format %q", format)
+	}
+}
+
+// withOutputs appends to opts an option replacing the core built from config
+// with the configured core or outputs, if any.
+func (l *Logger) withOutputs(opts []zap.Option, config zap.Config, c *Config) ([]zap.Option, error) {
+	if c == nil {
+		return opts, nil
+	}
+	core := c.Core
+	if core == nil && (c.hasOutputs() || c.Async != nil) {
+		var err error
+		if core, err = l.outputsCore(config, c); err != nil {
+			return nil, err
+		}
+	}
+	// The cores below are written to in addition to the outputs.
+	var extra []zapcore.Core
+	if c.PubSub != nil {
+		pubsubCore, closeCore, err := l.pubsubCore(c.PubSub, zapcore.NewJSONEncoder(plainEncoderConfig(config.EncoderConfig)))
+		if err != nil {
+			return nil, err
+		}
+		l.closers = append(l.closers, closeCore)
+		extra = append(extra, pubsubCore)
+	}
+	if core == nil && len(extra) == 0 {
+		return opts, nil
+	}
+	return append(opts, zap.WrapCore(func(base zapcore.Core) zapcore.Core {
+		if core != nil {
+			base = core
+		}
+		return zapcore.NewTee(append([]zapcore.Core{base}, extra...)...)
+	})), nil
+}
+
+// hasOutputs reports whether c replaces the standard error output.
+func (c *Config) hasOutputs() bool {
+	return c.Output != nil || c.SplitStreams || c.File != nil || c.Syslog != nil || c.Loki != nil || c.GELF != nil ||
+		c.Fluentd != nil || c.OTLP != nil || c.LoggerProvider != nil || len(c.Sinks) > 0 || len(c.Outputs) > 0
+}
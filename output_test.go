@@ -0,0 +1,173 @@
+package logging
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+	"golang.org/x/net/context"
+)
+
+func TestOutputsWithoutColors(t *testing.T) {
+	dir := t.TempDir()
+	jsonPath := filepath.Join(dir, "json.log")
+	consolePath := filepath.Join(dir, "console.log")
+	rotatedPath := filepath.Join(dir, "rotated.log")
+	l, err := New(&Config{
+		Level: LevelDebug,
+		Outputs: []Output{
+			{Path: jsonPath, Format: "json"},
+			{Path: consolePath, Format: "console", Level: LevelWarn},
+		},
+		File: &FileOutput{Path: rotatedPath},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Info(context.Background(), "started")
+	l.Error(context.Background(), "failed")
+	l.Close()
+
+	for path, want := range map[string][]string{
+		jsonPath:    {`"L":"INFO"`, `"L":"ERROR"`},
+		consolePath: {"\tERROR\t"},
+		rotatedPath: {"\tINFO\t", "\tERROR\t"},
+	} {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out := string(data)
+		for _, w := range want {
+			if !strings.Contains(out, w) {
+				t.Errorf("%s: output %q lacks %q", filepath.Base(path), out, w)
+			}
+		}
+		if strings.Contains(out, "\x1b") {
+			t.Errorf("%s: output %q contains color escapes", filepath.Base(path), out)
+		}
+	}
+	if data, _ := os.ReadFile(consolePath); strings.Contains(string(data), "started") {
+		t.Errorf("console output %q has entries below its level", data)
+	}
+}
+
+func TestOutputWriterConcurrency(t *testing.T) {
+	l, buf := newTestLogger(t, &Config{})
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			l.Info(context.Background(), "concurrent")
+		}()
+	}
+	wg.Wait()
+	if got := entries(t, buf); len(got) != 50 {
+		t.Errorf("got %d entries, want 50", len(got))
+	}
+}
+
+func TestSplitStreams(t *testing.T) {
+	dir := t.TempDir()
+	stdout, err := os.Create(filepath.Join(dir, "stdout"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stderr, err := os.Create(filepath.Join(dir, "stderr"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldStdout, oldStderr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = stdout, stderr
+	l, err := New(&Config{ProjectID: "test", Level: LevelDebug, SplitStreams: true})
+	os.Stdout, os.Stderr = oldStdout, oldStderr
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Debug(context.Background(), "debugging")
+	l.Info(context.Background(), "started")
+	l.Warn(context.Background(), "slow")
+	l.Error(context.Background(), "failed")
+	l.Close()
+
+	for path, want := range map[string][]string{
+		stdout.Name(): {"debugging", "started"},
+		stderr.Name(): {"slow", "failed"},
+	} {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, e := range entries(t, bytes.NewBuffer(data)) {
+			got = append(got, e["message"].(string))
+		}
+		if strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Errorf("%s: got %q, want %q", filepath.Base(path), got, want)
+		}
+	}
+}
+
+// closingSink records whether it was closed.
+type closingSink struct {
+	closed bool
+}
+
+func (s *closingSink) Open(*SinkContext) (zapcore.Core, func(), error) {
+	return zapcore.NewNopCore(), func() { s.closed = true }, nil
+}
+
+func TestOutputFailureClosesOpened(t *testing.T) {
+	sink := &closingSink{}
+	_, err := New(&Config{
+		ProjectID: "test",
+		Sinks:     []Sink{sink},
+		Outputs:   []Output{{Path: filepath.Join(t.TempDir(), "missing", "app.log")}},
+	})
+	if err == nil {
+		t.Fatal("got no error opening a file in a missing directory")
+	}
+	if !sink.closed {
+		t.Error("got the sink opened before the error left open, want closed")
+	}
+}
+
+func TestFileRotation(t *testing.T) {
+	dir := t.TempDir()
+	l, err := New(&Config{
+		ProjectID: "test",
+		Level:     LevelInfo,
+		File:      &FileOutput{Path: filepath.Join(dir, "app.log"), MaxSizeMB: 1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := strings.Repeat("x", 1000)
+	for i := 0; i < 1100; i++ {
+		// Distinct messages aren't sampled.
+		l.Info(context.Background(), "%d %s", i, msg)
+	}
+	l.Close()
+
+	files, err := filepath.Glob(filepath.Join(dir, "app*.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("got files %q, want the log file and a rotated one", files)
+	}
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Size() > 1<<20 {
+			t.Errorf("%s: got %d bytes, want at most a megabyte", filepath.Base(file), info.Size())
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+// prettyEncoding is the zap encoding name of the pretty console encoder.
+const prettyEncoding = "logging-pretty"
+
+const (
+	colorKey   = "\x1b[36m"
+	colorReset = "\x1b[0m"
+)
+
+func init() {
+	err := zap.RegisterEncoder(prettyEncoding, func(cfg zapcore.EncoderConfig) (zapcore.Encoder, error) {
+		return newPrettyEncoder(cfg), nil
+	})
+	if err != nil {
+		panic(err)
+	}
+}
+
+// prettyEncoder renders the entry header like the console encoder and the
+// fields as colorized key=value pairs. It is only meant for local
+// development, never for machine-parsed output.
+type prettyEncoder struct {
+	*zapcore.MapObjectEncoder
+	base zapcore.Encoder
+	cfg  zapcore.EncoderConfig
+}
+
+func newPrettyEncoder(cfg zapcore.EncoderConfig) *prettyEncoder {
+	baseCfg := cfg
+	baseCfg.StacktraceKey = ""
+	return &prettyEncoder{
+		MapObjectEncoder: zapcore.NewMapObjectEncoder(),
+		base:             zapcore.NewConsoleEncoder(baseCfg),
+		cfg:              cfg,
+	}
+}
+
+func (e *prettyEncoder) Clone() zapcore.Encoder {
+	clone := &prettyEncoder{
+		MapObjectEncoder: zapcore.NewMapObjectEncoder(),
+		base:             e.base.Clone(),
+		cfg:              e.cfg,
+	}
+	for k, v := range e.Fields {
+		clone.Fields[k] = v
+	}
+	return clone
+}
+
+func (e *prettyEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	m := zapcore.NewMapObjectEncoder()
+	for k, v := range e.Fields {
+		m.Fields[k] = v
+	}
+	for _, f := range fields {
+		f.AddTo(m)
+	}
+
+	line, err := e.base.EncodeEntry(ent, nil)
+	if err != nil {
+		return nil, err
+	}
+	line.TrimNewline()
+
+	keys := make([]string, 0, len(m.Fields))
+	for k := range m.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		line.AppendString("  ")
+		line.AppendString(colorKey)
+		line.AppendString(k)
+		line.AppendString(colorReset)
+		line.AppendByte('=')
+		line.AppendString(prettyValue(m.Fields[k]))
+	}
+
+	lineEnding := e.cfg.LineEnding
+	if lineEnding == "" {
+		lineEnding = zapcore.DefaultLineEnding
+	}
+	line.AppendString(lineEnding)
+	if ent.Stack != "" && e.cfg.StacktraceKey != "" {
+		line.AppendString(ent.Stack)
+		line.AppendString(lineEnding)
+	}
+	return line, nil
+}
+
+func prettyValue(v interface{}) string {
+	if s, ok := v.(string); ok {
+		if strings.ContainsAny(s, " \t\n\"=") {
+			return fmt.Sprintf("%q", s)
+		}
+		return s
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%+v", v)
+	}
+	return string(b)
+}
@@ -0,0 +1,45 @@
+package logging
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/context"
+)
+
+func TestPrettyConsole(t *testing.T) {
+	buf := &bytes.Buffer{}
+	l, err := New(&Config{Level: LevelDebug, PrettyConsole: true, Output: buf})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Infow(context.Background(), "hello", "k", "some value", Int("n", 3))
+
+	out := buf.String()
+	for _, want := range []string{
+		"\thello  ",
+		colorKey + "labels.k" + colorReset + `="some value"`,
+		colorKey + "n" + colorReset + "=3",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output %q lacks %q", out, want)
+		}
+	}
+}
+
+func TestPrettyConsoleKeepsJSON(t *testing.T) {
+	l, buf := newTestLogger(t, &Config{PrettyConsole: true})
+	l.Infow(context.Background(), "hello", "k", "some value")
+
+	got := entries(t, buf)
+	if len(got) != 1 {
+		t.Fatalf("got %d entries, want 1", len(got))
+	}
+	if msg := got[0]["message"]; msg != "hello" {
+		t.Errorf("got message %v, want hello", msg)
+	}
+	if k := labels(got[0])["k"]; k != "some value" {
+		t.Errorf("got label %v, want some value", k)
+	}
+}
@@ -0,0 +1,59 @@
+// Package prommetrics counts the entries of the logging package with
+// Prometheus.
+package prommetrics
+
+import (
+	"github.com/cyoyu/logging"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Collector is a prometheus.Collector counting log entries by level and, for
+// request logs, by route, and the entries the outputs failed to write.
+type Collector struct {
+	entries *prometheus.CounterVec
+	// outputErrors counts the entries the outputs failed to write.
+	outputErrors prometheus.CounterFunc
+}
+
+// New returns a Collector. Its Hook method must be registered with
+// logging.RegisterHook to count entries.
+func New() *Collector {
+	return &Collector{
+		entries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "log_entries_total",
+			Help: "Number of log entries by level and request route.",
+		}, []string{"level", "route"}),
+		outputErrors: prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name: "log_output_errors_total",
+			Help: "Number of log entries and flushes the outputs failed to write.",
+		}, func() float64 { return float64(logging.OutputErrors()) }),
+	}
+}
+
+// Register registers a new Collector with reg and hooks it to the package
+// level logger.
+func Register(reg prometheus.Registerer) (*Collector, error) {
+	c := New()
+	if err := reg.Register(c); err != nil {
+		return nil, err
+	}
+	logging.RegisterHook(c.Hook)
+	return c, nil
+}
+
+// Hook counts the entry.
+func (c *Collector) Hook(e logging.Entry) {
+	c.entries.WithLabelValues(e.Level.String(), e.Route).Inc()
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	c.entries.Describe(ch)
+	c.outputErrors.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.entries.Collect(ch)
+	c.outputErrors.Collect(ch)
+}
@@ -0,0 +1,62 @@
+package prommetrics
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/cyoyu/logging"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"golang.org/x/net/context"
+)
+
+// failingWriter fails all the writes.
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestCollector(t *testing.T) {
+	c := New()
+	reg := prometheus.NewPedanticRegistry()
+	if err := reg.Register(c); err != nil {
+		t.Fatal(err)
+	}
+	l, err := logging.New(&logging.Config{ProjectID: "test", Level: logging.LevelDebug, Output: io.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.RegisterHook(c.Hook)
+
+	ctx := context.Background()
+	l.Info(ctx, "started")
+	l.Info(ctx, "ready")
+	l.Error(ctx, "failed")
+	if got := testutil.ToFloat64(c.entries.WithLabelValues("info", "")); got != 2 {
+		t.Errorf("got %v info entries, want 2", got)
+	}
+	if got := testutil.ToFloat64(c.entries.WithLabelValues("error", "")); got != 1 {
+		t.Errorf("got %v error entries, want 1", got)
+	}
+	if got := testutil.CollectAndCount(c, "log_entries_total"); got != 2 {
+		t.Errorf("got %d entry series, want 2", got)
+	}
+	if problems, err := testutil.CollectAndLint(c); err != nil || len(problems) > 0 {
+		t.Errorf("got lint problems %v, %v", problems, err)
+	}
+}
+
+func TestCollectorOutputErrors(t *testing.T) {
+	c := New()
+	l, err := logging.New(&logging.Config{ProjectID: "test", Level: logging.LevelDebug, Output: failingWriter{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := testutil.ToFloat64(c.outputErrors)
+	l.Info(context.Background(), "lost")
+	if got := testutil.ToFloat64(c.outputErrors) - before; got != 1 {
+		t.Errorf("got %v more output errors, want 1", got)
+	}
+}
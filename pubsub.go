@@ -0,0 +1,103 @@
+package logging
+
+import (
+	"time"
+
+	"cloud.google.com/go/pubsub"
+	"go.uber.org/zap/zapcore"
+	"golang.org/x/net/context"
+	"google.golang.org/api/option"
+)
+
+// PubSubOutput publishes selected entries to a Pub/Sub topic in batches, in
+// addition to the other outputs.
+type PubSubOutput struct {
+	// ProjectID is the project of the topic, it defaults to the project of
+	// the configuration.
+	ProjectID string `json:"project_id" yaml:"project_id"`
+	Topic     string `json:"topic" yaml:"topic"`
+	// Level is the minimum level of the entries published, it defaults to
+	// LevelWarn.
+	Level Level `json:"level" yaml:"level"`
+	// Labels selects the entries with these labels whatever their level,
+	// e.g. {"stream": "audit"}.
+	Labels map[string]string `json:"labels" yaml:"labels"`
+	// BatchSize and BatchInterval default to 100 entries and a second.
+	BatchSize     int           `json:"batch_size" yaml:"batch_size"`
+	BatchInterval time.Duration `json:"batch_interval" yaml:"batch_interval"`
+	// Options configure the Pub/Sub client.
+	Options []option.ClientOption `json:"-" yaml:"-"`
+}
+
+// selects reports whether the entry is published.
+func (out *PubSubOutput) selects(level zapcore.Level, labels map[string]string) bool {
+	minLevel := out.Level
+	if minLevel == LevelFirst {
+		minLevel = LevelWarn
+	}
+	if minLevel.zapLevel() <= level {
+		return true
+	}
+	if len(out.Labels) == 0 {
+		return false
+	}
+	for k, v := range out.Labels {
+		if labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// pubsubCore returns a core publishing the selected entries to Pub/Sub, and
+// the function flushing it on close.
+func (l *Logger) pubsubCore(out *PubSubOutput, enc zapcore.Encoder) (zapcore.Core, func(), error) {
+	projectID := out.ProjectID
+	if projectID == "" {
+		projectID = l.projectID
+	}
+	client, err := pubsub.NewClient(context.Background(), projectID, out.Options...)
+	if err != nil {
+		return nil, nil, err
+	}
+	topic := client.Topic(out.Topic)
+	// The entries are already batched.
+	topic.PublishSettings.DelayThreshold = 10 * time.Millisecond
+	// The entries lost are only counted, being written to the other outputs
+	// too.
+	b := newBatcher(out.BatchSize, out.BatchInterval, func(msgs []*pubsub.Message) error {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		results := make([]*pubsub.PublishResult, len(msgs))
+		for i, msg := range msgs {
+			results[i] = topic.Publish(ctx, msg)
+		}
+		for _, res := range results {
+			if _, err := res.Get(ctx); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, nil)
+	core := &sinkCore{
+		LevelEnabler: l.coreLevel,
+		enc:          enc,
+		write: func(ent zapcore.Entry, labels map[string]string, line []byte) error {
+			if !out.selects(ent.Level, labels) {
+				return nil
+			}
+			attrs := map[string]string{"level": levelFromZap(ent.Level).String()}
+			if requestID := labels[l.keyRequestID]; validRequestID(requestID) {
+				attrs[l.keyRequestID] = requestID
+			}
+			b.add(&pubsub.Message{Data: line, Attributes: attrs})
+			return nil
+		},
+		sync: b.flush,
+	}
+	return core, func() {
+		b.close()
+		topic.Stop()
+		client.Close()
+	}, nil
+}
@@ -0,0 +1,73 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"cloud.google.com/go/pubsub"
+	"cloud.google.com/go/pubsub/pstest"
+	"golang.org/x/net/context"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+func TestPubSub(t *testing.T) {
+	srv := pstest.NewServer()
+	defer srv.Close()
+	opts := []option.ClientOption{
+		option.WithEndpoint(srv.Addr),
+		option.WithoutAuthentication(),
+		option.WithGRPCDialOption(grpc.WithTransportCredentials(insecure.NewCredentials())),
+	}
+	client, err := pubsub.NewClient(context.Background(), "test", opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	if _, err := client.CreateTopic(context.Background(), "logs"); err != nil {
+		t.Fatal(err)
+	}
+
+	out := &bytes.Buffer{}
+	l, err := New(&Config{
+		ProjectID: "test",
+		Level:     LevelDebug,
+		Output:    out,
+		PubSub: &PubSubOutput{
+			Topic:   "logs",
+			Labels:  map[string]string{"stream": "audit"},
+			Options: opts,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Info(context.Background(), "ignored")
+	l.Infow(context.Background(), "audited", "stream", "audit")
+	l.Warn(context.Background(), "disk full")
+	l.Close()
+
+	msgs := srv.Messages()
+	if len(msgs) != 2 {
+		t.Fatalf("got %d messages, want 2", len(msgs))
+	}
+	var messages []string
+	for _, msg := range msgs {
+		entry := map[string]interface{}{}
+		if err := json.Unmarshal(msg.Data, &entry); err != nil {
+			t.Fatal(err)
+		}
+		messages = append(messages, entry["message"].(string))
+	}
+	if messages[0] != "audited" || messages[1] != "disk full" {
+		t.Errorf("got messages %q, want the audited entry and the warning", messages)
+	}
+	if got := msgs[1].Attributes["level"]; got != LevelWarn.String() {
+		t.Errorf("got level attribute %q, want %q", got, LevelWarn.String())
+	}
+	if n := len(entries(t, out)); n != 3 {
+		t.Errorf("got %d entries written to the output, want 3", n)
+	}
+}
@@ -0,0 +1,91 @@
+package logging
+
+import (
+	"fmt"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"strings"
+
+	"github.com/blendle/zapdriver"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"golang.org/x/net/context"
+)
+
+// Recovery provides a gin middleware recovering from panics, logging them at
+// critical severity with their stack trace and answering 500. It should be
+// installed after RequestLogger so the request is logged with its status.
+func Recovery() gin.HandlerFunc {
+	return recovery(nil)
+}
+
+// Recovery provides a gin middleware recovering from panics logged with l.
+func (l *Logger) Recovery() gin.HandlerFunc {
+	return recovery(l)
+}
+
+func recovery(logger *Logger) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+			if v == http.ErrAbortHandler {
+				// net/http aborts the response silently.
+				panic(v)
+			}
+			orStd(logger).logPanic(ctx.Request.Context(), v, debug.Stack())
+			if ctx.Writer.Written() {
+				ctx.Abort()
+				return
+			}
+			ctx.AbortWithStatus(http.StatusInternalServerError)
+		}()
+		ctx.Next()
+	}
+}
+
+// logPanic logs a recovered panic at critical severity, located where it
+// was raised, with the stack of the recovering goroutine.
+func (l *Logger) logPanic(ctx context.Context, v interface{}, stack []byte) {
+	l.flushDebug(ctx)
+	if !l.enabled(ctx, LevelCritical) {
+		return
+	}
+	pc, file, line, ok := panicCaller()
+	msg := fmt.Sprintf("panic: %v", v)
+	fields := []zapcore.Field{
+		zapdriver.SourceLocation(pc, file, line, ok),
+		zap.String(keyStackTrace, msg+"\n\n"+string(stack)),
+	}
+	if err, isErr := v.(error); isErr {
+		fields = append(fields, zap.NamedError(l.keyError, err))
+	}
+	if l.errorReporting {
+		fields = append(fields, l.errorReport(pc, file, line, ok)...)
+	}
+	l.zentry(ctx, LevelCritical, msg, "", fields)
+}
+
+// panicCaller returns the location of the frame that panicked, from a
+// deferred function recovering the panic.
+func panicCaller() (uintptr, string, int, bool) {
+	pcs := make([]uintptr, 64)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(1, pcs)])
+	panicking := false
+	for {
+		frame, more := frames.Next()
+		if panicking && !strings.HasPrefix(frame.Function, "runtime.") {
+			return frame.PC, frame.File, frame.Line, true
+		}
+		if frame.Function == "runtime.gopanic" {
+			panicking = true
+		}
+		if !more {
+			return 0, "", 0, false
+		}
+	}
+}
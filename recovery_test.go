@@ -0,0 +1,47 @@
+package logging
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRecovery(t *testing.T) {
+	l, buf := newTestLogger(t, &Config{})
+	r := gin.New()
+	r.Use(l.RequestLogger(), l.Recovery())
+	r.GET("/panic", func(c *gin.Context) {
+		panic("boom")
+	})
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/panic", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	r.ServeHTTP(w, req)
+
+	if w.Code != 500 {
+		t.Errorf("got status %d, want 500", w.Code)
+	}
+	got := entries(t, buf)
+	if len(got) != 2 {
+		t.Fatalf("got %d entries, want 2", len(got))
+	}
+	e := got[0]
+	if e["severity"] != "CRITICAL" || e["message"] != "panic: boom" {
+		t.Errorf("got %v %v, want the critical panic", e["severity"], e["message"])
+	}
+	if stack, _ := e["stack_trace"].(string); !strings.HasPrefix(stack, "panic: boom\n\ngoroutine ") || !strings.Contains(stack, "TestRecovery") {
+		t.Errorf("got stack trace %q, want the stack of the panic", stack)
+	}
+	source, _ := e["logging.googleapis.com/sourceLocation"].(map[string]interface{})
+	if file, _ := source["file"].(string); !strings.HasSuffix(file, "recovery_test.go") {
+		t.Errorf("got source location %v, want the panicking handler", source)
+	}
+	if tr := e["logging.googleapis.com/trace"]; tr != "projects/test/traces/4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("got trace %v, want the trace of the request", tr)
+	}
+	if req, _ := got[1]["httpRequest"].(map[string]interface{}); req["status"] != float64(500) {
+		t.Errorf("got access log %v, want status 500", got[1]["httpRequest"])
+	}
+}
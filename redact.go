@@ -0,0 +1,225 @@
+package logging
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"golang.org/x/net/context"
+)
+
+// redactedValue replaces the value of redacted labels.
+const redactedValue = "[REDACTED]"
+
+// defaultRedactKeys are the keys redacted in bodies, and by Redaction,
+// whatever their case.
+var defaultRedactKeys = []string{
+	"password", "passwd", "secret", "token", "access_token", "refresh_token", "id_token",
+	"api_key", "apikey", "authorization", "credit_card", "card_number", "cvv", "ssn",
+}
+
+func keySet(keys []string) map[string]struct{} {
+	if len(keys) == 0 {
+		return nil
+	}
+	set := make(map[string]struct{}, len(keys))
+	for _, k := range keys {
+		set[k] = struct{}{}
+	}
+	return set
+}
+
+func (l *Logger) setRedaction(keys []string, policies map[string][]string) {
+	l.redactDefault = keySet(keys)
+	l.redactPolicies = make(map[string]map[string]struct{}, len(policies))
+	for scope, keys := range policies {
+		l.redactPolicies[scope] = keySet(keys)
+	}
+}
+
+// redactKeysFor returns the set of keys to redact for the scope carried by
+// the context, falling back to the default policy.
+func (l *Logger) redactKeysFor(ctx context.Context) map[string]struct{} {
+	if scope, ok := l.scope(ctx); ok {
+		if keys, ok := l.redactPolicies[scope]; ok {
+			return keys
+		}
+	}
+	return l.redactDefault
+}
+
+// EmailPattern matches email addresses, it is one of the default patterns of
+// Redaction.
+const EmailPattern = `[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`
+
+// cardPattern matches the candidate card numbers, redacted if their Luhn
+// checksum is valid.
+var cardPattern = regexp.MustCompile(`\b\d(?:[ \-]?\d){12,18}\b`)
+
+// Redaction replaces sensitive values in the labels, payload fields and
+// captured bodies of the entries before they are encoded, including the
+// access logs and audit events.
+type Redaction struct {
+	// Keys lists the names of the labels, fields and body keys whose values
+	// are redacted whatever their case, in addition to common credentials
+	// and secrets such as password, token or ssn.
+	Keys []string `json:"keys" yaml:"keys"`
+	// Patterns lists the regular expressions matching the parts of the
+	// string values redacted, in addition to card numbers and EmailPattern.
+	Patterns []string `json:"patterns" yaml:"patterns"`
+}
+
+// redactor redacts the fields of entries, a nil redactor redacting nothing.
+type redactor struct {
+	keys     map[string]struct{}
+	patterns []*regexp.Regexp
+	// skip lists the fields never redacted, such as the request ID.
+	skip map[string]struct{}
+}
+
+func newRedactor(c *Redaction, skip ...string) (*redactor, error) {
+	if c == nil {
+		return nil, nil
+	}
+	r := &redactor{keys: map[string]struct{}{}, skip: keySet(skip)}
+	for _, keys := range [][]string{defaultRedactKeys, c.Keys} {
+		for _, k := range keys {
+			r.keys[strings.ToLower(k)] = struct{}{}
+		}
+	}
+	for _, pattern := range append([]string{EmailPattern}, c.Patterns...) {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("logging: invalid redaction pattern %q: %w", pattern, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+	return r, nil
+}
+
+// redactsKey reports whether the value of a key is redacted.
+func (r *redactor) redactsKey(key string) bool {
+	if r == nil {
+		return false
+	}
+	_, ok := r.keys[strings.ToLower(key)]
+	return ok
+}
+
+// redactString replaces the sensitive parts of a string.
+func (r *redactor) redactString(s string) string {
+	s = cardPattern.ReplaceAllStringFunc(s, func(match string) string {
+		if luhnValid(match) {
+			return redactedValue
+		}
+		return match
+	})
+	for _, re := range r.patterns {
+		s = re.ReplaceAllLiteralString(s, redactedValue)
+	}
+	return s
+}
+
+// entry returns the message and fields of an entry redacted, the fields
+// being copied if any is.
+func (r *redactor) entry(msg string, fields []zapcore.Field) (string, []zapcore.Field) {
+	if r == nil {
+		return msg, fields
+	}
+	msg = r.redactString(msg)
+	redacted, copied := fields, false
+	for i, f := range fields {
+		rf, ok := r.field(f)
+		if !ok {
+			continue
+		}
+		if !copied {
+			redacted, copied = append([]zapcore.Field(nil), fields...), true
+		}
+		redacted[i] = rf
+	}
+	return msg, redacted
+}
+
+// field returns a field redacted, and whether it was.
+func (r *redactor) field(f zapcore.Field) (zapcore.Field, bool) {
+	if _, ok := r.skip[f.Key]; ok || f.Type == zapcore.SkipType || strings.HasPrefix(f.Key, zapdriverPrefix) {
+		return f, false
+	}
+	if r.redactsKey(strings.TrimPrefix(f.Key, "labels.")) {
+		return zap.String(f.Key, redactedValue), true
+	}
+	switch f.Type {
+	case zapcore.StringType:
+		if s := r.redactString(f.String); s != f.String {
+			return zap.String(f.Key, s), true
+		}
+	case zapcore.ErrorType, zapcore.StringerType:
+		s := fmt.Sprint(f.Interface)
+		if redacted := r.redactString(s); redacted != s {
+			return zap.String(f.Key, redacted), true
+		}
+	case zapcore.ObjectMarshalerType, zapcore.ArrayMarshalerType, zapcore.ReflectType:
+		m := zapcore.NewMapObjectEncoder()
+		f.AddTo(m)
+		if v, ok := r.value(m.Fields[f.Key]); ok {
+			return zap.Any(f.Key, v), true
+		}
+	}
+	return f, false
+}
+
+// value redacts a value decoded by a zapcore.MapObjectEncoder in place, and
+// reports whether it was.
+func (r *redactor) value(v interface{}) (interface{}, bool) {
+	switch v := v.(type) {
+	case string:
+		s := r.redactString(v)
+		return s, s != v
+	case map[string]interface{}:
+		redacted := false
+		for k, e := range v {
+			if r.redactsKey(k) {
+				v[k] = redactedValue
+				redacted = true
+			} else if e, ok := r.value(e); ok {
+				v[k] = e
+				redacted = true
+			}
+		}
+		return v, redacted
+	case []interface{}:
+		redacted := false
+		for i, e := range v {
+			if e, ok := r.value(e); ok {
+				v[i] = e
+				redacted = true
+			}
+		}
+		return v, redacted
+	}
+	return v, false
+}
+
+// luhnValid reports whether the digits of a number have a valid Luhn
+// checksum, as card numbers do.
+func luhnValid(number string) bool {
+	sum, double := 0, false
+	for i := len(number) - 1; i >= 0; i-- {
+		c := number[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
@@ -0,0 +1,94 @@
+package logging
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/context"
+)
+
+func TestRedactionPolicies(t *testing.T) {
+	l, buf := newTestLogger(t, &Config{
+		RedactKeys:     []string{"email"},
+		RedactPolicies: map[string][]string{"admin": {}},
+	})
+	l.Infow(WithScope(context.Background(), "public"), "user", "email", "a@example.com")
+	l.Infow(WithScope(context.Background(), "admin"), "user", "email", "a@example.com")
+
+	got := entries(t, buf)
+	if len(got) != 2 {
+		t.Fatalf("got %d entries, want 2", len(got))
+	}
+	if email := labels(got[0])["email"]; email != redactedValue {
+		t.Errorf("got email %v under the default policy, want it redacted", email)
+	}
+	if email := labels(got[1])["email"]; email != "a@example.com" {
+		t.Errorf("got email %v under the admin policy, want it passed through", email)
+	}
+}
+
+func TestRedaction(t *testing.T) {
+	l, buf := newTestLogger(t, &Config{
+		PayloadFields: true,
+		Redaction:     &Redaction{Keys: []string{"Customer_Ref"}, Patterns: []string{`\bACCT-\d+\b`}},
+	})
+	ctx := WithRequestID(context.Background(), "4111111111111111")
+	l.Infow(ctx, "paid by bob@example.com",
+		"note", "card 4111 1111 1111 1111, order 4111111111111112, account ACCT-42",
+		"customer_ref", "c-1",
+		Any("payment", map[string]interface{}{"Token": "t", "payer": map[string]interface{}{"email": "a@example.com"}, "amount": 12}),
+		Err(errors.New("rejected for carol@example.com")),
+	)
+
+	got := entries(t, buf)
+	if len(got) != 1 {
+		t.Fatalf("got %d entries, want 1", len(got))
+	}
+	e := got[0]
+	if e["message"] != "paid by [REDACTED]" || e["note"] != "card [REDACTED], order 4111111111111112, account [REDACTED]" || e["customer_ref"] != redactedValue {
+		t.Errorf("got %v, want the message and fields redacted", e)
+	}
+	payment, _ := e["payment"].(map[string]interface{})
+	payer, _ := payment["payer"].(map[string]interface{})
+	if payment["Token"] != redactedValue || payer["email"] != "[REDACTED]" || payment["amount"] != 12.0 {
+		t.Errorf("got payment %v, want its token and email redacted", payment)
+	}
+	if e["error"] != "rejected for [REDACTED]" {
+		t.Errorf("got error %v, want it redacted", e["error"])
+	}
+	if labels(e)["request_id"] != "4111111111111111" {
+		t.Errorf("got labels %v, want the request ID kept", labels(e))
+	}
+}
+
+func TestRedactionBodies(t *testing.T) {
+	l, buf := newTestLogger(t, &Config{
+		BodyCapture: &BodyCapture{},
+		Redaction:   &Redaction{Keys: []string{"customer_ref"}},
+	})
+	h := l.Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	req := httptest.NewRequest("POST", "/orders?email=a@example.com", strings.NewReader(`{"customer_ref":"c-1","contact":"a@example.com"}`))
+	req.Header.Set("Content-Type", "application/json")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	got := entries(t, buf)
+	if len(got) != 1 {
+		t.Fatalf("got %d entries, want 1", len(got))
+	}
+	if body := got[0]["request_body"]; body != `{"contact":"[REDACTED]","customer_ref":"[REDACTED]"}` {
+		t.Errorf("got body %v, want it redacted", body)
+	}
+	httpRequest, _ := got[0]["httpRequest"].(map[string]interface{})
+	if url, _ := httpRequest["requestUrl"].(string); strings.Contains(url, "a@example.com") {
+		t.Errorf("got URL %v, want the email redacted", url)
+	}
+}
+
+func TestRedactionInvalidPattern(t *testing.T) {
+	if _, err := New(&Config{Redaction: &Redaction{Patterns: []string{"("}}}); err == nil {
+		t.Error("got no error for an invalid pattern")
+	}
+}
@@ -0,0 +1,149 @@
+// Package redishook logs the commands run by go-redis with the logging
+// package.
+package redishook
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/cyoyu/logging"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"golang.org/x/net/context"
+)
+
+// New returns a go-redis hook logging the commands with l, or with the
+// package level logger if l is nil, as logging.SQLDriver does with SQL
+// statements. The values of the commands are redacted, their keys are
+// logged along with their pattern as redis_key, e.g. user:* for user:42.
+func New(l *logging.Logger) redis.Hook {
+	return hook{l: l}
+}
+
+type hook struct {
+	l *logging.Logger
+}
+
+// logger returns the logger of h.
+func (h hook) logger() *logging.Logger {
+	if h.l == nil {
+		return logging.Default()
+	}
+	return h.l
+}
+
+func (h hook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return next(ctx, network, addr)
+	}
+}
+
+func (h hook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmd)
+		statement, key := redisStatement(cmd)
+		var extra []zapcore.Field
+		if key != "" {
+			extra = append(extra, zap.String("redis_key", key))
+		}
+		h.logger().LogQuery(ctx, "redis command", "redis", statement, -1, redisError(err), time.Since(start), extra...)
+		return err
+	}
+}
+
+func (h hook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmds)
+		logErr := redisError(err)
+		statements := make([]string, len(cmds))
+		for i, cmd := range cmds {
+			statements[i], _ = redisStatement(cmd)
+			if logErr == nil {
+				logErr = redisError(cmd.Err())
+			}
+		}
+		h.logger().LogQuery(ctx, "redis pipeline", "redis", strings.Join(statements, "; "), -1, logErr, time.Since(start))
+		return err
+	}
+}
+
+// redisError returns the error of a command, a missing key not being one.
+func redisError(err error) error {
+	if errors.Is(err, redis.Nil) {
+		return nil
+	}
+	return err
+}
+
+// redisStatement returns a command with its values redacted, and the
+// pattern of its first key.
+func redisStatement(cmd redis.Cmder) (string, string) {
+	name := cmd.FullName()
+	args := cmd.Args()
+	words := []string{strings.ToUpper(name)}
+	keyPos := redisKeyPos(cmd, len(strings.Fields(name)))
+	key := ""
+	for i := len(strings.Fields(name)); i < len(args); i++ {
+		if i == keyPos {
+			key = fmt.Sprint(args[i])
+			words = append(words, key)
+		} else {
+			words = append(words, "?")
+		}
+	}
+	return strings.Join(words, " "), redisKeyPattern(key)
+}
+
+// redisKeyPos returns the position of the first key in the arguments of a
+// command, or 0 if it has none.
+func redisKeyPos(cmd redis.Cmder, nameWords int) int {
+	args := cmd.Args()
+	switch cmd.Name() {
+	case "eval", "evalsha", "eval_ro", "evalsha_ro":
+		if len(args) > 2 && fmt.Sprint(args[2]) != "0" {
+			return 3
+		}
+		return 0
+	}
+	if nameWords > 1 {
+		// Subcommands, e.g. CLUSTER INFO, take no key.
+		return 0
+	}
+	return 1
+}
+
+// redisKeyPattern replaces the IDs in the segments of a key with *.
+func redisKeyPattern(key string) string {
+	segments := strings.Split(key, ":")
+	for i, s := range segments {
+		if redisID(s) {
+			segments[i] = "*"
+		}
+	}
+	return strings.Join(segments, ":")
+}
+
+// redisID reports whether a key segment is an ID: a number, or a long
+// hexadecimal string such as a UUID.
+func redisID(s string) bool {
+	if s == "" {
+		return false
+	}
+	digits, hex := true, len(s) >= 16
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+		case r >= 'a' && r <= 'f', r >= 'A' && r <= 'F', r == '-':
+			digits = false
+		default:
+			return false
+		}
+	}
+	return digits || hex
+}
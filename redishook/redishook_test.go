@@ -0,0 +1,83 @@
+package redishook
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/cyoyu/logging"
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/net/context"
+)
+
+func TestHook(t *testing.T) {
+	var buf bytes.Buffer
+	l, err := logging.New(&logging.Config{ProjectID: "test", Level: logging.LevelDebug, Output: &buf})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := logging.WithRequestID(context.Background(), "4bf92f3577b34da6a3ce929d0e0e4736")
+	hook := New(l)
+	process := hook.ProcessHook(func(ctx context.Context, cmd redis.Cmder) error {
+		if cmd.Name() == "get" {
+			cmd.SetErr(redis.Nil)
+			return redis.Nil
+		}
+		if cmd.Name() == "incr" {
+			err := errors.New("WRONGTYPE")
+			cmd.SetErr(err)
+			return err
+		}
+		return nil
+	})
+	pipeline := hook.ProcessPipelineHook(func(ctx context.Context, cmds []redis.Cmder) error {
+		return nil
+	})
+
+	process(ctx, redis.NewStatusCmd(ctx, "set", "session:4bf92f3577b34da6a3ce929d0e0e4736", "secret", "ex", 10))
+	if err := process(ctx, redis.NewStringCmd(ctx, "get", "user:42:name")); err != redis.Nil {
+		t.Errorf("got error %v, want redis.Nil", err)
+	}
+	process(ctx, redis.NewIntCmd(ctx, "incr", "counter"))
+	process(ctx, redis.NewCmd(ctx, "evalsha", "sha", 1, "lock:7", "token"))
+	process(ctx, redis.NewStringCmd(ctx, "cluster", "info"))
+	pipeline(ctx, []redis.Cmder{
+		redis.NewStatusCmd(ctx, "set", "a", "1"),
+		redis.NewIntCmd(ctx, "expire", "a", 10),
+	})
+
+	var got []map[string]interface{}
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var e map[string]interface{}
+		if err := dec.Decode(&e); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, e)
+	}
+	want := []struct {
+		severity, message, statement string
+		key, err                     interface{}
+	}{
+		{"DEBUG", "redis command", "SET session:4bf92f3577b34da6a3ce929d0e0e4736 ? ? ?", "session:*", nil},
+		{"DEBUG", "redis command", "GET user:42:name", "user:*:name", nil},
+		{"ERROR", "redis command", "INCR counter", "counter", "WRONGTYPE"},
+		{"DEBUG", "redis command", "EVALSHA ? ? lock:7 ?", "lock:*", nil},
+		{"DEBUG", "redis command", "CLUSTER INFO", nil, nil},
+		{"DEBUG", "redis pipeline", "SET a ?; EXPIRE a ?", nil, nil},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d entries, want %d", len(got), len(want))
+	}
+	for i, w := range want {
+		e := got[i]
+		if e["severity"] != w.severity || e["message"] != w.message || e["redis"] != w.statement || e["redis_key"] != w.key || e["err"] != w.err {
+			t.Errorf("entry %d: got %v, want %+v", i, e, w)
+		}
+		labels, _ := e["logging.googleapis.com/labels"].(map[string]interface{})
+		if labels["request_id"] != "4bf92f3577b34da6a3ce929d0e0e4736" {
+			t.Errorf("entry %d: got labels %v, want the request ID", i, labels)
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package logging
+
+import (
+	"github.com/blendle/zapdriver"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// reportedErrorEventType marks an entry as a Cloud Error Reporting event.
+const reportedErrorEventType = "type.googleapis.com/google.devtools.clouderrorreporting.v1beta1.ReportedErrorEvent"
+
+// errorReport returns the fields formatting an entry as a ReportedErrorEvent
+// located at the caller.
+func (l *Logger) errorReport(pc uintptr, file string, line int, ok bool) []zapcore.Field {
+	fields := []zapcore.Field{
+		zap.String("@type", reportedErrorEventType),
+		zapdriver.ErrorReport(pc, file, line, ok),
+	}
+	if l.serviceName != "" {
+		fields = append(fields, zap.Object(serviceContextKey, serviceContext{name: l.serviceName}))
+	}
+	return fields
+}
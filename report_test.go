@@ -0,0 +1,48 @@
+package logging
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/net/context"
+)
+
+func TestErrorReporting(t *testing.T) {
+	l, buf := newTestLogger(t, &Config{ErrorReporting: true, ServiceName: "api"})
+	l.Info(context.Background(), "started")
+	l.Error(context.Background(), "failed")
+
+	got := entries(t, buf)
+	if len(got) != 2 {
+		t.Fatalf("got %d entries, want 2", len(got))
+	}
+	if _, ok := got[0]["@type"]; ok {
+		t.Errorf("got %v, want an info entry not reported", got[0])
+	}
+	e := got[1]
+	if e["@type"] != reportedErrorEventType {
+		t.Errorf("got @type %v, want %s", e["@type"], reportedErrorEventType)
+	}
+	reportCtx, _ := e["context"].(map[string]interface{})
+	location, _ := reportCtx["reportLocation"].(map[string]interface{})
+	if fn, _ := location["functionName"].(string); !strings.HasSuffix(fn, "TestErrorReporting") {
+		t.Errorf("got report location %v, want the caller", location)
+	}
+	service, _ := e["serviceContext"].(map[string]interface{})
+	if service["service"] != "api" {
+		t.Errorf("got service context %v, want the service name", service)
+	}
+}
+
+func TestErrorReportingDisabled(t *testing.T) {
+	l, buf := newTestLogger(t, &Config{})
+	l.Error(context.Background(), "failed")
+
+	got := entries(t, buf)
+	if len(got) != 1 {
+		t.Fatalf("got %d entries, want 1", len(got))
+	}
+	if _, ok := got[0]["@type"]; ok {
+		t.Errorf("got %v, want no error report unless enabled", got[0])
+	}
+}
@@ -0,0 +1,75 @@
+package logging
+
+import (
+	"crypto/rand"
+
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/net/context"
+)
+
+// requestIDHeader is the header carrying the request ID, read from requests
+// and echoed in responses by the middlewares.
+const requestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength is the maximum length of the request IDs accepted from
+// the requests.
+const maxRequestIDLength = 128
+
+// requestIDKey is the context key of the request ID.
+type requestIDKey struct{}
+
+// WithRequestID returns a copy of ctx carrying the request ID, logged when
+// ctx doesn't carry a trace.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestIDFromContext returns the ID the entries logged with ctx are
+// correlated by, the ID of its trace or else the ID set by WithRequestID.
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+		return sc.TraceID().String(), true
+	}
+	requestID, ok := ctx.Value(requestIDKey{}).(string)
+	return requestID, ok && requestID != ""
+}
+
+// requestID returns the request ID logged with ctx, all zeros if none.
+func requestID(ctx context.Context) string {
+	if requestID, ok := RequestIDFromContext(ctx); ok {
+		return requestID
+	}
+	return trace.TraceID{}.String()
+}
+
+// withRequestID returns a copy of the request context carrying a request ID
+// unless it carries a trace, the ID being taken from the request header or
+// generated in the format of trace IDs.
+func withRequestID(ctx context.Context, header string) (context.Context, string) {
+	if requestID, ok := RequestIDFromContext(ctx); ok {
+		return ctx, requestID
+	}
+	requestID := header
+	if !validHeaderRequestID(requestID) {
+		var id trace.TraceID
+		_, _ = rand.Read(id[:])
+		requestID = id.String()
+	}
+	return WithRequestID(ctx, requestID), requestID
+}
+
+// validHeaderRequestID reports whether a request ID sent by a client can be
+// logged, being short and made of letters, digits, '-', '_' and '.'.
+func validHeaderRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range requestID {
+		switch {
+		case 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z', '0' <= r && r <= '9', r == '-', r == '_', r == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,79 @@
+package logging
+
+import (
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/net/context"
+)
+
+func TestRequestLoggerRequestID(t *testing.T) {
+	l, buf := newTestLogger(t, &Config{})
+	traceID := trace.TraceID{1, 2, 3}
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		if c.Query("traced") != "" {
+			ctx := trace.ContextWithSpanContext(c.Request.Context(), trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID}))
+			c.Request = c.Request.WithContext(ctx)
+		}
+	})
+	r.Use(l.RequestLogger())
+	r.GET("/", func(c *gin.Context) {
+		l.Info(c.Request.Context(), "handling")
+		c.Status(200)
+	})
+
+	for _, tt := range []struct {
+		path, header string
+		want         *regexp.Regexp
+	}{
+		{"/", "", regexp.MustCompile(`^[0-9a-f]{32}$`)},
+		{"/", "req-42", regexp.MustCompile(`^req-42$`)},
+		{"/", "bad id\n", regexp.MustCompile(`^[0-9a-f]{32}$`)},
+		{"/?traced=1", "req-42", regexp.MustCompile(`^` + traceID.String() + `$`)},
+	} {
+		buf.Reset()
+		req := httptest.NewRequest("GET", tt.path, nil)
+		if tt.header != "" {
+			req.Header.Set("X-Request-ID", tt.header)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		requestID := w.Header().Get("X-Request-ID")
+		if !tt.want.MatchString(requestID) {
+			t.Errorf("%s %q: got request ID %q, want %v", tt.path, tt.header, requestID, tt.want)
+		}
+		got := entries(t, buf)
+		if len(got) != 2 {
+			t.Fatalf("got %d entries, want 2", len(got))
+		}
+		for _, e := range got {
+			if id := labels(e)["request_id"]; id != requestID {
+				t.Errorf("%s %q: logged request ID %v, want %s", tt.path, tt.header, id, requestID)
+			}
+		}
+	}
+}
+
+func TestRequestIDFromContext(t *testing.T) {
+	ctx := context.Background()
+	if _, ok := RequestIDFromContext(ctx); ok {
+		t.Error("got a request ID from an empty context")
+	}
+	if id := requestID(ctx); validRequestID(id) {
+		t.Errorf("got request ID %q from an empty context, want zeros", id)
+	}
+	ctx = WithRequestID(ctx, "req-1")
+	if id, ok := RequestIDFromContext(ctx); !ok || id != "req-1" {
+		t.Errorf("got request ID %q, want req-1", id)
+	}
+	traceID := trace.TraceID{1}
+	ctx = trace.ContextWithSpanContext(ctx, trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID}))
+	if id, _ := RequestIDFromContext(ctx); id != traceID.String() {
+		t.Errorf("got request ID %q, want the trace ID", id)
+	}
+}
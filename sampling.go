@@ -0,0 +1,138 @@
+package logging
+
+import (
+	"hash/fnv"
+	"math"
+	"math/rand"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"golang.org/x/net/context"
+)
+
+// Sampling limits the entries logged each second with the same level and
+// message: the first Initial ones are logged, then every Thereafter-th one.
+// Entries of error severity and above are never sampled.
+type Sampling struct {
+	// Initial zero disables sampling.
+	Initial    int `json:"initial" yaml:"initial"`
+	Thereafter int `json:"thereafter" yaml:"thereafter"`
+}
+
+// samplingOptions takes the sampling out of config, which would sample
+// errors too, and returns the options sampling the entries below error
+// severity instead. They must be applied last so the sampler sees the
+// entries before the zapdriver core.
+func samplingOptions(config *zap.Config, c *Config) []zap.Option {
+	sampling := config.Sampling
+	config.Sampling = nil
+	if c != nil && c.Sampling != nil {
+		sampling = &zap.SamplingConfig{Initial: c.Sampling.Initial, Thereafter: c.Sampling.Thereafter}
+	}
+	if sampling == nil || sampling.Initial <= 0 {
+		return nil
+	}
+	return []zap.Option{zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return &samplingCore{
+			Core:    core,
+			sampled: zapcore.NewSamplerWithOptions(core, time.Second, sampling.Initial, sampling.Thereafter),
+		}
+	})}
+}
+
+// samplingCore samples the entries below error severity.
+type samplingCore struct {
+	zapcore.Core
+	sampled zapcore.Core
+}
+
+func (c *samplingCore) With(fields []zapcore.Field) zapcore.Core {
+	return &samplingCore{Core: c.Core.With(fields), sampled: c.sampled.With(fields)}
+}
+
+func (c *samplingCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if ent.Level >= zapcore.ErrorLevel {
+		return c.Core.Check(ent, ce)
+	}
+	return c.sampled.Check(ent, ce)
+}
+
+// sampleRoute reports whether the access log of a request to the route
+// should be kept.
+func (l *Logger) sampleRoute(ctx context.Context, route string) bool {
+	ratio, ok := l.routeSampling[route]
+	if !ok || ratio >= 1 {
+		return true
+	}
+	return sampleRatio(ctx, ratio)
+}
+
+// statusSample is the ratio of the access logs kept for a status code or
+// class, on the routes matching the pattern or on all routes if it is empty.
+type statusSample struct {
+	pattern string
+	status  string
+	ratio   float64
+}
+
+// setStatusSampling sets the status sampling from keys that are a status
+// code or class optionally preceded by a route pattern and a space.
+func (l *Logger) setStatusSampling(sampling map[string]float64) {
+	l.statusSampling = nil
+	for key, ratio := range sampling {
+		pattern, status, ok := strings.Cut(key, " ")
+		if !ok {
+			pattern, status = "", key
+		}
+		l.statusSampling = append(l.statusSampling, statusSample{pattern: pattern, status: strings.TrimSpace(status), ratio: ratio})
+	}
+	// Longer patterns are more specific.
+	sort.Slice(l.statusSampling, func(i, j int) bool {
+		return len(l.statusSampling[i].pattern) > len(l.statusSampling[j].pattern)
+	})
+}
+
+// sampleStatus reports whether the access log of a request answered with the
+// status should be kept, looking the ratio up by code then by class. Client
+// and server errors are always kept.
+func (l *Logger) sampleStatus(ctx context.Context, route, urlPath string, status int) bool {
+	if len(l.statusSampling) == 0 || status >= http.StatusBadRequest {
+		return true
+	}
+	for _, key := range []string{strconv.Itoa(status), strconv.Itoa(status/100) + "xx"} {
+		for _, s := range l.statusSampling {
+			if s.status != key {
+				continue
+			}
+			if s.pattern == "" || routeMatch(s.pattern, route) || routeMatch(s.pattern, urlPath) {
+				return s.ratio >= 1 || sampleRatio(ctx, s.ratio)
+			}
+		}
+	}
+	return true
+}
+
+// sampleRatio reports whether to keep a request with the ratio. The decision
+// is derived from the trace ID so a request is consistently kept or dropped
+// across services.
+func sampleRatio(ctx context.Context, ratio float64) bool {
+	if ratio <= 0 {
+		return false
+	}
+	var h uint64
+	if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+		traceID := sc.TraceID()
+		hash := fnv.New64a()
+		hash.Write(traceID[:])
+		h = hash.Sum64()
+	} else {
+		h = rand.Uint64()
+	}
+	return float64(h)/math.MaxUint64 < ratio
+}
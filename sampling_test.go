@@ -0,0 +1,127 @@
+package logging
+
+import (
+	"math/rand"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/net/context"
+)
+
+func TestSampleRoute(t *testing.T) {
+	l := newLogger()
+	l.routeSampling = map[string]float64{"/sampled": 0.25}
+
+	const n = 4000
+	kept := 0
+	for i := 0; i < n; i++ {
+		var traceID trace.TraceID
+		rand.Read(traceID[:])
+		ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID}))
+		if l.sampleRoute(ctx, "/sampled") {
+			kept++
+		}
+		if !l.sampleRoute(ctx, "/other") {
+			t.Fatal("dropped a request to a route without sampling")
+		}
+	}
+	if ratio := float64(kept) / n; ratio < 0.2 || ratio > 0.3 {
+		t.Errorf("kept %.2f of the requests, want about 0.25", ratio)
+	}
+}
+
+func TestRequestLoggerSampling(t *testing.T) {
+	l, buf := newTestLogger(t, &Config{RouteSampling: map[string]float64{"/health": 0}})
+	r := gin.New()
+	r.Use(l.RequestLogger())
+	r.GET("/health", func(c *gin.Context) {
+		if c.Query("fail") != "" {
+			c.Status(503)
+			return
+		}
+		c.Status(200)
+	})
+	r.GET("/users", func(c *gin.Context) {
+		c.Status(200)
+	})
+	for _, path := range []string{"/health", "/users", "/health?fail=1"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+
+	got := entries(t, buf)
+	if len(got) != 2 {
+		t.Fatalf("got %d entries, want 2", len(got))
+	}
+	if route := labels(got[0])["route"]; route != "/users" {
+		t.Errorf("got route %v, want the unsampled /users", route)
+	}
+	if req, _ := got[1]["httpRequest"].(map[string]interface{}); req["status"] != float64(503) {
+		t.Errorf("got %v, want the failed /health request", got[1]["httpRequest"])
+	}
+}
+
+func TestRequestLoggerStatusSampling(t *testing.T) {
+	l, buf := newTestLogger(t, &Config{StatusSampling: map[string]float64{
+		"/health 200": 0,
+		"304":         0,
+		"2xx":         1,
+		"4xx":         0,
+	}})
+	r := gin.New()
+	r.Use(l.RequestLogger())
+	r.GET("/*path", func(c *gin.Context) {
+		status, _ := strconv.Atoi(c.Query("status"))
+		c.Status(status)
+	})
+	for _, path := range []string{
+		"/health?status=200",
+		"/health?status=204",
+		"/users?status=200",
+		"/users?status=304",
+		"/users?status=404",
+	} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+
+	var got []string
+	for _, e := range entries(t, buf) {
+		req, _ := e["httpRequest"].(map[string]interface{})
+		got = append(got, req["requestUrl"].(string))
+	}
+	want := []string{"/health?status=204", "/users?status=200", "/users?status=404"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestSampling(t *testing.T) {
+	l, buf := newTestLogger(t, &Config{Sampling: &Sampling{Initial: 2}})
+	for i := 0; i < 5; i++ {
+		l.Info(context.Background(), "retrying")
+		l.Error(context.Background(), "failed")
+	}
+	counts := map[string]int{}
+	for _, e := range entries(t, buf) {
+		counts[e["message"].(string)]++
+	}
+	if counts["retrying"] != 2 {
+		t.Errorf("got %d info entries, want 2", counts["retrying"])
+	}
+	if counts["failed"] != 5 {
+		t.Errorf("got %d error entries, want all 5", counts["failed"])
+	}
+}
+
+func TestSamplingDefault(t *testing.T) {
+	l, buf := newTestLogger(t, &Config{})
+	for i := 0; i < 150; i++ {
+		l.Error(context.Background(), "failed")
+	}
+	if n := len(entries(t, buf)); n != 150 {
+		t.Errorf("got %d error entries, want all 150", n)
+	}
+}
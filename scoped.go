@@ -0,0 +1,93 @@
+package logging
+
+import (
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/context"
+)
+
+// loggerKey is the context key of the logger of the middleware handling a
+// request.
+type loggerKey struct{}
+
+// withLogger returns a copy of ctx carrying the logger.
+func withLogger(ctx context.Context, l *Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
+// ContextLogger logs with the context of a request, its entries carrying the
+// request ID, route, user ID and fields of the context.
+type ContextLogger struct {
+	l   *Logger
+	ctx context.Context
+}
+
+// From returns the logger of the request of a gin context, logging with the
+// logger of the request logging middleware.
+func From(c *gin.Context) *ContextLogger {
+	return FromContext(c.Request.Context())
+}
+
+// FromContext returns the logger of ctx, whose entries carry the fields
+// accumulated in ctx by WithFields. It logs with the logger of the request
+// logging middleware that set up ctx if any, the package level logger
+// otherwise.
+func FromContext(ctx context.Context) *ContextLogger {
+	l, _ := ctx.Value(loggerKey{}).(*Logger)
+	return &ContextLogger{l: orStd(l), ctx: ctx}
+}
+
+// Context returns the context of the logger.
+func (c *ContextLogger) Context() context.Context {
+	return c.ctx
+}
+
+// Fields returns a copy of the key/value pairs accumulated in the context of
+// the logger by WithFields.
+func (c *ContextLogger) Fields() []interface{} {
+	fields := contextFields(c.ctx)
+	if fields == nil {
+		return nil
+	}
+	return append([]interface{}(nil), fields...)
+}
+
+// With returns a copy of the logger logging the key/value pairs with every
+// entry, as WithFields.
+func (c *ContextLogger) With(keysAndValues ...interface{}) *ContextLogger {
+	return &ContextLogger{l: c.l, ctx: WithFields(c.ctx, keysAndValues...)}
+}
+
+// Critical logs a message of critical severity.
+func (c *ContextLogger) Critical(format string, args ...interface{}) {
+	c.l.zlog(c.ctx, LevelCritical, format, args, nil)
+}
+
+// Error logs a message of error severity.
+func (c *ContextLogger) Error(format string, args ...interface{}) {
+	c.l.zlog(c.ctx, LevelError, format, args, nil)
+}
+
+// Errorw logs a message with additional context.
+func (c *ContextLogger) Errorw(msg string, keysAndValues ...interface{}) {
+	c.l.zlog(c.ctx, LevelError, msg, nil, keysAndValues)
+}
+
+// Warn logs a message of warning severity.
+func (c *ContextLogger) Warn(format string, args ...interface{}) {
+	c.l.zlog(c.ctx, LevelWarn, format, args, nil)
+}
+
+// Info logs a message of informational severity.
+func (c *ContextLogger) Info(format string, args ...interface{}) {
+	c.l.zlog(c.ctx, LevelInfo, format, args, nil)
+}
+
+// Infow logs a message with additional context.
+func (c *ContextLogger) Infow(msg string, keysAndValues ...interface{}) {
+	c.l.zlog(c.ctx, LevelInfo, msg, nil, keysAndValues)
+}
+
+// Debug logs a message of debugging severity.
+func (c *ContextLogger) Debug(format string, args ...interface{}) {
+	c.l.zlog(c.ctx, LevelDebug, format, args, nil)
+}
@@ -0,0 +1,72 @@
+package logging
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/context"
+)
+
+func TestFrom(t *testing.T) {
+	l, buf := newTestLogger(t, &Config{})
+	r := gin.New()
+	r.Use(l.RequestLogger())
+	r.Use(func(c *gin.Context) {
+		c.Request = c.Request.WithContext(WithUserID(c.Request.Context(), "u-1"))
+	})
+	r.GET("/users/:id", func(c *gin.Context) {
+		From(c).With("order", "o-1").Infow("placed", "items", "3")
+	})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/users/1", nil))
+
+	got := entries(t, buf)
+	if len(got) != 2 {
+		t.Fatalf("got %d entries, want 2", len(got))
+	}
+	labels := labels(got[0])
+	for k, want := range map[string]string{
+		"request_id": w.Header().Get("X-Request-ID"),
+		"user_id":    "u-1",
+		"order":      "o-1",
+		"items":      "3",
+	} {
+		if labels[k] != want {
+			t.Errorf("got %s %v, want %s", k, labels[k], want)
+		}
+	}
+	source, _ := got[0]["logging.googleapis.com/sourceLocation"].(map[string]interface{})
+	if file, _ := source["file"].(string); !strings.HasSuffix(file, "scoped_test.go") {
+		t.Errorf("got source location %v, want the handler", source)
+	}
+}
+
+func TestFromContextWithoutMiddleware(t *testing.T) {
+	l, buf := newTestLogger(t, &Config{})
+	useStd(t, l)
+	FromContext(context.Background()).Warn("no %s", "middleware")
+	got := entries(t, buf)
+	if len(got) != 1 || got[0]["message"] != "no middleware" || got[0]["severity"] != "WARNING" {
+		t.Errorf("got %v, want the entry logged with the package level logger", got)
+	}
+}
+
+func TestFromContextFields(t *testing.T) {
+	l, buf := newTestLogger(t, &Config{})
+	useStd(t, l)
+	ctx := WithFields(context.Background(), "tenant", "acme", "order_id", "o-1")
+	FromContext(ctx).Info("placed")
+	FromContext(ctx).Errorw("charging failed", "attempt", 2)
+
+	got := entries(t, buf)
+	if len(got) != 2 {
+		t.Fatalf("got %d entries, want 2", len(got))
+	}
+	for _, e := range got {
+		if labels := labels(e); labels["tenant"] != "acme" || labels["order_id"] != "o-1" {
+			t.Errorf("got labels %v, want the accumulated fields", labels)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package logging
+
+import (
+	"fmt"
+
+	"github.com/blendle/zapdriver"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"golang.org/x/net/context"
+)
+
+// SecurityKind is the kind of a security event.
+type SecurityKind string
+
+// The kinds of security events with a standard class.
+const (
+	SecurityAuthFailure      SecurityKind = "auth_failure"
+	SecurityPermissionDenied SecurityKind = "permission_denied"
+	SecurityRateLimited      SecurityKind = "rate_limited"
+)
+
+// securityClass is the OCSF class of a kind of security events.
+type securityClass struct {
+	uid          int
+	name         string
+	categoryUID  int
+	categoryName string
+	activityName string
+}
+
+var securityClasses = map[SecurityKind]securityClass{
+	SecurityAuthFailure:      {3002, "Authentication", 3, "Identity & Access Management", "Logon"},
+	SecurityPermissionDenied: {3003, "Authorize Session", 3, "Identity & Access Management", "Other"},
+	SecurityRateLimited:      {6003, "API Activity", 6, "Application Activity", "Other"},
+}
+
+// securityEvent is the security field of a security event, laid out after
+// the Open Cybersecurity Schema Framework.
+type securityEvent struct {
+	kind    SecurityKind
+	userID  string
+	route   string
+	details map[string]interface{}
+}
+
+func (e securityEvent) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("type", string(e.kind))
+	if class, ok := securityClasses[e.kind]; ok {
+		enc.AddInt("class_uid", class.uid)
+		enc.AddString("class_name", class.name)
+		enc.AddInt("category_uid", class.categoryUID)
+		enc.AddString("category_name", class.categoryName)
+		enc.AddString("activity_name", class.activityName)
+	}
+	enc.AddString("status", "Failure")
+	enc.AddString("severity", "Medium")
+	if e.userID != "" {
+		enc.AddObject("actor", zapcore.ObjectMarshalerFunc(func(enc zapcore.ObjectEncoder) error {
+			return enc.AddObject("user", zapcore.ObjectMarshalerFunc(func(enc zapcore.ObjectEncoder) error {
+				enc.AddString("uid", e.userID)
+				return nil
+			}))
+		}))
+	}
+	if e.route != "" {
+		enc.AddObject("api", zapcore.ObjectMarshalerFunc(func(enc zapcore.ObjectEncoder) error {
+			enc.AddString("operation", e.route)
+			return nil
+		}))
+	}
+	if len(e.details) > 0 {
+		return enc.AddReflected("unmapped", e.details)
+	}
+	return nil
+}
+
+// SecurityEvent logs a security event with the package level logger, see
+// Logger.SecurityEvent.
+func SecurityEvent(ctx context.Context, kind SecurityKind, details ...interface{}) {
+	std().SecurityEvent(ctx, kind, details...)
+}
+
+// SecurityEvent logs a security event at warning level, such as a failed
+// authentication, a denied permission or a tripped rate limit, as the
+// security field of an entry labeled stream=security. The field follows the
+// Open Cybersecurity Schema Framework, the details key/value pairs being
+// unmapped attributes. Like audit events, security events are written to
+// Config.AuditOutput and never filtered by level nor sampled.
+func (l *Logger) SecurityEvent(ctx context.Context, kind SecurityKind, details ...interface{}) {
+	ctx = orBackground(ctx)
+	e := securityEvent{kind: kind}
+	e.userID, _ = l.loggedUserID(ctx)
+	if r, ok := ctx.Value(routeKey{}).(requestRoute); ok {
+		e.route = r.route
+	}
+	if len(details) > 1 {
+		e.details = make(map[string]interface{}, len(details)/2)
+		for i := 0; i+1 < len(details); i += 2 {
+			key, ok := details[i].(string)
+			if !ok {
+				continue
+			}
+			if err, isErr := details[i+1].(error); isErr {
+				e.details[key] = err.Error()
+			} else {
+				e.details[key] = details[i+1]
+			}
+		}
+	}
+	fields, _, _ := l.entryFields(ctx)
+	fields = append(fields, zapdriver.Label(streamLabel, "security"), zap.Object("security", e))
+	msg, fields := l.redactor.entry(fmt.Sprintf("security event: %s", kind), fields)
+	msg, fields = l.truncator.entry(msg, fields)
+	l.audit().Warn(msg, fields...)
+}
@@ -0,0 +1,40 @@
+package logging
+
+import (
+	"errors"
+	"testing"
+
+	"golang.org/x/net/context"
+)
+
+func TestSecurityEvent(t *testing.T) {
+	l, buf := newTestLogger(t, &Config{Level: LevelError})
+	ctx := withRoute(WithUserID(context.Background(), "u-1"), "/admin/users", "/admin/users")
+	l.SecurityEvent(ctx, SecurityPermissionDenied, "permission", "users.delete", "reason", errors.New("missing role"))
+	l.SecurityEvent(context.Background(), SecurityKind("token_reuse"))
+
+	got := entries(t, buf)
+	if len(got) != 2 {
+		t.Fatalf("got %d entries, want 2", len(got))
+	}
+	e := got[0]
+	security, _ := e["security"].(map[string]interface{})
+	if e["severity"] != "WARNING" || e["message"] != "security event: permission_denied" || labels(e)["stream"] != "security" {
+		t.Errorf("got %v, want a security event", e)
+	}
+	if security["type"] != "permission_denied" || security["class_uid"] != 3003.0 || security["category_uid"] != 3.0 || security["status"] != "Failure" {
+		t.Errorf("got %v, want the class of the event", security)
+	}
+	actor, _ := security["actor"].(map[string]interface{})
+	user, _ := actor["user"].(map[string]interface{})
+	api, _ := security["api"].(map[string]interface{})
+	unmapped, _ := security["unmapped"].(map[string]interface{})
+	if user["uid"] != "u-1" || api["operation"] != "/admin/users" || unmapped["permission"] != "users.delete" || unmapped["reason"] != "missing role" {
+		t.Errorf("got %v, want the actor, operation and details", security)
+	}
+
+	custom, _ := got[1]["security"].(map[string]interface{})
+	if custom["type"] != "token_reuse" || custom["class_uid"] != nil || custom["actor"] != nil {
+		t.Errorf("got %v, want a custom event without class", custom)
+	}
+}
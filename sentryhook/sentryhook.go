@@ -0,0 +1,85 @@
+// Package sentryhook forwards the error entries of the logging package to
+// Sentry.
+package sentryhook
+
+import (
+	"context"
+	"reflect"
+	"runtime"
+
+	"github.com/cyoyu/logging"
+	"github.com/getsentry/sentry-go"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Hook is a logging.ErrorHook capturing entries as Sentry events. It uses
+// the hub carried by the context, or the current hub.
+type Hook struct{}
+
+// New returns a Sentry hook. Register it with logging.AddErrorHook once
+// Sentry has been initialized.
+func New() *Hook {
+	return &Hook{}
+}
+
+// Fire captures the entry as a Sentry event.
+func (h *Hook) Fire(ctx context.Context, e *logging.Entry) {
+	hub := sentry.GetHubFromContext(ctx)
+	if hub == nil {
+		hub = sentry.CurrentHub()
+	}
+
+	event := sentry.NewEvent()
+	event.Level = sentry.LevelError
+	if e.Level != logging.LevelError {
+		event.Level = sentry.LevelFatal
+	}
+	event.Message = e.Message
+	event.Timestamp = e.Time
+	if e.RequestID != "" {
+		event.Tags["request_id"] = e.RequestID
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+		event.Contexts["trace"] = sentry.Context{"trace_id": sc.TraceID().String(), "span_id": sc.SpanID().String()}
+	}
+	if e.UserID != "" {
+		event.User.ID = e.UserID
+	}
+
+	stacktrace := stacktraceFromPCs(e.Stack)
+	if e.Err != nil {
+		if errStacktrace := sentry.ExtractStacktrace(e.Err); errStacktrace != nil {
+			stacktrace = errStacktrace
+		}
+		event.Exception = []sentry.Exception{{
+			Type:       reflect.TypeOf(e.Err).String(),
+			Value:      e.Err.Error(),
+			Stacktrace: stacktrace,
+		}}
+	} else {
+		event.Threads = []sentry.Thread{{
+			Stacktrace: stacktrace,
+			Current:    true,
+		}}
+	}
+
+	hub.CaptureEvent(event)
+}
+
+// stacktraceFromPCs builds a Sentry stack trace, which lists the outermost
+// frame first.
+func stacktraceFromPCs(pcs []uintptr) *sentry.Stacktrace {
+	if len(pcs) == 0 {
+		return nil
+	}
+	var frames []sentry.Frame
+	callers := runtime.CallersFrames(pcs)
+	for {
+		frame, more := callers.Next()
+		frames = append([]sentry.Frame{sentry.NewFrame(frame)}, frames...)
+		if !more {
+			break
+		}
+	}
+	return &sentry.Stacktrace{Frames: frames}
+}
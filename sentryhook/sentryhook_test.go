@@ -0,0 +1,72 @@
+package sentryhook
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cyoyu/logging"
+	"github.com/getsentry/sentry-go"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/net/context"
+)
+
+// transport records the events sent to Sentry.
+type transport struct {
+	mu     sync.Mutex
+	events []*sentry.Event
+}
+
+func (t *transport) Configure(sentry.ClientOptions) {}
+
+func (t *transport) Flush(time.Duration) bool { return true }
+
+func (t *transport) SendEvent(event *sentry.Event) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.events = append(t.events, event)
+}
+
+func TestHook(t *testing.T) {
+	tr := &transport{}
+	client, err := sentry.NewClient(sentry.ClientOptions{Dsn: "https://key@sentry.example.com/1", Transport: tr})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err := logging.New(&logging.Config{ProjectID: "test", Level: logging.LevelDebug, Output: io.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.AddErrorHook(New())
+
+	traceID := trace.TraceID{1}
+	spanID := trace.SpanID{2}
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: spanID}))
+	ctx = sentry.SetHubOnContext(logging.WithUserID(ctx, "u1"), sentry.NewHub(client, sentry.NewScope()))
+	l.Info(ctx, "not an error")
+	l.Errorw(ctx, "charging failed", "error", errors.New("card declined"))
+
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	if len(tr.events) != 1 {
+		t.Fatalf("got %d events, want the error entry only", len(tr.events))
+	}
+	event := tr.events[0]
+	if event.Level != sentry.LevelError || event.Message != "charging failed" {
+		t.Errorf("got event %v %q, want the error entry", event.Level, event.Message)
+	}
+	if got := event.Tags["request_id"]; got != traceID.String() {
+		t.Errorf("got request_id tag %q, want the trace ID %s", got, traceID)
+	}
+	if got := event.Contexts["trace"]["trace_id"]; got != traceID.String() {
+		t.Errorf("got trace context %v, want the trace ID %s", event.Contexts["trace"], traceID)
+	}
+	if event.User.ID != "u1" {
+		t.Errorf("got user %q, want u1", event.User.ID)
+	}
+	if len(event.Exception) != 1 || event.Exception[0].Value != "card declined" {
+		t.Errorf("got exceptions %v, want the error", event.Exception)
+	}
+}
@@ -0,0 +1,72 @@
+package logging
+
+import (
+	"sort"
+
+	"github.com/blendle/zapdriver"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// serviceContextKey is the key of the serviceContext field identifying the
+// service that logged an entry, as laid out by Cloud Error Reporting.
+const serviceContextKey = "serviceContext"
+
+// serviceContext is the serviceContext field of the entries. Unlike
+// zapdriver.ServiceContext it also carries the version of the service.
+type serviceContext struct {
+	name    string
+	version string
+}
+
+func (s serviceContext) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("service", s.name)
+	if s.version != "" {
+		enc.AddString("version", s.version)
+	}
+	return nil
+}
+
+// staticFields returns the fields identifying the service, its version and
+// environment and the static labels logged on every entry. The entries of
+// Config.Format have service, version and environment fields, the others a
+// serviceContext field and an environment label.
+func staticFields(c *Config) []zapcore.Field {
+	if c == nil {
+		return nil
+	}
+	var fields []zapcore.Field
+	if c.Format != "" {
+		if c.ServiceName != "" {
+			fields = append(fields, zap.String("service", c.ServiceName))
+		}
+		if c.ServiceVersion != "" {
+			fields = append(fields, zap.String("version", c.ServiceVersion))
+		}
+		if c.Environment != "" {
+			fields = append(fields, zap.String("environment", c.Environment))
+		}
+	} else {
+		if c.ServiceName != "" {
+			fields = append(fields, zap.Object(serviceContextKey, serviceContext{c.ServiceName, c.ServiceVersion}))
+		}
+		if c.Environment != "" {
+			fields = append(fields, zapdriver.Label("environment", c.Environment))
+		}
+	}
+	return append(fields, labelFields(c.Labels)...)
+}
+
+// labelFields returns the labels sorted by key.
+func labelFields(labels map[string]string) []zapcore.Field {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	fields := make([]zapcore.Field, 0, len(keys))
+	for _, k := range keys {
+		fields = append(fields, zapdriver.Label(k, labels[k]))
+	}
+	return fields
+}
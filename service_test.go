@@ -0,0 +1,37 @@
+package logging
+
+import (
+	"testing"
+
+	"golang.org/x/net/context"
+)
+
+func TestStaticFields(t *testing.T) {
+	l, buf := newTestLogger(t, &Config{
+		ServiceName:    "api",
+		ServiceVersion: "1.2.0",
+		Environment:    "prod",
+		Labels:         map[string]string{"team": "payments"},
+		ErrorReporting: true,
+	})
+	ctx := context.Background()
+	l.Info(ctx, "started")
+	l.Error(ctx, "failed")
+	if err := l.Audit(ctx, AuditEvent{Actor: "u1", Action: "invoice.update", Resource: "invoices/1", Outcome: AuditSuccess}); err != nil {
+		t.Fatal(err)
+	}
+
+	got := entries(t, buf)
+	if len(got) != 3 {
+		t.Fatalf("got %d entries, want 3", len(got))
+	}
+	for _, e := range got {
+		service, _ := e[serviceContextKey].(map[string]interface{})
+		if service["service"] != "api" || service["version"] != "1.2.0" {
+			t.Errorf("got service context %v, want api 1.2.0", e[serviceContextKey])
+		}
+		if lbls := labels(e); lbls["environment"] != "prod" || lbls["team"] != "payments" {
+			t.Errorf("got labels %v, want the environment and team", lbls)
+		}
+	}
+}
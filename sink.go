@@ -0,0 +1,91 @@
+package logging
+
+import (
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap/zapcore"
+)
+
+// Sink is an output implemented in another package, so that its client and
+// dependencies are only pulled in by the programs using it, such as
+// cloudwatchsink. Sinks are added to Config.Sinks.
+type Sink interface {
+	// Open returns a core writing to the output, and the function flushing
+	// and closing it when the logger is closed.
+	Open(s *SinkContext) (zapcore.Core, func(), error)
+}
+
+// SinkContext is what a Sink is opened with.
+type SinkContext struct {
+	// ProjectID is the project ID of the logger.
+	ProjectID string
+	// Level enables the entries at or above the level of the logger.
+	Level zapcore.LevelEnabler
+	// Encoder encodes the entries as JSON, with plain level names.
+	Encoder zapcore.Encoder
+
+	l *Logger
+}
+
+// BatchEntry is an entry encoded by a batch core.
+type BatchEntry struct {
+	Time time.Time
+	// RequestID is empty for the entries logged outside of a request.
+	RequestID string
+	Line      []byte
+}
+
+// BatchCore returns a core sending the entries with send in batches of size
+// entries, once a batch is full or every interval, from a background
+// goroutine, and the function sending the remaining entries. They default to
+// 100 entries and a second. The entries of the batches send fails on are
+// written to the standard error output instead, and counted by OutputErrors.
+func (s *SinkContext) BatchCore(size int, interval time.Duration, send func([]BatchEntry) error) (zapcore.Core, func()) {
+	b := newBatcher(size, interval, send, func(e BatchEntry) []byte { return e.Line })
+	core := &sinkCore{
+		LevelEnabler: s.Level,
+		enc:          s.Encoder,
+		write: func(ent zapcore.Entry, labels map[string]string, line []byte) error {
+			e := BatchEntry{Time: ent.Time, Line: line}
+			if requestID := labels[s.l.keyRequestID]; validRequestID(requestID) {
+				e.RequestID = requestID
+			}
+			b.add(e)
+			return nil
+		},
+		sync: b.flush,
+	}
+	return core, func() { b.close() }
+}
+
+// Labels returns the labels among the fields of an entry, for the cores
+// encoding them apart.
+func (s *SinkContext) Labels(fieldSets ...[]zapcore.Field) map[string]string {
+	return entryLabels(fieldSets...)
+}
+
+// LinksTrace reports whether the entries logged in a span are linked to its
+// trace, only the sampled traces being linked with Config.SampledTracesOnly.
+func (s *SinkContext) LinksTrace(sc trace.SpanContext) bool {
+	return s.l.linksTrace(sc)
+}
+
+// sinkCores opens the sinks, registering their closing.
+func (l *Logger) sinkCores(sinks []Sink, encoderConfig zapcore.EncoderConfig) ([]zapcore.Core, error) {
+	var cores []zapcore.Core
+	for _, sink := range sinks {
+		core, closeCore, err := sink.Open(&SinkContext{
+			ProjectID: l.projectID,
+			Level:     l.coreLevel,
+			Encoder:   zapcore.NewJSONEncoder(plainEncoderConfig(encoderConfig)),
+			l:         l,
+		})
+		if err != nil {
+			return nil, err
+		}
+		l.closers = append(l.closers, closeCore)
+		cores = append(cores, core)
+	}
+	return cores, nil
+}
@@ -0,0 +1,105 @@
+package logging
+
+import (
+	"log/slog"
+	"strings"
+
+	"golang.org/x/net/context"
+)
+
+// slogHandler is a slog.Handler logging the records with a Logger, the
+// package level logger if l is nil.
+type slogHandler struct {
+	l *Logger
+	// attrs are the key/value pairs of the attributes added with WithAttrs.
+	attrs []interface{}
+	// prefix qualifies the keys of the attributes with the open groups.
+	prefix string
+}
+
+// NewSlogHandler returns a slog.Handler logging the records with the package
+// level logger, so the entries of libraries logging with log/slog are
+// filtered, encoded and linked to their trace as the others. The attributes
+// are logged as key/value pairs, the keys of the attributes of groups being
+// qualified by the group names, e.g. "http.method".
+func NewSlogHandler() slog.Handler {
+	return &slogHandler{}
+}
+
+// SlogHandler returns a slog.Handler logging the records with l.
+func (l *Logger) SlogHandler() slog.Handler {
+	return &slogHandler{l: l}
+}
+
+// slogLevel maps a slog level to the level of the entries.
+func slogLevel(level slog.Level) Level {
+	switch {
+	case level < slog.LevelInfo:
+		return LevelDebug
+	case level < slog.LevelWarn:
+		return LevelInfo
+	case level < slog.LevelError:
+		return LevelWarn
+	default:
+		return LevelError
+	}
+}
+
+func (h *slogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return orStd(h.l).Enabled(ctx, slogLevel(level))
+}
+
+func (h *slogHandler) Handle(ctx context.Context, r slog.Record) error {
+	ctx = orBackground(ctx)
+	keysAndValues := make([]interface{}, len(h.attrs), len(h.attrs)+2*r.NumAttrs())
+	copy(keysAndValues, h.attrs)
+	r.Attrs(func(a slog.Attr) bool {
+		keysAndValues = appendSlogAttr(keysAndValues, h.prefix, a)
+		return true
+	})
+	// The message is the format, for the warnings to be escalated per
+	// message.
+	format := strings.ReplaceAll(r.Message, "%", "%%")
+	orStd(h.l).zlogAt(ctx, slogLevel(r.Level), r.PC, format, nil, keysAndValues)
+	return nil
+}
+
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	clone := *h
+	clone.attrs = h.attrs[:len(h.attrs):len(h.attrs)]
+	for _, a := range attrs {
+		clone.attrs = appendSlogAttr(clone.attrs, h.prefix, a)
+	}
+	return &clone
+}
+
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	clone := *h
+	clone.prefix = h.prefix + name + "."
+	return &clone
+}
+
+// appendSlogAttr appends the key/value pairs of an attribute, flattening
+// groups.
+func appendSlogAttr(keysAndValues []interface{}, prefix string, a slog.Attr) []interface{} {
+	v := a.Value.Resolve()
+	if v.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, a := range v.Group() {
+			keysAndValues = appendSlogAttr(keysAndValues, prefix, a)
+		}
+		return keysAndValues
+	}
+	if a.Key == "" {
+		return keysAndValues
+	}
+	return append(keysAndValues, prefix+a.Key, v.Any())
+}
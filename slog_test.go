@@ -0,0 +1,60 @@
+package logging
+
+import (
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/net/context"
+)
+
+func TestSlogHandler(t *testing.T) {
+	l, buf := newTestLogger(t, &Config{Level: LevelInfo})
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID}))
+	logger := slog.New(l.SlogHandler()).With("component", "cache").WithGroup("http")
+	logger.DebugContext(ctx, "skipped")
+	logger.InfoContext(ctx, "100% hit", "method", "GET", slog.Group("req", "id", 7))
+	slog.New(l.SlogHandler()).ErrorContext(ctx, "miss", "error", errors.New("boom"))
+
+	got := entries(t, buf)
+	if len(got) != 2 {
+		t.Fatalf("got %d entries, want 2", len(got))
+	}
+	info := got[0]
+	if info["message"] != "100% hit" || info["severity"] != "INFO" {
+		t.Errorf("got %v %v, want INFO 100%% hit", info["severity"], info["message"])
+	}
+	for key, want := range map[string]string{"component": "cache", "http.method": "GET", "http.req.id": "7"} {
+		if got := labels(info)[key]; got != want {
+			t.Errorf("got %s %q, want %q", key, got, want)
+		}
+	}
+	if info["logging.googleapis.com/trace"] != "projects/test/traces/4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("got trace %v, want the trace of the context", info["logging.googleapis.com/trace"])
+	}
+	loc, _ := info["logging.googleapis.com/sourceLocation"].(map[string]interface{})
+	if file, _ := loc["file"].(string); !strings.HasSuffix(file, "slog_test.go") {
+		t.Errorf("got source file %v, want the caller", loc["file"])
+	}
+	if got[1]["severity"] != "ERROR" || labels(got[1])["err"] != "boom" {
+		t.Errorf("got %v err %v, want ERROR boom", got[1]["severity"], labels(got[1])["err"])
+	}
+}
+
+func TestSlogLevel(t *testing.T) {
+	for level, want := range map[slog.Level]Level{
+		slog.LevelDebug:     LevelDebug,
+		slog.LevelInfo:      LevelInfo,
+		slog.LevelInfo + 2:  LevelInfo,
+		slog.LevelWarn:      LevelWarn,
+		slog.LevelError:     LevelError,
+		slog.LevelError + 4: LevelError,
+	} {
+		if got := slogLevel(level); got != want {
+			t.Errorf("slogLevel(%v) = %v, want %v", level, got, want)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package logging
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap/zapcore"
+	"golang.org/x/net/context"
+)
+
+// recordSpanEvent adds an entry logged with ctx to its recording span as an
+// event, with the fields as attributes, if the entry is at least at the span
+// event level. Entries of error severity also set the status of the span.
+func (l *Logger) recordSpanEvent(ctx context.Context, level Level, msg string, err error, fields []zapcore.Field) {
+	if level.zapLevel() < l.spanEventLevel.zapLevel() {
+		return
+	}
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return
+	}
+	attrs := append(spanAttributes(fields), attribute.String("log.severity", level.String()))
+	opts := []trace.EventOption{trace.WithAttributes(attrs...), trace.WithTimestamp(l.clock.Now())}
+	if level.zapLevel() < zapcore.ErrorLevel {
+		span.AddEvent(msg, opts...)
+		return
+	}
+	if err != nil {
+		span.RecordError(err, opts...)
+	} else {
+		span.AddEvent(msg, opts...)
+	}
+	span.SetStatus(codes.Error, msg)
+}
+
+// spanAttributes converts the fields of an entry to span attributes, the
+// labels being attributes as well.
+func spanAttributes(fields []zapcore.Field) []attribute.KeyValue {
+	m := zapcore.NewMapObjectEncoder()
+	for _, f := range fields {
+		if f.Type == zapcore.SkipType || strings.HasPrefix(f.Key, zapdriverPrefix) {
+			continue
+		}
+		f.AddTo(m)
+	}
+	attrs := make([]attribute.KeyValue, 0, len(m.Fields))
+	for k, v := range m.Fields {
+		k = strings.TrimPrefix(k, "labels.")
+		switch v := v.(type) {
+		case string:
+			attrs = append(attrs, attribute.String(k, v))
+		case bool:
+			attrs = append(attrs, attribute.Bool(k, v))
+		case int:
+			attrs = append(attrs, attribute.Int(k, v))
+		case int64:
+			attrs = append(attrs, attribute.Int64(k, v))
+		case int32:
+			attrs = append(attrs, attribute.Int64(k, int64(v)))
+		case uint32:
+			attrs = append(attrs, attribute.Int64(k, int64(v)))
+		case float64:
+			attrs = append(attrs, attribute.Float64(k, v))
+		case float32:
+			attrs = append(attrs, attribute.Float64(k, float64(v)))
+		case time.Duration:
+			attrs = append(attrs, attribute.String(k, v.String()))
+		case time.Time:
+			attrs = append(attrs, attribute.String(k, v.Format(time.RFC3339Nano)))
+		default:
+			attrs = append(attrs, attribute.String(k, fmt.Sprint(v)))
+		}
+	}
+	return attrs
+}
@@ -0,0 +1,51 @@
+package logging
+
+import (
+	"errors"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"golang.org/x/net/context"
+)
+
+func TestSpanEvents(t *testing.T) {
+	l, _ := newTestLogger(t, &Config{SpanEventLevel: LevelWarn})
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	ctx, span := tp.Tracer("test").Start(context.Background(), "request")
+	l.Info(ctx, "ignored")
+	l.Warn(ctx, "slow query")
+	l.Errorw(ctx, "query failed", "table", "users", "error", errors.New("timeout"))
+	span.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("got %d spans, want 1", len(spans))
+	}
+	events := spans[0].Events()
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2", len(events))
+	}
+	if events[0].Name != "slow query" || !hasAttribute(events[0].Attributes, attribute.String("log.severity", "warn")) {
+		t.Errorf("got event %s %v, want the warning", events[0].Name, events[0].Attributes)
+	}
+	if events[1].Name != "exception" || !hasAttribute(events[1].Attributes, attribute.String("table", "users")) ||
+		!hasAttribute(events[1].Attributes, attribute.String("exception.message", "timeout")) {
+		t.Errorf("got event %s %v, want the error recorded with its fields", events[1].Name, events[1].Attributes)
+	}
+	if status := spans[0].Status(); status.Code != codes.Error || status.Description != "query failed" {
+		t.Errorf("got status %v, want an error", status)
+	}
+}
+
+func hasAttribute(attrs []attribute.KeyValue, want attribute.KeyValue) bool {
+	for _, a := range attrs {
+		if a == want {
+			return true
+		}
+	}
+	return false
+}
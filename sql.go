@@ -0,0 +1,337 @@
+package logging
+
+import (
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/blendle/zapdriver"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"golang.org/x/net/context"
+)
+
+// SQLDriver wraps a database/sql driver to log the statements it runs with
+// the package level logger, for use with sql.Register.
+func SQLDriver(d driver.Driver) driver.Driver {
+	return &sqlDriver{d: d}
+}
+
+// SQLDriver wraps a database/sql driver to log the statements it runs with
+// l, for use with sql.Register.
+func (l *Logger) SQLDriver(d driver.Driver) driver.Driver {
+	return &sqlDriver{l: l, d: d}
+}
+
+// SQLConnector wraps a database/sql connector to log the statements it runs
+// with the package level logger, for use with sql.OpenDB.
+func SQLConnector(c driver.Connector) driver.Connector {
+	return &sqlConnector{c: c, d: &sqlDriver{d: c.Driver()}}
+}
+
+// SQLConnector wraps a database/sql connector to log the statements it runs
+// with l, for use with sql.OpenDB.
+func (l *Logger) SQLConnector(c driver.Connector) driver.Connector {
+	return &sqlConnector{c: c, d: &sqlDriver{l: l, d: c.Driver()}}
+}
+
+// LogQuery logs a statement under key, e.g. "sql", as SQLDriver does: at
+// debug level, or at warning level if it is slower than Config.SlowQuery and
+// at error level if it failed. rows is the number of rows affected, if
+// known, or -1. It is used by the adapters of other clients, such as gormlog
+// and redishook.
+func (l *Logger) LogQuery(ctx context.Context, msg, key, statement string, rows int64, err error, latency time.Duration, extra ...zapcore.Field) {
+	level := LevelDebug
+	var fields []zapcore.Field
+	switch {
+	case err != nil:
+		level = LevelError
+	case l.SlowQuery(latency):
+		level = LevelWarn
+		fields = append(fields, zapdriver.Label("slow_query", "true"))
+	}
+	if level.zapLevel() >= zapcore.ErrorLevel {
+		l.flushDebug(ctx)
+	}
+	if !l.enabled(ctx, level) {
+		return
+	}
+	pc, file, line, ok := queryCaller()
+	fields = append(fields,
+		zapdriver.SourceLocation(pc, file, line, ok),
+		zap.String(key, statement),
+		zap.Int64("duration_ms", latency.Milliseconds()),
+	)
+	if rows >= 0 {
+		fields = append(fields, zap.Int64("rows", rows))
+	}
+	if err != nil {
+		fields = append(fields, zap.NamedError(l.keyError, err))
+	}
+	l.zentry(ctx, level, msg, "", append(fields, extra...))
+}
+
+// SlowQuery reports whether a statement taking latency is slower than
+// Config.SlowQuery.
+func (l *Logger) SlowQuery(latency time.Duration) bool {
+	return l.slowQuery > 0 && latency >= l.slowQuery
+}
+
+// packagePath is the import path of this package.
+var packagePath = reflect.TypeOf(Logger{}).PkgPath()
+
+// queryCaller returns the location of the code running a statement, the
+// first frame out of database/sql, GORM, go-redis and their adapters.
+func queryCaller() (uintptr, string, int, bool) {
+	pcs := make([]uintptr, 64)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs)])
+	for {
+		frame, more := frames.Next()
+		if !queryFrame(frame) {
+			return frame.PC, frame.File, frame.Line, true
+		}
+		if !more {
+			return 0, "", 0, false
+		}
+	}
+}
+
+// queryFrame reports whether the frame runs statements for the caller.
+func queryFrame(frame runtime.Frame) bool {
+	for _, prefix := range []string{"database/sql.", "gorm.io/", "github.com/redis/go-redis/", "runtime.", packagePath + "/gormlog.", packagePath + "/redishook."} {
+		if strings.HasPrefix(frame.Function, prefix) {
+			return true
+		}
+	}
+	return strings.HasPrefix(frame.Function, packagePath+".") && strings.HasSuffix(frame.File, "/sql.go")
+}
+
+type sqlDriver struct {
+	l *Logger
+	d driver.Driver
+}
+
+func (d *sqlDriver) Open(name string) (driver.Conn, error) {
+	conn, err := d.d.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &sqlConn{l: d.l, conn: conn}, nil
+}
+
+type sqlConnector struct {
+	c driver.Connector
+	d *sqlDriver
+}
+
+func (c *sqlConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.c.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &sqlConn{l: c.d.l, conn: conn}, nil
+}
+
+func (c *sqlConnector) Driver() driver.Driver {
+	return c.d
+}
+
+// sqlConn logs the statements run on a connection, the optional interfaces
+// of the wrapped connection being skipped when it doesn't implement them.
+type sqlConn struct {
+	l    *Logger
+	conn driver.Conn
+}
+
+func (c *sqlConn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *sqlConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var stmt driver.Stmt
+	var err error
+	if p, ok := c.conn.(driver.ConnPrepareContext); ok {
+		stmt, err = p.PrepareContext(ctx, query)
+	} else {
+		stmt, err = c.conn.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &sqlStmt{l: c.l, stmt: stmt, query: query}, nil
+}
+
+func (c *sqlConn) Close() error {
+	return c.conn.Close()
+}
+
+func (c *sqlConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *sqlConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if b, ok := c.conn.(driver.ConnBeginTx); ok {
+		return b.BeginTx(ctx, opts)
+	}
+	if opts.Isolation != driver.IsolationLevel(0) || opts.ReadOnly {
+		return nil, errors.New("logging: the driver does not support transaction options")
+	}
+	return c.conn.Begin()
+}
+
+func (c *sqlConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	e, ok := c.conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	l := orStd(c.l)
+	start := l.clock.Now()
+	res, err := e.ExecContext(ctx, query, args)
+	if errors.Is(err, driver.ErrSkip) {
+		return nil, err
+	}
+	l.LogQuery(ctx, "sql query", "sql", query, rowsAffected(res, err), err, l.since(start))
+	return res, err
+}
+
+func (c *sqlConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	q, ok := c.conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	l := orStd(c.l)
+	start := l.clock.Now()
+	rows, err := q.QueryContext(ctx, query, args)
+	if errors.Is(err, driver.ErrSkip) {
+		return nil, err
+	}
+	l.LogQuery(ctx, "sql query", "sql", query, -1, err, l.since(start))
+	return rows, err
+}
+
+func (c *sqlConn) Ping(ctx context.Context) error {
+	if p, ok := c.conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *sqlConn) ResetSession(ctx context.Context) error {
+	if r, ok := c.conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *sqlConn) IsValid() bool {
+	if v, ok := c.conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+func (c *sqlConn) CheckNamedValue(v *driver.NamedValue) error {
+	if n, ok := c.conn.(driver.NamedValueChecker); ok {
+		return n.CheckNamedValue(v)
+	}
+	return driver.ErrSkip
+}
+
+// sqlStmt logs the executions of a prepared statement.
+type sqlStmt struct {
+	l     *Logger
+	stmt  driver.Stmt
+	query string
+}
+
+func (s *sqlStmt) Close() error {
+	return s.stmt.Close()
+}
+
+func (s *sqlStmt) NumInput() int {
+	return s.stmt.NumInput()
+}
+
+func (s *sqlStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.ExecContext(context.Background(), namedValues(args))
+}
+
+func (s *sqlStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.QueryContext(context.Background(), namedValues(args))
+}
+
+func (s *sqlStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	l := orStd(s.l)
+	start := l.clock.Now()
+	var res driver.Result
+	var err error
+	if e, ok := s.stmt.(driver.StmtExecContext); ok {
+		res, err = e.ExecContext(ctx, args)
+	} else if values, convErr := driverValues(args); convErr != nil {
+		return nil, convErr
+	} else {
+		res, err = s.stmt.Exec(values)
+	}
+	l.LogQuery(ctx, "sql query", "sql", s.query, rowsAffected(res, err), err, l.since(start))
+	return res, err
+}
+
+func (s *sqlStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	l := orStd(s.l)
+	start := l.clock.Now()
+	var rows driver.Rows
+	var err error
+	if q, ok := s.stmt.(driver.StmtQueryContext); ok {
+		rows, err = q.QueryContext(ctx, args)
+	} else if values, convErr := driverValues(args); convErr != nil {
+		return nil, convErr
+	} else {
+		rows, err = s.stmt.Query(values)
+	}
+	l.LogQuery(ctx, "sql query", "sql", s.query, -1, err, l.since(start))
+	return rows, err
+}
+
+func (s *sqlStmt) CheckNamedValue(v *driver.NamedValue) error {
+	if n, ok := s.stmt.(driver.NamedValueChecker); ok {
+		return n.CheckNamedValue(v)
+	}
+	return driver.ErrSkip
+}
+
+// rowsAffected returns the number of rows affected by a statement, or -1 if
+// it isn't known.
+func rowsAffected(res driver.Result, err error) int64 {
+	if err != nil || res == nil {
+		return -1
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return -1
+	}
+	return rows
+}
+
+func namedValues(args []driver.Value) []driver.NamedValue {
+	named := make([]driver.NamedValue, len(args))
+	for i, v := range args {
+		named[i] = driver.NamedValue{Ordinal: i + 1, Value: v}
+	}
+	return named
+}
+
+// driverValues returns the values of positional arguments, for drivers
+// without support for named ones.
+func driverValues(args []driver.NamedValue) ([]driver.Value, error) {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		if arg.Name != "" {
+			return nil, errors.New("logging: the driver does not support named arguments")
+		}
+		values[i] = arg.Value
+	}
+	return values, nil
+}
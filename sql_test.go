@@ -0,0 +1,156 @@
+package logging
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// testDriver is a driver whose connections only support prepared
+// statements, failing the statements containing "fail".
+type testDriver struct{}
+
+func (testDriver) Open(string) (driver.Conn, error) {
+	return testConn{}, nil
+}
+
+type testConn struct{}
+
+func (testConn) Prepare(query string) (driver.Stmt, error) {
+	return testStmt(query), nil
+}
+
+func (testConn) Close() error {
+	return nil
+}
+
+func (testConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("not supported")
+}
+
+type testStmt string
+
+func (testStmt) Close() error {
+	return nil
+}
+
+func (testStmt) NumInput() int {
+	return -1
+}
+
+func (s testStmt) Exec([]driver.Value) (driver.Result, error) {
+	if s == "fail" {
+		return nil, errors.New("syntax error")
+	}
+	if s == "slow" {
+		time.Sleep(10 * time.Millisecond)
+	}
+	return driver.RowsAffected(3), nil
+}
+
+func (testStmt) Query([]driver.Value) (driver.Rows, error) {
+	return testRows{}, nil
+}
+
+type testRows struct{}
+
+func (testRows) Columns() []string {
+	return []string{"id"}
+}
+
+func (testRows) Close() error {
+	return nil
+}
+
+func (testRows) Next([]driver.Value) error {
+	return io.EOF
+}
+
+// testConnector connects to testDriver.
+type testConnector struct{}
+
+func (testConnector) Connect(context.Context) (driver.Conn, error) {
+	return testConn{}, nil
+}
+
+func (testConnector) Driver() driver.Driver {
+	return testDriver{}
+}
+
+func TestSQLDriver(t *testing.T) {
+	l, buf := newTestLogger(t, &Config{Level: LevelDebug, SlowQuery: 5 * time.Millisecond})
+	sql.Register("logging-test", l.SQLDriver(testDriver{}))
+	db, err := sql.Open("logging-test", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	ctx := WithRequestID(context.Background(), "4bf92f3577b34da6a3ce929d0e0e4736")
+	if _, err := db.ExecContext(ctx, "update users set name = ?", "a"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.ExecContext(ctx, "fail"); err == nil {
+		t.Fatal("got no error")
+	}
+	if _, err := db.ExecContext(ctx, "slow"); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := db.QueryContext(ctx, "select id from users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows.Close()
+
+	got := entries(t, buf)
+	if len(got) != 4 {
+		t.Fatalf("got %d entries, want 4", len(got))
+	}
+	for i, want := range []struct {
+		severity, sql string
+		rows          interface{}
+		err           interface{}
+	}{
+		{"DEBUG", "update users set name = ?", 3.0, nil},
+		{"ERROR", "fail", nil, "syntax error"},
+		{"WARNING", "slow", 3.0, nil},
+		{"DEBUG", "select id from users", nil, nil},
+	} {
+		e := got[i]
+		if e["severity"] != want.severity || e["sql"] != want.sql || e["rows"] != want.rows || e["err"] != want.err {
+			t.Errorf("entry %d: got %v, want %+v", i, e, want)
+		}
+		if labels(e)["request_id"] != "4bf92f3577b34da6a3ce929d0e0e4736" {
+			t.Errorf("entry %d: got labels %v, want the request ID", i, labels(e))
+		}
+		source, _ := e["logging.googleapis.com/sourceLocation"].(map[string]interface{})
+		if file, _ := source["file"].(string); len(file) < 12 || file[len(file)-12:] != "/sql_test.go" {
+			t.Errorf("entry %d: got source location %v, want the test", i, source)
+		}
+	}
+	if labels(got[2])["slow_query"] != "true" {
+		t.Errorf("got labels %v, want the slow query label", labels(got[2]))
+	}
+}
+
+func TestSQLConnector(t *testing.T) {
+	l, buf := newTestLogger(t, &Config{Level: LevelInfo})
+	db := sql.OpenDB(l.SQLConnector(testConnector{}))
+	defer db.Close()
+	if _, err := db.Exec("update users set name = ?", "a"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec("fail"); err == nil {
+		t.Fatal("got no error")
+	}
+
+	got := entries(t, buf)
+	if len(got) != 1 || got[0]["sql"] != "fail" {
+		t.Errorf("got %v, want the failed statement only", got)
+	}
+}
@@ -0,0 +1,120 @@
+package logging
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// keyStackTrace is the payload field Cloud Error Reporting reads stack
+// traces from.
+const keyStackTrace = "stack_trace"
+
+// errorStack returns the stack trace recorded by the innermost error of the
+// chain carrying one, such as those created by github.com/pkg/errors,
+// formatted like a Go panic so that Error Reporting can group it.
+func errorStack(err error) (string, bool) {
+	var pcs []uintptr
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		if stack := stackTrace(e); len(stack) > 0 {
+			pcs = stack
+		}
+	}
+	if len(pcs) == 0 {
+		return "", false
+	}
+	return formatStack(err.Error(), pcs), true
+}
+
+// callerStack returns the stack trace from the caller skip frames up, as
+// runtime.Caller counts them in the caller of callerStack, formatted like
+// errorStack.
+func callerStack(msg string, skip int) string {
+	pcs := make([]uintptr, 32)
+	return formatStack(msg, pcs[:runtime.Callers(skip+2, pcs)])
+}
+
+// hasField reports whether fields has a field with the key.
+func hasField(fields []zapcore.Field, key string) bool {
+	for _, f := range fields {
+		if f.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// formatStack formats the frames of the program counters like a Go panic
+// with the message.
+func formatStack(msg string, pcs []uintptr) string {
+	var sb strings.Builder
+	sb.WriteString(msg)
+	sb.WriteString("\n\ngoroutine 1 [running]:\n")
+	frames := runtime.CallersFrames(pcs)
+	for {
+		frame, more := frames.Next()
+		if frame.Function != "" {
+			sb.WriteString(frame.Function)
+			sb.WriteString("()\n\t")
+			sb.WriteString(frame.File)
+			sb.WriteByte(':')
+			sb.WriteString(strconv.Itoa(frame.Line))
+			sb.WriteByte('\n')
+		}
+		if !more {
+			break
+		}
+	}
+	return sb.String()
+}
+
+// stacktraceOptions returns the options adding the stack traces of
+// Config.StacktraceFormat to the entries of Config.StacktraceLevel and above,
+// none keeping the defaults of zap. They must be applied after the others.
+func (l *Logger) stacktraceOptions(c *Config) ([]zap.Option, error) {
+	if c == nil || (c.StacktraceLevel == LevelFirst && c.StacktraceFormat == "") {
+		return nil, nil
+	}
+	level := zapcore.ErrorLevel
+	if c.StacktraceLevel != LevelFirst {
+		level = c.StacktraceLevel.zapLevel()
+	}
+	// No entry is above the fatal level.
+	never := zapcore.FatalLevel + 1
+	switch c.StacktraceFormat {
+	case "", "zap":
+		return []zap.Option{zap.AddStacktrace(level)}, nil
+	case "error_reporting":
+		l.stackLevel, l.stackReport = level, true
+		return []zap.Option{zap.AddStacktrace(never)}, nil
+	case "none":
+		return []zap.Option{zap.AddStacktrace(never)}, nil
+	}
+	return nil, fmt.Errorf("logging: unknown stack trace format %q", c.StacktraceFormat)
+}
+
+// stackTrace returns the program counters of a StackTrace method returning a
+// slice of uintptr based frames, as implemented by github.com/pkg/errors.
+func stackTrace(err error) []uintptr {
+	m := reflect.ValueOf(err).MethodByName("StackTrace")
+	if !m.IsValid() || m.Type().NumIn() != 0 || m.Type().NumOut() != 1 {
+		return nil
+	}
+	out := m.Type().Out(0)
+	if out.Kind() != reflect.Slice || out.Elem().Kind() != reflect.Uintptr {
+		return nil
+	}
+	frames := m.Call(nil)[0]
+	pcs := make([]uintptr, frames.Len())
+	for i := range pcs {
+		// Like runtime.Callers, pkg/errors records return addresses.
+		pcs[i] = uintptr(frames.Index(i).Uint())
+	}
+	return pcs
+}
@@ -0,0 +1,133 @@
+package logging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/context"
+)
+
+func TestStacktraceErrorReporting(t *testing.T) {
+	l, buf := newTestLogger(t, &Config{StacktraceLevel: LevelWarn, StacktraceFormat: "error_reporting"})
+	l.Warn(context.Background(), "disk %d%% full", 90)
+	l.Info(context.Background(), "fine")
+
+	got := entries(t, buf)
+	if len(got) != 2 {
+		t.Fatalf("got %d entries, want 2", len(got))
+	}
+	stack, _ := got[0][keyStackTrace].(string)
+	if !strings.HasPrefix(stack, "disk 90% full\n\ngoroutine 1 [running]:\n") {
+		t.Errorf("stack_trace = %q, want a panic formatted stack", stack)
+	}
+	if !strings.Contains(stack, "TestStacktraceErrorReporting") {
+		t.Errorf("stack_trace = %q, want it to start at the caller", stack)
+	}
+	if strings.Contains(stack, "zlogAt") {
+		t.Errorf("stack_trace = %q, want no frames of the logger", stack)
+	}
+	if _, ok := got[0]["stacktrace"]; ok {
+		t.Error("got the zap stacktrace too")
+	}
+	if _, ok := got[1][keyStackTrace]; ok {
+		t.Error("got a stack_trace below the stacktrace level")
+	}
+}
+
+func TestStacktraceNone(t *testing.T) {
+	// The console logger adds stack traces from the error level by default.
+	console := &bytes.Buffer{}
+	dev, err := New(&Config{Level: LevelDebug, StacktraceFormat: "none", Output: console})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dev.Error(context.Background(), "failed")
+	if lines := strings.Count(console.String(), "\n"); lines != 1 {
+		t.Errorf("got a stack trace on the console with the none format: %s", console)
+	}
+
+	l, buf := newTestLogger(t, &Config{StacktraceFormat: "none"})
+	l.Error(context.Background(), "failed")
+	e := entries(t, buf)[0]
+	if _, ok := e["stacktrace"]; ok {
+		t.Error("got a stacktrace with the none format")
+	}
+	if _, ok := e[keyStackTrace]; ok {
+		t.Error("got a stack_trace with the none format")
+	}
+}
+
+func TestStacktraceLevel(t *testing.T) {
+	l, buf := newTestLogger(t, &Config{StacktraceLevel: LevelWarn})
+	l.Warn(context.Background(), "slow")
+	l.Info(context.Background(), "fine")
+
+	got := entries(t, buf)
+	if _, ok := got[0]["stacktrace"]; !ok {
+		t.Error("got no stacktrace on a warning")
+	}
+	if _, ok := got[1]["stacktrace"]; ok {
+		t.Error("got a stacktrace below the stacktrace level")
+	}
+}
+
+func TestStacktraceUnknownFormat(t *testing.T) {
+	if _, err := New(&Config{ProjectID: "test", StacktraceFormat: "xml"}); err == nil {
+		t.Error("got no error for an unknown stack trace format")
+	}
+}
+
+// frame and stack mirror the stack traces of github.com/pkg/errors.
+type (
+	frame uintptr
+	stack []frame
+)
+
+// stackedError records the stack it was created at, like the errors of
+// github.com/pkg/errors.
+type stackedError struct {
+	msg   string
+	stack stack
+}
+
+func newStackedError(msg string) error {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(2, pcs)
+	e := &stackedError{msg: msg}
+	for _, pc := range pcs[:n] {
+		e.stack = append(e.stack, frame(pc))
+	}
+	return e
+}
+
+func (e *stackedError) Error() string     { return e.msg }
+func (e *stackedError) StackTrace() stack { return e.stack }
+
+func TestErrorStack(t *testing.T) {
+	l, buf := newTestLogger(t, &Config{})
+	err := fmt.Errorf("charging: %w", newStackedError("declined"))
+	l.Errorw(context.Background(), "failed", "error", err)
+	l.Errorw(context.Background(), "failed", "error", errors.New("no stack"))
+
+	got := entries(t, buf)
+	if len(got) != 2 {
+		t.Fatalf("got %d entries, want 2", len(got))
+	}
+	stack, _ := got[0]["stack_trace"].(string)
+	if !strings.HasPrefix(stack, "charging: declined\n\ngoroutine 1 [running]:\n") {
+		t.Errorf("stack_trace = %q, want a panic formatted stack", stack)
+	}
+	if !strings.Contains(stack, "logging.TestErrorStack()") {
+		t.Errorf("stack_trace = %q, want the stack the error was created at", stack)
+	}
+	if strings.Contains(stack, "newStackedError") {
+		t.Errorf("stack_trace = %q, want it to start at the caller of the constructor", stack)
+	}
+	if _, ok := got[1]["stack_trace"]; ok {
+		t.Errorf("got a stack_trace for an error without one: %v", got[1]["stack_trace"])
+	}
+}
@@ -0,0 +1,78 @@
+package logging
+
+import (
+	"io"
+	"log"
+	"strings"
+
+	"golang.org/x/net/context"
+)
+
+// writer is an io.Writer logging each write as an entry with a Logger, the
+// package level logger if l is nil.
+type writer struct {
+	l     *Logger
+	level Level
+}
+
+// Writer returns an io.Writer logging each write as an entry at the level
+// with the package level logger, the trailing newline trimmed, for the
+// dependencies only accepting a writer.
+func Writer(level Level) io.Writer {
+	return writer{level: level}
+}
+
+// Writer returns an io.Writer logging each write as an entry at the level
+// with l.
+func (l *Logger) Writer(level Level) io.Writer {
+	return writer{l: l, level: level}
+}
+
+// StdLogger returns a *log.Logger logging each message as an entry at the
+// level with the package level logger, e.g. for http.Server.ErrorLog.
+func StdLogger(level Level) *log.Logger {
+	return log.New(Writer(level), "", 0)
+}
+
+// StdLogger returns a *log.Logger logging each message as an entry at the
+// level with l.
+func (l *Logger) StdLogger(level Level) *log.Logger {
+	return log.New(l.Writer(level), "", 0)
+}
+
+func (w writer) Write(p []byte) (int, error) {
+	l := orStd(w.l)
+	ctx := context.Background()
+	if l.enabled(ctx, w.level) {
+		l.zentry(ctx, w.level, strings.TrimSuffix(string(p), "\n"), "", nil)
+	}
+	return len(p), nil
+}
+
+// RedirectStdLog redirects the output of the standard log package to the
+// package level logger at info level, so the messages logged by dependencies
+// with log.Printf don't break the parsing of the entries, and returns a
+// function restoring it. Messages logged with log.Panic and log.Fatal are
+// logged before they panic or exit, but the panics reaching the runtime are
+// written to the standard error output by the runtime as the process exits,
+// they must be recovered, e.g. by Recovery or Job, to be logged.
+func RedirectStdLog() func() {
+	return redirectStdLog(Writer(LevelInfo))
+}
+
+// RedirectStdLog redirects the output of the standard log package to l.
+func (l *Logger) RedirectStdLog() func() {
+	return redirectStdLog(l.Writer(LevelInfo))
+}
+
+func redirectStdLog(w io.Writer) func() {
+	flags, prefix, out := log.Flags(), log.Prefix(), log.Writer()
+	log.SetFlags(0)
+	log.SetPrefix("")
+	log.SetOutput(w)
+	return func() {
+		log.SetFlags(flags)
+		log.SetPrefix(prefix)
+		log.SetOutput(out)
+	}
+}
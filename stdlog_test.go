@@ -0,0 +1,46 @@
+package logging
+
+import (
+	"bytes"
+	"log"
+	"testing"
+)
+
+func TestStdLogger(t *testing.T) {
+	l, buf := newTestLogger(t, &Config{Level: LevelInfo})
+	l.StdLogger(LevelWarn).Printf("http: TLS handshake error from %s", "10.0.0.1:5000")
+	l.Writer(LevelError).Write([]byte("driver: bad connection\n"))
+	l.StdLogger(LevelDebug).Print("skipped")
+
+	got := entries(t, buf)
+	if len(got) != 2 {
+		t.Fatalf("got %d entries, want 2", len(got))
+	}
+	for i, want := range []struct{ severity, message string }{
+		{"WARNING", "http: TLS handshake error from 10.0.0.1:5000"},
+		{"ERROR", "driver: bad connection"},
+	} {
+		if got[i]["severity"] != want.severity || got[i]["message"] != want.message {
+			t.Errorf("entry %d: got %v %q, want %s %q", i, got[i]["severity"], got[i]["message"], want.severity, want.message)
+		}
+	}
+}
+
+func TestRedirectStdLog(t *testing.T) {
+	l, buf := newTestLogger(t, &Config{})
+	out, orig := &bytes.Buffer{}, log.Writer()
+	log.SetOutput(out)
+	defer log.SetOutput(orig)
+	restore := l.RedirectStdLog()
+	log.Printf("retrying in %ds", 5)
+	restore()
+	log.Print("after")
+
+	got := entries(t, buf)
+	if len(got) != 1 || got[0]["message"] != "retrying in 5s" || got[0]["severity"] != "INFO" {
+		t.Fatalf("got %v, want the message logged at info level", got)
+	}
+	if !bytes.HasSuffix(out.Bytes(), []byte("after\n")) {
+		t.Errorf("got output %q after restoring, want the message", out)
+	}
+}
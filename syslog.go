@@ -0,0 +1,158 @@
+package logging
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// SyslogOutput sends entries to a syslog daemon in the RFC 5424 format.
+type SyslogOutput struct {
+	// Network is "udp", "tcp" or "unix". Empty Network and Address connect to
+	// the local daemon.
+	Network string `json:"network" yaml:"network"`
+	Address string `json:"address" yaml:"address"`
+	// Facility is the numerical syslog facility, e.g. 1 for user-level
+	// messages or 16 for local0. It defaults to 1.
+	Facility int `json:"facility" yaml:"facility"`
+	// AppName defaults to the name of the executable.
+	AppName string `json:"app_name" yaml:"app_name"`
+}
+
+// syslogTimeFormat is RFC 3339 with the microsecond precision allowed by
+// RFC 5424.
+const syslogTimeFormat = "2006-01-02T15:04:05.000000Z07:00"
+
+// syslogSeverities maps zap levels to syslog severities.
+var syslogSeverities = map[zapcore.Level]int{
+	zapcore.DebugLevel:  7,
+	zapcore.InfoLevel:   6,
+	zapcore.WarnLevel:   4,
+	zapcore.ErrorLevel:  3,
+	zapcore.DPanicLevel: 2,
+	zapcore.PanicLevel:  1,
+	zapcore.FatalLevel:  0,
+}
+
+// syslogCore is a zapcore.Core formatting entries as RFC 5424 messages whose
+// content is the JSON encoded entry.
+type syslogCore struct {
+	zapcore.LevelEnabler
+	enc      zapcore.Encoder
+	w        *connWriter
+	facility int
+	hostname string
+	appName  string
+	procID   string
+}
+
+func newSyslogCore(out *SyslogOutput, enc zapcore.Encoder, enab zapcore.LevelEnabler) (*syslogCore, error) {
+	w, err := newConnWriter(func() (net.Conn, error) {
+		return dialSyslog(out.Network, out.Address)
+	}, sendSyslog)
+	if err != nil {
+		return nil, err
+	}
+	hostname, _ := os.Hostname()
+	if hostname == "" {
+		hostname = "-"
+	}
+	appName := out.AppName
+	if appName == "" {
+		appName = filepath.Base(os.Args[0])
+	}
+	facility := out.Facility
+	if facility == 0 {
+		facility = 1
+	}
+	return &syslogCore{
+		LevelEnabler: enab,
+		enc:          enc,
+		w:            w,
+		facility:     facility,
+		hostname:     hostname,
+		appName:      appName,
+		procID:       strconv.Itoa(os.Getpid()),
+	}, nil
+}
+
+func (c *syslogCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := *c
+	clone.enc = c.enc.Clone()
+	for _, f := range fields {
+		f.AddTo(clone.enc)
+	}
+	return &clone
+}
+
+func (c *syslogCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *syslogCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	if !c.Enabled(ent.Level) {
+		return nil
+	}
+	buf, err := c.enc.EncodeEntry(ent, fields)
+	if err != nil {
+		return err
+	}
+	defer buf.Free()
+	buf.TrimNewline()
+
+	severity, ok := syslogSeverities[ent.Level]
+	if !ok {
+		severity = 6
+	}
+	msg := fmt.Sprintf("<%d>1 %s %s %s %s - - %s",
+		c.facility*8+severity,
+		ent.Time.Format(syslogTimeFormat),
+		c.hostname,
+		c.appName,
+		c.procID,
+		buf.Bytes(),
+	)
+	return c.w.write([]byte(msg))
+}
+
+func (c *syslogCore) Sync() error {
+	return nil
+}
+
+// dialSyslog connects to the syslog daemon, the local one if network and
+// address are empty.
+func dialSyslog(network, address string) (net.Conn, error) {
+	if network != "" || address != "" {
+		return net.Dial(network, address)
+	}
+	for _, network := range []string{"unixgram", "unix"} {
+		for _, path := range []string{"/dev/log", "/var/run/syslog", "/var/run/log"} {
+			if conn, err := net.Dial(network, path); err == nil {
+				return conn, nil
+			}
+		}
+	}
+	return nil, errors.New("logging: no local syslog daemon found")
+}
+
+// sendSyslog frames the message for the connection: octet counting over TCP
+// as described by RFC 6587, a trailing newline over local stream sockets and
+// nothing over datagrams.
+func sendSyslog(conn net.Conn, msg []byte) error {
+	switch conn.RemoteAddr().Network() {
+	case "tcp", "tcp4", "tcp6":
+		msg = append([]byte(strconv.Itoa(len(msg))+" "), msg...)
+	case "unix":
+		msg = append(msg, '\n')
+	}
+	_, err := conn.Write(msg)
+	return err
+}
@@ -0,0 +1,108 @@
+package logging
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+var syslogHeader = regexp.MustCompile(`^<(\d+)>1 \d{4}-\d\d-\d\dT\d\d:\d\d:\d\d\.\d{6}(Z|[+-]\d\d:\d\d) \S+ app \d+ - - \{`)
+
+func TestSyslogUDP(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+
+	l, err := New(&Config{Level: LevelDebug, Syslog: &SyslogOutput{Network: "udp", Address: pc.LocalAddr().String(), AppName: "app"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	l.Warn(context.Background(), "hello %d", 1)
+
+	buf := make([]byte, 4096)
+	pc.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := pc.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := string(buf[:n])
+	m := syslogHeader.FindStringSubmatch(msg)
+	if m == nil {
+		t.Fatalf("message %q doesn't start with an RFC 5424 header", msg)
+	}
+	// Facility user (1) and severity warning (4).
+	if m[1] != "12" {
+		t.Errorf("got priority %s, want 12", m[1])
+	}
+	if !strings.Contains(msg, "hello 1") {
+		t.Errorf("message %q lacks the logged message", msg)
+	}
+	if strings.Contains(msg, "\x1b") {
+		t.Errorf("message %q contains color escapes", msg)
+	}
+}
+
+func TestSyslogTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	received := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		length, err := r.ReadString(' ')
+		if err != nil {
+			return
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(length))
+		if err != nil {
+			received <- "bad length " + length
+			return
+		}
+		buf := make([]byte, n)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return
+		}
+		received <- string(buf)
+	}()
+
+	l, err := New(&Config{ProjectID: "p", Level: LevelDebug, Syslog: &SyslogOutput{Network: "tcp", Address: ln.Addr().String(), Facility: 16, AppName: "app"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	l.Error(context.Background(), "boom")
+
+	select {
+	case msg := <-received:
+		m := syslogHeader.FindStringSubmatch(msg)
+		if m == nil {
+			t.Fatalf("message %q doesn't start with an RFC 5424 header", msg)
+		}
+		// Facility local0 (16) and severity error (3).
+		if m[1] != "131" {
+			t.Errorf("got priority %s, want 131", m[1])
+		}
+		if !strings.HasSuffix(msg, "}") {
+			t.Errorf("message %q isn't framed by its length", msg)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message received")
+	}
+}
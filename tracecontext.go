@@ -0,0 +1,93 @@
+package logging
+
+import (
+	"encoding/binary"
+	"strconv"
+	"strings"
+
+	"github.com/blendle/zapdriver"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap/zapcore"
+	"golang.org/x/net/context"
+)
+
+// cloudTraceHeader is the trace header of Google Cloud load balancers and
+// services, TRACE_ID/SPAN_ID;o=OPTIONS with a decimal span ID.
+const cloudTraceHeader = "X-Cloud-Trace-Context"
+
+// headerCarrier reads request headers for the propagators.
+type headerCarrier func(key string) string
+
+func (h headerCarrier) Get(key string) string { return h(key) }
+func (h headerCarrier) Set(string, string)    {}
+func (h headerCarrier) Keys() []string        { return nil }
+
+// withHeaderTrace returns a copy of the request context carrying the remote
+// span context of the traceparent or X-Cloud-Trace-Context header, for
+// services not instrumented with OpenTelemetry. ctx is returned as is if it
+// already carries a span context.
+func withHeaderTrace(ctx context.Context, header func(key string) string) context.Context {
+	if trace.SpanContextFromContext(ctx).IsValid() {
+		return ctx
+	}
+	if sc := trace.SpanContextFromContext(propagation.TraceContext{}.Extract(ctx, headerCarrier(header))); sc.IsValid() {
+		return trace.ContextWithRemoteSpanContext(ctx, sc)
+	}
+	if sc, ok := parseCloudTrace(header(cloudTraceHeader)); ok {
+		return trace.ContextWithRemoteSpanContext(ctx, sc)
+	}
+	return ctx
+}
+
+// withHeaderBaggage returns a copy of the request context carrying the
+// members of the baggage header, unless it already carries baggage.
+func withHeaderBaggage(ctx context.Context, header func(key string) string) context.Context {
+	if baggage.FromContext(ctx).Len() > 0 {
+		return ctx
+	}
+	return propagation.Baggage{}.Extract(ctx, headerCarrier(header))
+}
+
+// parseCloudTrace parses the value of an X-Cloud-Trace-Context header.
+func parseCloudTrace(value string) (trace.SpanContext, bool) {
+	traceValue, rest, _ := strings.Cut(value, "/")
+	traceID, err := trace.TraceIDFromHex(traceValue)
+	if err != nil {
+		return trace.SpanContext{}, false
+	}
+	spanValue, options, _ := strings.Cut(rest, ";")
+	var spanID trace.SpanID
+	if n, err := strconv.ParseUint(spanValue, 10, 64); err == nil {
+		binary.BigEndian.PutUint64(spanID[:], n)
+	}
+	var flags trace.TraceFlags
+	if options == "o=1" {
+		flags = trace.FlagsSampled
+	}
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: flags,
+		Remote:     true,
+	})
+	// The span ID is optional, the context is not valid without one.
+	return sc, sc.HasTraceID()
+}
+
+// traceFields returns the fields linking an entry logged with ctx to its
+// trace in Cloud Trace, with its sampling decision.
+func (l *Logger) traceFields(ctx context.Context) []zapcore.Field {
+	sc := trace.SpanContextFromContext(ctx)
+	if l.projectID == "" || !l.linksTrace(sc) {
+		return nil
+	}
+	return zapdriver.TraceContext(sc.TraceID().String(), sc.SpanID().String(), sc.IsSampled(), l.projectID)
+}
+
+// linksTrace reports whether entries of the span context are linked to its
+// trace.
+func (l *Logger) linksTrace(sc trace.SpanContext) bool {
+	return sc.HasTraceID() && (sc.IsSampled() || !l.sampledTraces)
+}
@@ -0,0 +1,113 @@
+package logging
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/net/context"
+)
+
+func TestParseCloudTrace(t *testing.T) {
+	for _, tt := range []struct {
+		value   string
+		ok      bool
+		traceID string
+		spanID  string
+		sampled bool
+	}{
+		{"105445aa7843bc8bf206b12000100000/1;o=1", true, "105445aa7843bc8bf206b12000100000", "0000000000000001", true},
+		{"105445aa7843bc8bf206b12000100000/255;o=0", true, "105445aa7843bc8bf206b12000100000", "00000000000000ff", false},
+		{"105445aa7843bc8bf206b12000100000", true, "105445aa7843bc8bf206b12000100000", "0000000000000000", false},
+		{"not-a-trace/1;o=1", false, "", "", false},
+		{"", false, "", "", false},
+	} {
+		sc, ok := parseCloudTrace(tt.value)
+		if ok != tt.ok {
+			t.Errorf("parseCloudTrace(%q) ok = %v, want %v", tt.value, ok, tt.ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if sc.TraceID().String() != tt.traceID || sc.SpanID().String() != tt.spanID || sc.IsSampled() != tt.sampled {
+			t.Errorf("parseCloudTrace(%q) = %s/%s sampled %v, want %s/%s sampled %v", tt.value,
+				sc.TraceID(), sc.SpanID(), sc.IsSampled(), tt.traceID, tt.spanID, tt.sampled)
+		}
+	}
+}
+
+func TestRequestLoggerHeaderTrace(t *testing.T) {
+	l, buf := newTestLogger(t, &Config{})
+	r := gin.New()
+	r.Use(l.RequestLogger())
+	var spans []trace.SpanContext
+	r.GET("/", func(c *gin.Context) {
+		spans = append(spans, trace.SpanContextFromContext(c.Request.Context()))
+		c.Status(200)
+	})
+	for _, header := range []struct{ key, value string }{
+		{"traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+		{"X-Cloud-Trace-Context", "105445aa7843bc8bf206b12000100000/1;o=1"},
+	} {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set(header.key, header.value)
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	want := []string{"4bf92f3577b34da6a3ce929d0e0e4736", "105445aa7843bc8bf206b12000100000"}
+	got := entries(t, buf)
+	if len(got) != 2 || len(spans) != 2 {
+		t.Fatalf("got %d entries and %d requests, want 2", len(got), len(spans))
+	}
+	for i, traceID := range want {
+		if id := spans[i].TraceID().String(); id != traceID || !spans[i].IsRemote() {
+			t.Errorf("request %d: got trace %s remote %v, want remote trace %s", i, id, spans[i].IsRemote(), traceID)
+		}
+		if id := labels(got[i])["request_id"]; id != traceID {
+			t.Errorf("request %d: logged request ID %v, want %s", i, id, traceID)
+		}
+		if tr := got[i]["logging.googleapis.com/trace"]; tr != "projects/test/traces/"+traceID {
+			t.Errorf("request %d: logged trace %v, want %s", i, tr, traceID)
+		}
+	}
+}
+
+func TestTraceSampled(t *testing.T) {
+	traceID := trace.TraceID{1}
+	sampled := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     trace.SpanID{2},
+		TraceFlags: trace.FlagsSampled,
+	}))
+	unsampled := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID,
+		SpanID:  trace.SpanID{2},
+	}))
+	for _, tt := range []struct {
+		sampledOnly bool
+		ctx         context.Context
+		want        interface{}
+	}{
+		{false, sampled, true},
+		{false, unsampled, false},
+		{true, sampled, true},
+		{true, unsampled, nil},
+		{false, context.Background(), nil},
+	} {
+		l, buf := newTestLogger(t, &Config{SampledTracesOnly: tt.sampledOnly})
+		l.Info(tt.ctx, "hello")
+		got := entries(t, buf)
+		if len(got) != 1 {
+			t.Fatalf("got %d entries, want 1", len(got))
+		}
+		if s := got[0]["logging.googleapis.com/trace_sampled"]; s != tt.want {
+			t.Errorf("sampled only %v, sampled %v: got trace_sampled %v, want %v", tt.sampledOnly, trace.SpanContextFromContext(tt.ctx).IsSampled(), s, tt.want)
+		}
+		_, hasTrace := got[0]["logging.googleapis.com/trace"]
+		if hasTrace != (tt.want != nil) {
+			t.Errorf("sampled only %v: got trace %v, want it only if linked", tt.sampledOnly, got[0]["logging.googleapis.com/trace"])
+		}
+	}
+}
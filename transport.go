@@ -0,0 +1,67 @@
+package logging
+
+import (
+	"net/http"
+
+	"github.com/blendle/zapdriver"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Transport wraps an HTTP transport, http.DefaultTransport if base is nil,
+// to log the outgoing requests as the access logs of the requests they are
+// made for. The request ID of their context is sent in the X-Request-ID
+// header if they are not traced.
+func Transport(base http.RoundTripper) http.RoundTripper {
+	return &transport{base: base}
+}
+
+// Transport wraps an HTTP transport to log the outgoing requests with l.
+func (l *Logger) Transport(base http.RoundTripper) http.RoundTripper {
+	return &transport{logger: l, base: base}
+}
+
+type transport struct {
+	logger *Logger
+	base   http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	l := orStd(t.logger)
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	ctx := req.Context()
+	if requestID, ok := RequestIDFromContext(ctx); ok && req.Header.Get(requestIDHeader) == "" {
+		// A RoundTripper must not modify the request.
+		req = req.Clone(ctx)
+		req.Header.Set(requestIDHeader, requestID)
+	}
+	start := l.clock.Now()
+	res, err := base.RoundTrip(req)
+	latency := l.since(start)
+
+	level := LevelError
+	logged := &http.Response{ContentLength: -1}
+	if err == nil {
+		level = l.statusLevel(res.StatusCode)
+		logged.StatusCode = res.StatusCode
+		logged.ContentLength = res.ContentLength
+	}
+	if level.zapLevel() >= zapcore.ErrorLevel {
+		l.flushDebug(ctx)
+	}
+	if !l.enabled(ctx, level) {
+		return res, err
+	}
+	// The bodies are left to the caller.
+	loggedReq := *req
+	loggedReq.Body = nil
+	fields := []zapcore.Field{zapdriver.HTTP(httpPayload(&loggedReq, logged, latency))}
+	if err != nil {
+		fields = append(fields, zap.NamedError(l.keyError, err))
+	}
+	l.zentry(ctx, level, "http call", "", fields)
+	return res, err
+}
@@ -0,0 +1,78 @@
+package logging
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/context"
+)
+
+func TestTransport(t *testing.T) {
+	l, buf := newTestLogger(t, &Config{})
+	var requestIDs []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestIDs = append(requestIDs, r.Header.Get("X-Request-ID"))
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		io.WriteString(w, "hello")
+	}))
+	defer srv.Close()
+	client := &http.Client{Transport: l.Transport(nil)}
+	ctx := WithRequestID(context.Background(), "req-1")
+
+	for _, path := range []string{"/", "/missing"} {
+		req, _ := http.NewRequestWithContext(ctx, "POST", srv.URL+path, strings.NewReader("body"))
+		res, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if body, _ := io.ReadAll(res.Body); path == "/" && string(body) != "hello" {
+			t.Errorf("got body %q, want it left to the caller", body)
+		}
+		res.Body.Close()
+		if req.Header.Get("X-Request-ID") != "" {
+			t.Error("the request was modified")
+		}
+	}
+	req, _ := http.NewRequestWithContext(ctx, "GET", "http://127.0.0.1:1/", nil)
+	if _, err := client.Do(req); err == nil {
+		t.Fatal("got no error from a closed port")
+	}
+
+	if requestIDs[0] != "req-1" || requestIDs[1] != "req-1" {
+		t.Errorf("server got request IDs %v, want req-1", requestIDs)
+	}
+	got := entries(t, buf)
+	if len(got) != 3 {
+		t.Fatalf("got %d entries, want 3", len(got))
+	}
+	for i, want := range []struct {
+		severity string
+		status   interface{}
+		size     interface{}
+	}{
+		{"INFO", float64(200), "5"},
+		{"WARNING", float64(404), "19"},
+		{"ERROR", float64(0), ""},
+	} {
+		e := got[i]
+		req, _ := e["httpRequest"].(map[string]interface{})
+		if e["message"] != "http call" || e["severity"] != want.severity || req["status"] != want.status || req["responseSize"] != want.size {
+			t.Errorf("entry %d: got %v %v status %v size %v, want %s %v %v", i, e["message"], e["severity"], req["status"], req["responseSize"], want.severity, want.status, want.size)
+		}
+		if id := labels(e)["request_id"]; id != "req-1" {
+			t.Errorf("entry %d: got request ID %v, want req-1", i, id)
+		}
+	}
+	if req, _ := got[0]["httpRequest"].(map[string]interface{}); req["requestSize"] != "4" || req["latency"] == "" {
+		t.Errorf("got request %v, want its size and latency", req)
+	}
+	if got[2]["err"] == nil {
+		t.Error("got no error logged for the failed request")
+	}
+}
@@ -0,0 +1,129 @@
+package logging
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// truncatedKey is the field marking the entries truncated.
+const truncatedKey = "truncated"
+
+// truncator truncates the messages and field values of entries longer than
+// Config.MaxMessageLength and Config.MaxFieldLength, a nil truncator
+// truncating nothing.
+type truncator struct {
+	message int
+	field   int
+	// skip lists the fields never truncated, such as the request ID.
+	skip map[string]struct{}
+}
+
+func newTruncator(message, field int, skip ...string) *truncator {
+	if message <= 0 && field <= 0 {
+		return nil
+	}
+	return &truncator{message: message, field: field, skip: keySet(skip)}
+}
+
+// entry returns the message and fields of an entry truncated, marked with
+// truncated=true if any is.
+func (t *truncator) entry(msg string, fields []zapcore.Field) (string, []zapcore.Field) {
+	if t == nil {
+		return msg, fields
+	}
+	msg, truncated := truncateString(msg, t.message)
+	out, copied := fields, false
+	for i, f := range fields {
+		tf, ok := t.fieldValue(f)
+		if !ok {
+			continue
+		}
+		if !copied {
+			out, copied = append([]zapcore.Field(nil), fields...), true
+		}
+		out[i] = tf
+		truncated = true
+	}
+	if truncated {
+		out = append(out[:len(out):len(out)], zap.Bool(truncatedKey, true))
+	}
+	return msg, out
+}
+
+// fieldValue returns a field truncated, and whether it was.
+func (t *truncator) fieldValue(f zapcore.Field) (zapcore.Field, bool) {
+	if _, ok := t.skip[f.Key]; ok || t.field <= 0 || strings.HasPrefix(f.Key, zapdriverPrefix) {
+		return f, false
+	}
+	switch f.Type {
+	case zapcore.StringType:
+		if s, ok := truncateString(f.String, t.field); ok {
+			return zap.String(f.Key, s), true
+		}
+	case zapcore.ByteStringType, zapcore.BinaryType:
+		if b, _ := f.Interface.([]byte); len(b) > t.field {
+			if f.Type == zapcore.BinaryType {
+				return zap.Binary(f.Key, b[:t.field]), true
+			}
+			s, _ := truncateString(string(b), t.field)
+			return zap.String(f.Key, s), true
+		}
+	case zapcore.ErrorType, zapcore.StringerType:
+		if s, ok := truncateString(fmt.Sprint(f.Interface), t.field); ok {
+			return zap.String(f.Key, s), true
+		}
+	case zapcore.ObjectMarshalerType, zapcore.ArrayMarshalerType, zapcore.ReflectType:
+		m := zapcore.NewMapObjectEncoder()
+		f.AddTo(m)
+		if v, ok := t.value(m.Fields[f.Key]); ok {
+			return zap.Any(f.Key, v), true
+		}
+	}
+	return f, false
+}
+
+// value truncates the strings of a value decoded by a
+// zapcore.MapObjectEncoder in place, and reports whether any was.
+func (t *truncator) value(v interface{}) (interface{}, bool) {
+	switch v := v.(type) {
+	case string:
+		return truncateString(v, t.field)
+	case []byte:
+		return truncateString(string(v), t.field)
+	case map[string]interface{}:
+		truncated := false
+		for k, e := range v {
+			if e, ok := t.value(e); ok {
+				v[k] = e
+				truncated = true
+			}
+		}
+		return v, truncated
+	case []interface{}:
+		truncated := false
+		for i, e := range v {
+			if e, ok := t.value(e); ok {
+				v[i] = e
+				truncated = true
+			}
+		}
+		return v, truncated
+	}
+	return v, false
+}
+
+// truncateString returns the first max bytes of s, without splitting a
+// character, and whether it was truncated. max <= 0 doesn't truncate.
+func truncateString(s string, max int) (string, bool) {
+	if max <= 0 || len(s) <= max {
+		return s, false
+	}
+	for max > 0 && !utf8.RuneStart(s[max]) {
+		max--
+	}
+	return s[:max], true
+}
@@ -0,0 +1,58 @@
+package logging
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"golang.org/x/net/context"
+)
+
+func TestTruncation(t *testing.T) {
+	l, buf := newTestLogger(t, &Config{MaxMessageLength: 8, MaxFieldLength: 4})
+	ctx := context.Background()
+	l.Infow(ctx, "héllo world", "blob", strings.Repeat("x", 100), "err", errors.New("boom boom"), zap.Any("nested", map[string]interface{}{"v": "abcdefgh"}))
+	l.Infow(ctx, "short", "blob", "xs")
+
+	got := entries(t, buf)
+	if len(got) != 2 {
+		t.Fatalf("got %d entries, want 2", len(got))
+	}
+	if msg := got[0]["message"]; msg != "héllo w" {
+		t.Errorf("got message %q, want %q", msg, "héllo w")
+	}
+	if blob := labels(got[0])["blob"]; blob != "xxxx" {
+		t.Errorf("got blob %v, want xxxx", blob)
+	}
+	if err := labels(got[0])["err"]; err != "boom" {
+		t.Errorf("got err %v, want boom", err)
+	}
+	if nested, _ := got[0]["nested"].(map[string]interface{}); nested["v"] != "abcd" {
+		t.Errorf("got nested %v, want v truncated", got[0]["nested"])
+	}
+	if got[0][truncatedKey] != true {
+		t.Errorf("got truncated %v, want true", got[0][truncatedKey])
+	}
+	if _, ok := got[1][truncatedKey]; ok || got[1]["message"] != "short" || labels(got[1])["blob"] != "xs" {
+		t.Errorf("got %v, want the entry unchanged", got[1])
+	}
+}
+
+func TestTruncateString(t *testing.T) {
+	for _, c := range []struct {
+		s    string
+		max  int
+		want string
+		ok   bool
+	}{
+		{"abc", 0, "abc", false},
+		{"abc", 3, "abc", false},
+		{"abcd", 3, "abc", true},
+		{"aé", 2, "a", true},
+	} {
+		if got, ok := truncateString(c.s, c.max); got != c.want || ok != c.ok {
+			t.Errorf("truncateString(%q, %d) = %q, %v, want %q, %v", c.s, c.max, got, ok, c.want, c.ok)
+		}
+	}
+}
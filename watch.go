@@ -0,0 +1,223 @@
+package logging
+
+import (
+	"encoding/json"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"golang.org/x/net/context"
+	"gopkg.in/yaml.v3"
+)
+
+// LoadConfig reads a JSON or YAML configuration file, depending on its
+// extension. Durations are either strings such as "1.5s", or numbers of
+// nanoseconds in JSON.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	c := &Config{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, c)
+	default:
+		err = unmarshalJSONConfig(data, c)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// unmarshalJSONConfig decodes a JSON configuration, parsing the duration
+// strings json.Unmarshal rejects.
+func unmarshalJSONConfig(data []byte, c *Config) error {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	v, err := parseDurations(v, reflect.TypeOf(c).Elem())
+	if err != nil {
+		return err
+	}
+	if data, err = json.Marshal(v); err != nil {
+		return err
+	}
+	return json.Unmarshal(data, c)
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// parseDurations replaces the duration strings of a decoded JSON value of
+// type t with their number of nanoseconds.
+func parseDurations(v interface{}, t reflect.Type) (interface{}, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch v := v.(type) {
+	case string:
+		if t != durationType {
+			return v, nil
+		}
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, err
+		}
+		return int64(d), nil
+	case []interface{}:
+		if t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
+			return v, nil
+		}
+		for i, e := range v {
+			var err error
+			if v[i], err = parseDurations(e, t.Elem()); err != nil {
+				return nil, err
+			}
+		}
+	case map[string]interface{}:
+		for k, e := range v {
+			var et reflect.Type
+			switch t.Kind() {
+			case reflect.Map:
+				et = t.Elem()
+			case reflect.Struct:
+				f, ok := jsonField(t, k)
+				if !ok {
+					continue
+				}
+				et = f.Type
+			default:
+				return v, nil
+			}
+			var err error
+			if v[k], err = parseDurations(e, et); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return v, nil
+}
+
+// jsonField returns the field of the struct type t a JSON key is decoded
+// into, matching the names case-insensitively as json.Unmarshal.
+func jsonField(t reflect.Type, key string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" || !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		if strings.EqualFold(name, key) {
+			return f, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// WatchConfig initializes the logger module from the configuration file and
+// reinitializes it whenever the file changes or the process receives SIGHUP.
+// The settings that can't be read from a file, such as Output, Core, Clock
+// and LoggerProvider, are kept from the last configuration the module was
+// initialized with. The returned function stops watching, and may be called
+// again.
+func WatchConfig(path string) (func(), error) {
+	if err := reloadConfig(path); err != nil {
+		return nil, err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	// Watch the directory rather than the file so that atomic replacements,
+	// such as Kubernetes ConfigMap updates, are noticed.
+	path = filepath.Clean(path)
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		watcher.Close()
+		return nil, err
+	}
+	realPath, _ := filepath.EvalSymlinks(path)
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-hup:
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				currentPath, _ := filepath.EvalSymlinks(path)
+				changed := filepath.Clean(event.Name) == path && event.Has(fsnotify.Write|fsnotify.Create)
+				if !changed && currentPath == realPath {
+					continue
+				}
+				realPath = currentPath
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				Error(context.Background(), "watching logging config %s: %v", path, err)
+				continue
+			}
+			if err := reloadConfig(path); err != nil {
+				Error(context.Background(), "reloading logging config %s: %v", path, err)
+			}
+		}
+	}()
+
+	var stopOnce sync.Once
+	return func() {
+		stopOnce.Do(func() {
+			signal.Stop(hup)
+			close(done)
+			watcher.Close()
+		})
+	}, nil
+}
+
+func reloadConfig(path string) error {
+	c, err := LoadConfig(path)
+	if err != nil {
+		return err
+	}
+	c.keepProgrammatic(lastConfig.Load())
+	return Initialize(c)
+}
+
+// lastConfig holds the last configuration the package level logger was
+// initialized with.
+var lastConfig atomic.Pointer[Config]
+
+// keepProgrammatic copies the settings of old that can't be read from a file
+// to c.
+func (c *Config) keepProgrammatic(old *Config) {
+	if old == nil {
+		return
+	}
+	c.Clock = old.Clock
+	c.LoggerProvider = old.LoggerProvider
+	c.Output = old.Output
+	c.Core = old.Core
+	c.Sinks = old.Sinks
+	if c.PubSub != nil && old.PubSub != nil {
+		c.PubSub.Options = old.PubSub.Options
+	}
+}
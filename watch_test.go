@@ -0,0 +1,153 @@
+package logging
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestLoadConfigDurations(t *testing.T) {
+	dir := t.TempDir()
+	for name, data := range map[string]string{
+		"config.json": `{"level": "warn", "flush_interval": "1.5s", "slow_query": 2000000000,
+			"slow_routes": {"/export": "1m"}, "warn_escalation": {"count": 3, "window": "10s"}}`,
+		"config.yaml": "level: warn\nflush_interval: 1.5s\nslow_query: 2s\nslow_routes:\n  /export: 1m\n" +
+			"warn_escalation:\n  count: 3\n  window: 10s\n",
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		c, err := LoadConfig(path)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if c.Level != LevelWarn || c.FlushInterval != 1500*time.Millisecond || c.SlowQuery != 2*time.Second ||
+			c.SlowRoutes["/export"] != time.Minute || c.WarnEscalation.Window != 10*time.Second {
+			t.Errorf("%s: got %+v", name, c)
+		}
+	}
+}
+
+func TestLoadConfigInvalidDuration(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"flush_interval": "soon"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadConfig(path); err == nil {
+		t.Error("got no error for an invalid duration")
+	}
+}
+
+// writeConfig writes a configuration file of the level.
+func writeConfig(t *testing.T, path, level string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(`{"project_id": "test", "level": "`+level+`"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// waitLevel waits for the package level logger to be reloaded at the level.
+func waitLevel(t *testing.T, level Level) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for GetLevel() != level {
+		if time.Now().After(deadline) {
+			t.Fatalf("got level %v, want %v", GetLevel(), level)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// watchConfig watches the configuration file with an output kept by the
+// reloads.
+func watchConfig(t *testing.T, path string) *bytes.Buffer {
+	t.Helper()
+	useStd(t, newLogger())
+	t.Cleanup(func() { lastConfig.Store(nil) })
+	buf := &bytes.Buffer{}
+	if err := Initialize(&Config{ProjectID: "test", Output: buf}); err != nil {
+		t.Fatal(err)
+	}
+	stop, err := WatchConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(stop)
+	return buf
+}
+
+func TestWatchConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	writeConfig(t, path, "info")
+	buf := watchConfig(t, path)
+	waitLevel(t, LevelInfo)
+
+	writeConfig(t, path, "error")
+	waitLevel(t, LevelError)
+	Error(context.Background(), "after reload")
+	got := entries(t, buf)
+	if len(got) != 1 || got[0]["message"] != "after reload" {
+		t.Errorf("got %v, want the entry written to the output kept", got)
+	}
+}
+
+func TestWatchConfigSymlinkSwap(t *testing.T) {
+	// Kubernetes mounts ConfigMaps as a symlink to a directory swapped on
+	// updates.
+	dir := t.TempDir()
+	for _, version := range []string{"v1", "v2"} {
+		if err := os.Mkdir(filepath.Join(dir, version), 0o700); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeConfig(t, filepath.Join(dir, "v1", "config.json"), "info")
+	writeConfig(t, filepath.Join(dir, "v2", "config.json"), "warn")
+	if err := os.Symlink("v1", filepath.Join(dir, "..data")); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "config.json")
+	if err := os.Symlink(filepath.Join("..data", "config.json"), path); err != nil {
+		t.Fatal(err)
+	}
+	watchConfig(t, path)
+	waitLevel(t, LevelInfo)
+
+	if err := os.Symlink("v2", filepath.Join(dir, "..data_tmp")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(filepath.Join(dir, "..data_tmp"), filepath.Join(dir, "..data")); err != nil {
+		t.Fatal(err)
+	}
+	waitLevel(t, LevelWarn)
+}
+
+func TestWatchConfigSIGHUP(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	writeConfig(t, path, "warn")
+	watchConfig(t, path)
+	waitLevel(t, LevelWarn)
+
+	SetLevel(LevelDebug)
+	if err := syscall.Kill(os.Getpid(), syscall.SIGHUP); err != nil {
+		t.Fatal(err)
+	}
+	waitLevel(t, LevelWarn)
+}
+
+func TestWatchConfigStopTwice(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	writeConfig(t, path, "info")
+	useStd(t, newLogger())
+	stop, err := WatchConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stop()
+	stop()
+}